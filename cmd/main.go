@@ -19,9 +19,13 @@ import (
 
 	"github.com/MartynyukAlexey/gymshark/internal/api"
 	"github.com/MartynyukAlexey/gymshark/internal/config"
+	"github.com/MartynyukAlexey/gymshark/internal/geoip"
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
 	"github.com/MartynyukAlexey/gymshark/internal/service"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
 	"github.com/MartynyukAlexey/gymshark/internal/smtp"
 	"github.com/MartynyukAlexey/gymshark/internal/storage"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/memory"
 )
 
 func main() {
@@ -48,23 +52,112 @@ func main() {
 		os.Exit(-1)
 	}
 
-	store := storage.NewStorage(postgres, minioClient)
+	store := storage.NewStorage(postgres, minioClient, config.Minio.AvatarBucket)
+
+	if config.Auth.AttemptStore == "memory" {
+		store.Attempt = memory.NewAttemptStorage()
+	}
+
+	revokedTokens := memory.NewRevokedTokenCache(store.RevokedToken)
+	if err := revokedTokens.Sync(); err != nil {
+		logger.Error("revoked tokens loading error", "err", err.Error())
+		os.Exit(-1)
+	}
+	store.RevokedToken = revokedTokens
 
 	mailer := smtp.NewSMTPMailer(config.Mailer, logger)
 
+	authKeys, err := auth.NewKeySet(config.Auth)
+	if err != nil {
+		logger.Error("jwt keys loading error", "err", err.Error())
+		os.Exit(-1)
+	}
+
+	hasher, err := hashing.NewHasher(config.Auth)
+	if err != nil {
+		logger.Error("password hasher setup error", "err", err.Error())
+		os.Exit(-1)
+	}
+
+	var geoIP *geoip.DB
+	if config.Auth.GeoIPDatabase != "" {
+		geoIP, err = geoip.Open(config.Auth.GeoIPDatabase)
+		if err != nil {
+			logger.Error("geoip database loading error", "err", err.Error())
+			os.Exit(-1)
+		}
+	}
+
+	trustedProxies, err := api.ParseTrustedProxies(config.Server.TrustedProxies)
+	if err != nil {
+		logger.Error("trusted proxies parsing error", "err", err.Error())
+		os.Exit(-1)
+	}
+
 	svc := service.NewService(&service.ServiceOpts{
 		Storage:    store,
 		Mailer:     mailer,
 		Logger:     logger,
 		AuthConfig: config.Auth,
+		AuthKeys:   authKeys,
+		Hasher:     hasher,
+		OIDCConfig: config.OIDC,
+		GeoIP:      geoIP,
 	})
 
+	if !svc.Auth.OAuthEnabled() {
+		logger.Warn("oauth authorization server is disabled, it requires the RS256 or EdDSA jwt algorithm")
+	}
+
+	if err := svc.Auth.BootstrapSuperadmins(); err != nil {
+		logger.Error("superadmins bootstrap error", "err", err.Error())
+		os.Exit(1)
+	}
+
+	go func() {
+		for range time.Tick(config.Auth.AttemptWindow) {
+			svc.Auth.PruneAttempts()
+		}
+	}()
+
+	go func() {
+		for range time.Tick(config.Auth.RevokedTokenSyncInterval) {
+			if err := revokedTokens.Sync(); err != nil {
+				logger.Error("failed to sync revoked tokens", "err", err)
+			}
+		}
+	}()
+
+	go func() {
+		for range time.Tick(config.Auth.AccessTokenTTL) {
+			svc.Auth.PruneRevokedTokens()
+		}
+	}()
+
+	go func() {
+		for range time.Tick(time.Hour) {
+			svc.Auth.PruneSignIns()
+		}
+	}()
+
+	go func() {
+		for range time.Tick(time.Hour) {
+			svc.Auth.PurgeDeletedAccounts()
+		}
+	}()
+
+	go func() {
+		for range time.Tick(time.Minute) {
+			svc.Auth.LiftExpiredSuspensions()
+		}
+	}()
+
 	server := &http.Server{
 		Addr:         ":" + strconv.Itoa(config.Server.Port),
 		WriteTimeout: config.Server.WriteTimeout,
 		ReadTimeout:  config.Server.ReadTimeout,
 		IdleTimeout:  config.Server.IdleTimeout,
-		Handler:      api.Routes(svc, logger),
+		Handler:      api.TrustProxies(trustedProxies, api.Routes(svc, logger)),
 	}
 
 	go func() {
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleDeleteAccount(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.DeleteAccountReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		if err := svc.DeleteAccount(reqctx.UserID(r.Context()), &req); err != nil {
+			switch err {
+			case auth.ErrInvalidPassword:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrOwnsOAuthClients:
+				serveError(w, err.Error(), http.StatusConflict)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrTooManyAttempts:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		// every session was revoked, including this one
+		clearTokenCookies(w, svc.Cfg)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "account was deleted, it can be restored from the link sent to your email",
+			},
+		)
+	}
+}
+
+// HandleRestoreAccount is a GET, it is opened from the link in the
+// account deleted email.
+func HandleRestoreAccount(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := svc.RestoreAccount(r.URL.Query().Get("token")); err != nil {
+			switch err {
+			case auth.ErrInvalidRestoreToken:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "account was restored, please log in again",
+			},
+		)
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleCreateAPIKey(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.CreateAPIKeyReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		key, err := svc.CreateAPIKey(reqctx.UserID(r.Context()), &req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidAPIKeyName, auth.ErrInvalidScope, auth.ErrInvalidExpiry:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string                `json:"status"`
+				Key    auth.CreateAPIKeyResp `json:"api_key"`
+			}{
+				Status: "ok",
+				Key:    key,
+			},
+		)
+	}
+}
+
+func HandleAPIKeys(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := svc.APIKeys(reqctx.UserID(r.Context()))
+		if err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string        `json:"status"`
+				Keys   []auth.APIKey `json:"api_keys"`
+			}{
+				Status: "ok",
+				Keys:   keys,
+			},
+		)
+	}
+}
+
+func HandleRevokeAPIKey(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid api key id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.RevokeAPIKey(reqctx.UserID(r.Context()), keyID); err != nil {
+			switch err {
+			case auth.ErrAPIKeyNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "api key was revoked",
+			},
+		)
+	}
+}
@@ -18,6 +18,8 @@ func HandleConfirmation(svc *auth.Service, logger *slog.Logger) http.HandlerFunc
 			return
 		}
 
+		req.Client = clientInfo(r)
+
 		if err := svc.Confirm(&req); err != nil {
 			switch err {
 			case auth.ErrInvalidCode:
@@ -28,6 +30,12 @@ func HandleConfirmation(svc *auth.Service, logger *slog.Logger) http.HandlerFunc
 				serveError(w, err.Error(), http.StatusConflict)
 			case auth.ErrCodeExpired:
 				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrTooManyAttempts:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
+			case auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
 			default:
 				serveError(w, "internal error", http.StatusInternalServerError)
 			}
@@ -49,3 +57,39 @@ func HandleConfirmation(svc *auth.Service, logger *slog.Logger) http.HandlerFunc
 		)
 	}
 }
+
+func HandleResendConfirmation(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.ResendConfirmationReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.ResendConfirmation(&req); err != nil {
+			switch err {
+			case auth.ErrInvalidEmail:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			}{
+				Status:  "ok",
+				Message: "if the account is waiting for confirmation, a new code was sent",
+			},
+		)
+	}
+}
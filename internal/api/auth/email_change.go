@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleChangeEmail(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.ChangeEmailReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		if err := svc.ChangeEmail(reqctx.UserID(r.Context()), &req); err != nil {
+			switch err {
+			case auth.ErrInvalidEmail, auth.ErrEmailUnchanged:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrInvalidPassword:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrEmailChangeTooSoon:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "a confirmation code was sent to the new email",
+			},
+		)
+	}
+}
+
+func HandleConfirmEmailChange(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.ConfirmEmailChangeReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		if err := svc.ConfirmEmailChange(reqctx.UserID(r.Context()), &req); err != nil {
+			switch err {
+			case auth.ErrInvalidCode:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrEmailChangeNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrUserAlreadyExists:
+				serveError(w, err.Error(), http.StatusConflict)
+			case auth.ErrCodeExpired:
+				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrTooManyAttempts:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		// every session was revoked, including this one
+		clearTokenCookies(w, svc.Cfg)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "email was changed, please log in again",
+			},
+		)
+	}
+}
+
+// HandleCancelEmailChange is a GET, it is opened from the link in the
+// notice sent to the old address.
+func HandleCancelEmailChange(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := svc.CancelEmailChange(r.URL.Query().Get("token")); err != nil {
+			switch err {
+			case auth.ErrInvalidCancelToken:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "email change was cancelled",
+			},
+		)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleBeginEmailLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.BeginEmailLoginReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.BeginEmailLogin(&req); err != nil {
+			switch err {
+			case auth.ErrInvalidEmail:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			}{
+				Status:  "ok",
+				Message: "if the account exists, a login code was sent",
+			},
+		)
+	}
+}
+
+func HandleFinishEmailLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.FinishEmailLoginReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		loginResp, err := svc.FinishEmailLogin(&req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidEmail:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrInvalidCode:
+				serveError(w, err.Error(), http.StatusUnauthorized)
+			case auth.ErrCodeExpired:
+				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrTooManyAttempts:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrUserNotConfirmed, auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		if loginResp.MFAToken != "" {
+			serveMFARequired(w, loginResp.MFAToken)
+			return
+		}
+
+		serveTokens(w, r, svc.Cfg, loginResp.AccessToken, loginResp.RefreshToken, "successful login")
+	}
+}
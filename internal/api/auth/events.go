@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+// HandleSecurityEvents lists the authentication history of the user's own
+// account, pages are taken with ?before=<next_cursor>&limit=.
+func HandleSecurityEvents(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := securityEventsReq(r.URL.Query())
+		if err != nil {
+			serveError(w, auth.ErrInvalidEventFilter.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// only the page can be chosen here
+		eventsResp, err := svc.SecurityEvents(reqctx.UserID(r.Context()), &auth.SecurityEventsReq{
+			Before: req.Before,
+			Limit:  req.Limit,
+		})
+		if err != nil {
+			serveSecurityEventsError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(eventsResp)
+	}
+}
+
+// HandleQuerySecurityEvents searches the audit log of every account by
+// user_id, type, outcome, ip and the since/until time range (RFC 3339).
+func HandleQuerySecurityEvents(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := securityEventsReq(r.URL.Query())
+		if err != nil {
+			serveError(w, auth.ErrInvalidEventFilter.Error(), http.StatusBadRequest)
+			return
+		}
+
+		eventsResp, err := svc.QuerySecurityEvents(req)
+		if err != nil {
+			serveSecurityEventsError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(eventsResp)
+	}
+}
+
+func serveSecurityEventsError(w http.ResponseWriter, err error) {
+	switch err {
+	case auth.ErrInvalidEventFilter:
+		serveError(w, err.Error(), http.StatusBadRequest)
+	default:
+		serveError(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func securityEventsReq(query url.Values) (*auth.SecurityEventsReq, error) {
+	req := &auth.SecurityEventsReq{
+		Type:    query.Get("type"),
+		Outcome: query.Get("outcome"),
+		IP:      query.Get("ip"),
+	}
+
+	var err error
+
+	if req.UserID, err = parseNullUUID(query.Get("user_id")); err != nil {
+		return nil, err
+	}
+
+	if req.Before, err = parseNullUUID(query.Get("before")); err != nil {
+		return nil, err
+	}
+
+	if value := query.Get("since"); value != "" {
+		if req.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("until"); value != "" {
+		if req.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		if req.Limit, err = strconv.Atoi(value); err != nil {
+			return nil, err
+		}
+
+		if req.Limit == 0 {
+			return nil, errors.New("limit must be positive")
+		}
+	}
+
+	return req, nil
+}
+
+func parseNullUUID(value string) (uuid.NullUUID, error) {
+	if value == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
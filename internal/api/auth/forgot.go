@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleForgotPassword(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.ForgotPasswordReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.ForgotPassword(&req); err != nil {
+			switch err {
+			case auth.ErrInvalidEmail:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			}{
+				Status:  "ok",
+				Message: "if the account exists, a reset code was sent",
+			},
+		)
+	}
+}
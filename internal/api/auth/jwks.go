@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleJWKS(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(svc.Keys.JWKS())
+	}
+}
@@ -18,17 +18,23 @@ func HandleLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		req.Client = clientInfo(r)
+
 		loginResp, err := svc.Login(&req)
 		if err != nil {
 			switch err {
 			case auth.ErrInvalidPassword:
 				serveError(w, err.Error(), http.StatusUnauthorized)
-			case auth.ErrUserNotConfirmed:
+			case auth.ErrUserNotConfirmed, auth.ErrUserSuspended:
 				serveError(w, err.Error(), http.StatusForbidden)
 			case auth.ErrUserNotFound:
 				serveError(w, err.Error(), http.StatusNotFound)
 			case auth.ErrAccessTokenExpired:
 				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrTooManyAttempts:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
 			default:
 				serveError(w, "internal error", http.StatusInternalServerError)
 			}
@@ -36,34 +42,11 @@ func HandleLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		cookieAccessToken := &http.Cookie{
-			Name:     "access_token",
-			Value:    loginResp.AccessToken,
-			HttpOnly: true,
-			MaxAge:   int(svc.Cfg.AccessTokenTTL.Seconds()),
-		}
-
-		cookieRefreshToken := &http.Cookie{
-			Name:     "refresh_token",
-			Value:    loginResp.RefreshToken,
-			HttpOnly: true,
-			MaxAge:   int(svc.Cfg.RefreshTokenTTL.Seconds()),
+		if loginResp.MFAToken != "" {
+			serveMFARequired(w, loginResp.MFAToken)
+			return
 		}
 
-		http.SetCookie(w, cookieAccessToken)
-		http.SetCookie(w, cookieRefreshToken)
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		json.NewEncoder(w).Encode(
-			struct {
-				Status string `json:"status"`
-				Msg    string `json:"message"`
-			}{
-				Status: "ok",
-				Msg:    "successful login",
-			},
-		)
+		serveTokens(w, r, svc.Cfg, loginResp.AccessToken, loginResp.RefreshToken, "successful login")
 	}
 }
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleLogout(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := refreshToken(r)
+		if token == "" {
+			serveError(w, "no refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		// the cookies are useless to the client from now on,
+		// even if the token turns out to be invalid
+		clearTokenCookies(w, svc.Cfg)
+
+		if err := svc.Logout(&auth.LogoutReq{
+			RefreshToken: token,
+			Client:       clientInfo(r),
+		}); err != nil {
+			switch err {
+			case auth.ErrInvalidRefreshToken:
+				serveError(w, err.Error(), http.StatusUnauthorized)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "successful logout",
+			},
+		)
+	}
+}
+
+func HandleLogoutAll(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := svc.LogoutAll(reqctx.UserID(r.Context()), clientInfo(r)); err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		clearTokenCookies(w, svc.Cfg)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "all sessions were revoked",
+			},
+		)
+	}
+}
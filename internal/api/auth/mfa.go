@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleLoginMFA(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.LoginMFAReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		loginResp, err := svc.LoginMFA(&req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidMFAToken, auth.ErrMFATokenExpired:
+				serveError(w, err.Error(), http.StatusUnauthorized)
+			case auth.ErrInvalidCode:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrAccountLocked:
+				serveError(w, err.Error(), http.StatusLocked)
+			case auth.ErrTooManyAttempts:
+				serveError(w, err.Error(), http.StatusTooManyRequests)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrUserNotConfirmed, auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		serveTokens(w, r, svc.Cfg, loginResp.AccessToken, loginResp.RefreshToken, "successful login")
+	}
+}
+
+func serveMFARequired(w http.ResponseWriter, mfaToken string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		struct {
+			Status   string `json:"status"`
+			Msg      string `json:"message"`
+			MFAToken string `json:"mfa_token"`
+		}{
+			Status:   "mfa_required",
+			Msg:      "second factor required",
+			MFAToken: mfaToken,
+		},
+	)
+}
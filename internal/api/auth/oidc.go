@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+// the state is also kept in a cookie, so the callback is accepted only in
+// the browser that started the login
+const oidcStateCookie = "oidc_state"
+
+func HandleBeginOIDCLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		beginResp, err := svc.BeginOIDCLogin(r.PathValue("provider"))
+		if err != nil {
+			switch err {
+			case auth.ErrUnknownProvider:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    beginResp.State,
+			Path:     "/api/v1/oidc",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   int(svc.Cfg.OIDCStateTTL.Seconds()),
+		})
+
+		http.Redirect(w, r, beginResp.URL, http.StatusFound)
+	}
+}
+
+func HandleOIDCCallback(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		cookie, err := r.Cookie(oidcStateCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+			serveError(w, auth.ErrInvalidOIDCState.Error(), http.StatusBadRequest)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    "",
+			Path:     "/api/v1/oidc",
+			HttpOnly: true,
+			MaxAge:   -1,
+		})
+
+		// the user declined or the provider failed
+		if providerErr := query.Get("error"); providerErr != "" {
+			logger.Info("identity provider returned an error", "provider", r.PathValue("provider"), "error", providerErr)
+			serveError(w, auth.ErrOIDCLoginFailed.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		loginResp, err := svc.FinishOIDCLogin(&auth.FinishOIDCLoginReq{
+			Provider: r.PathValue("provider"),
+			State:    query.Get("state"),
+			Code:     query.Get("code"),
+			Client:   clientInfo(r),
+		})
+		if err != nil {
+			switch err {
+			case auth.ErrUnknownProvider, auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrInvalidOIDCState, auth.ErrOIDCEmailRequired, auth.ErrOIDCEmailNotVerified:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrOIDCStateExpired:
+				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrOIDCLoginFailed:
+				serveError(w, err.Error(), http.StatusUnauthorized)
+			case auth.ErrUserNotConfirmed, auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserAlreadyExists:
+				serveError(w, err.Error(), http.StatusConflict)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		if loginResp.MFAToken != "" {
+			serveMFARequired(w, loginResp.MFAToken)
+			return
+		}
+
+		// a browser redirect, the tokens can only go to cookies
+		if err := setTokenCookies(w, svc.Cfg, loginResp.AccessToken, loginResp.RefreshToken); err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "successful login",
+			},
+		)
+	}
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleBeginPasskeyRegistration(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		beginResp, err := svc.BeginPasskeyRegistration(reqctx.UserID(r.Context()))
+		if err != nil {
+			switch err {
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(beginResp)
+	}
+}
+
+func HandleFinishPasskeyRegistration(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.FinishPasskeyRegistrationReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		passkey, err := svc.FinishPasskeyRegistration(reqctx.UserID(r.Context()), &req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidCeremony, auth.ErrInvalidPasskey:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrCeremonyExpired:
+				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrPasskeyRegistered:
+				serveError(w, err.Error(), http.StatusConflict)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status  string       `json:"status"`
+				Passkey auth.Passkey `json:"passkey"`
+			}{
+				Status:  "ok",
+				Passkey: passkey,
+			},
+		)
+	}
+}
+
+func HandleBeginPasskeyLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.BeginPasskeyLoginReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		beginResp, err := svc.BeginPasskeyLogin(&req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidEmail:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrUserNotConfirmed, auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserNotFound, auth.ErrPasskeyNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(beginResp)
+	}
+}
+
+func HandleFinishPasskeyLogin(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.FinishPasskeyLoginReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		loginResp, err := svc.FinishPasskeyLogin(&req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidCeremony, auth.ErrInvalidPasskey:
+				serveError(w, err.Error(), http.StatusUnauthorized)
+			case auth.ErrCeremonyExpired:
+				serveError(w, err.Error(), http.StatusGone)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrUserNotConfirmed, auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		serveTokens(w, r, svc.Cfg, loginResp.AccessToken, loginResp.RefreshToken, "successful login")
+	}
+}
+
+func HandlePasskeys(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		passkeys, err := svc.Passkeys(reqctx.UserID(r.Context()))
+		if err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status   string         `json:"status"`
+				Passkeys []auth.Passkey `json:"passkeys"`
+			}{
+				Status:   "ok",
+				Passkeys: passkeys,
+			},
+		)
+	}
+}
+
+func HandleDeletePasskey(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := base64.RawURLEncoding.DecodeString(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid passkey id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.DeletePasskey(reqctx.UserID(r.Context()), id); err != nil {
+			switch err {
+			case auth.ErrPasskeyNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "passkey was deleted",
+			},
+		)
+	}
+}
@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"encoding/json"
 	"log/slog"
 	"net/http"
 
@@ -10,23 +9,27 @@ import (
 
 func HandleRefresh(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("refresh_token")
-		if err != nil {
+		// not checked for csrf: a forged refresh only rotates the victim's
+		// own cookies, the response is not readable by the other site
+		token := refreshToken(r)
+		if token == "" {
 			serveError(w, "no refresh token", http.StatusUnauthorized)
 			return
 		}
 
 		refreshResp, err := svc.Refresh(&auth.RefreshReq{
-			RefreshToken: cookie.Value,
-			Email:        "MartynyukAlexey05@gmail.com",
+			RefreshToken: token,
+			Client:       clientInfo(r),
 		})
 
 		if err != nil {
 			switch err {
-			case auth.ErrInvalidRefreshToken:
+			case auth.ErrInvalidRefreshToken, auth.ErrRefreshTokenExpired:
 				serveError(w, err.Error(), http.StatusUnauthorized)
 			case auth.ErrUserNotFound:
 				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
 			default:
 				serveError(w, "internal error", http.StatusInternalServerError)
 			}
@@ -34,35 +37,6 @@ func HandleRefresh(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		cookieAccessToken := &http.Cookie{
-			Name:     "access_token",
-			Value:    refreshResp.AccessToken,
-			HttpOnly: true,
-			MaxAge:   int(svc.Cfg.AccessTokenTTL.Seconds()),
-		}
-
-		cookieRefreshToken := &http.Cookie{
-			Name:     "refresh_token",
-			Value:    refreshResp.RefreshToken,
-			HttpOnly: true,
-			MaxAge:   int(svc.Cfg.RefreshTokenTTL.Seconds()),
-		}
-
-		http.SetCookie(w, cookieAccessToken)
-		http.SetCookie(w, cookieRefreshToken)
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		json.NewEncoder(w).Encode(
-			struct {
-				Status string `json:"status"`
-				Msg    string `json:"message"`
-			}{
-				Status: "ok",
-				Msg:    "successful login",
-			},
-		)
-
+		serveTokens(w, r, svc.Cfg, refreshResp.AccessToken, refreshResp.RefreshToken, "successful login")
 	}
 }
@@ -20,8 +20,15 @@ func HandleRegistration(svc *auth.Service, logger *slog.Logger) http.HandlerFunc
 			return
 		}
 
+		req.Client = clientInfo(r)
+
 		authID, err := svc.Register(&req)
 		if err != nil {
+			if policyErr, ok := err.(*auth.PasswordPolicyError); ok {
+				servePasswordPolicyError(w, policyErr)
+				return
+			}
+
 			switch err {
 			case auth.ErrInvalidEmail, auth.ErrInvalidName, auth.ErrInvalidPassword:
 				serveError(w, err.Error(), http.StatusBadRequest)
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleResetPassword(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.ResetPasswordReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		if err := svc.ResetPassword(&req); err != nil {
+			if policyErr, ok := err.(*auth.PasswordPolicyError); ok {
+				servePasswordPolicyError(w, policyErr)
+				return
+			}
+
+			switch err {
+			case auth.ErrInvalidEmail, auth.ErrInvalidPassword:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrInvalidCode:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrCodeExpired:
+				serveError(w, err.Error(), http.StatusGone)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			}{
+				Status:  "ok",
+				Message: "password was reset",
+			},
+		)
+	}
+}
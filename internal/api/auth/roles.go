@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleRoles(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roles, err := svc.Roles()
+		if err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string      `json:"status"`
+				Roles  []auth.Role `json:"roles"`
+			}{
+				Status: "ok",
+				Roles:  roles,
+			},
+		)
+	}
+}
+
+func HandleUserRoles(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		roles, err := svc.UserRoles(userID)
+		if err != nil {
+			switch err {
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				auth.UserRoles
+			}{
+				Status:    "ok",
+				UserRoles: roles,
+			},
+		)
+	}
+}
+
+func HandleGrantRole(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			Role string `json:"role"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.GrantRole(reqctx.UserID(r.Context()), userID, req.Role); err != nil {
+			serveRoleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "role was granted",
+			},
+		)
+	}
+}
+
+func HandleRevokeRole(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.RevokeRole(reqctx.UserID(r.Context()), userID, r.PathValue("role")); err != nil {
+			serveRoleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "role was revoked",
+			},
+		)
+	}
+}
+
+func serveRoleError(w http.ResponseWriter, err error) {
+	switch err {
+	case auth.ErrUserNotFound, auth.ErrRoleNotFound, auth.ErrUserRoleNotFound:
+		serveError(w, err.Error(), http.StatusNotFound)
+	case auth.ErrAccessDenied:
+		serveError(w, err.Error(), http.StatusForbidden)
+	case auth.ErrLastSuperadmin:
+		serveError(w, err.Error(), http.StatusConflict)
+	default:
+		serveError(w, "internal error", http.StatusInternalServerError)
+	}
+}
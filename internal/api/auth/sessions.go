@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleSessions(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessions, err := svc.Sessions(reqctx.UserID(r.Context()), reqctx.SessionID(r.Context()))
+		if err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status   string         `json:"status"`
+				Sessions []auth.Session `json:"sessions"`
+			}{
+				Status:   "ok",
+				Sessions: sessions,
+			},
+		)
+	}
+}
+
+func HandleRevokeSession(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID, err := uuid.Parse(r.PathValue("branch"))
+		if err != nil {
+			serveError(w, "invalid session id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.RevokeSession(reqctx.UserID(r.Context()), sessionID); err != nil {
+			switch err {
+			case auth.ErrSessionNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "session was revoked",
+			},
+		)
+	}
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleEnrollTOTP(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enrollResp, err := svc.EnrollTOTP(reqctx.UserID(r.Context()))
+		if err != nil {
+			switch err {
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrTOTPAlreadyEnabled:
+				serveError(w, err.Error(), http.StatusConflict)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Secret string `json:"secret"`
+				URI    string `json:"uri"`
+			}{
+				Status: "ok",
+				Secret: enrollResp.Secret,
+				URI:    enrollResp.URI,
+			},
+		)
+	}
+}
+
+func HandleConfirmTOTP(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.ConfirmTOTPReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		confirmResp, err := svc.ConfirmTOTP(reqctx.UserID(r.Context()), &req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidCode:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrTOTPNotEnrolled:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrTOTPAlreadyEnabled:
+				serveError(w, err.Error(), http.StatusConflict)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status        string   `json:"status"`
+				RecoveryCodes []string `json:"recovery_codes"`
+			}{
+				Status:        "ok",
+				RecoveryCodes: confirmResp.RecoveryCodes,
+			},
+		)
+	}
+}
+
+func HandleDisableTOTP(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.DisableTOTPReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.DisableTOTP(reqctx.UserID(r.Context()), &req); err != nil {
+			switch err {
+			case auth.ErrInvalidCode:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrTOTPNotEnabled:
+				serveError(w, err.Error(), http.StatusConflict)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "two-factor authentication was disabled",
+			},
+		)
+	}
+}
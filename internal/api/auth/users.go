@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+// HandleRevokeUserTokens logs the user out everywhere, access tokens stop
+// working right away.
+func HandleRevokeUserTokens(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.RevokeUserTokens(reqctx.UserID(r.Context()), userID); err != nil {
+			switch err {
+			case auth.ErrAccessDenied:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "user tokens were revoked",
+			},
+		)
+	}
+}
+
+func HandleSuspendUser(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		var req auth.SuspendUserReq
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req.Client = clientInfo(r)
+
+		if err := svc.SuspendUser(reqctx.UserID(r.Context()), userID, &req); err != nil {
+			switch err {
+			case auth.ErrInvalidSuspension, auth.ErrSelfSuspension:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			case auth.ErrAccessDenied:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "user was suspended",
+			},
+		)
+	}
+}
+
+func HandleUnsuspendUser(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.UnsuspendUser(reqctx.UserID(r.Context()), userID, clientInfo(r)); err != nil {
+			switch err {
+			case auth.ErrAccessDenied:
+				serveError(w, err.Error(), http.StatusForbidden)
+			case auth.ErrUserNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			case auth.ErrUserNotSuspended:
+				serveError(w, err.Error(), http.StatusConflict)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "suspension was lifted",
+			},
+		)
+	}
+}
@@ -2,7 +2,14 @@ package auth
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
+	"strings"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/csrf"
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+	"github.com/MartynyukAlexey/gymshark/internal/password"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
 )
 
 func serveError(w http.ResponseWriter, msg string, status int) {
@@ -20,3 +27,147 @@ func serveError(w http.ResponseWriter, msg string, status int) {
 		},
 	)
 }
+
+// servePasswordPolicyError lists every broken rule, so that clients can
+// show them all at once.
+func servePasswordPolicyError(w http.ResponseWriter, err *auth.PasswordPolicyError) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.WriteHeader(http.StatusBadRequest)
+
+	json.NewEncoder(w).Encode(
+		struct {
+			Status     string               `json:"status"`
+			Msg        string               `json:"message"`
+			Violations []password.Violation `json:"violations"`
+		}{
+			Status:     "error",
+			Msg:        err.Error(),
+			Violations: err.Violations,
+		},
+	)
+}
+
+// tokensInBody reports whether the client asked for the tokens in the
+// response body instead of cookies, as mobile clients do.
+func tokensInBody(r *http.Request) bool {
+	return r.Header.Get("X-Token-Delivery") == "body"
+}
+
+// serveTokens sends the token pair of a successful login or refresh.
+func serveTokens(w http.ResponseWriter, r *http.Request, cfg *config.AuthConfig, accessToken, refreshToken, msg string) {
+	if !tokensInBody(r) {
+		if err := setTokenCookies(w, cfg, accessToken, refreshToken); err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    msg,
+			},
+		)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		struct {
+			Status       string `json:"status"`
+			Msg          string `json:"message"`
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+			TokenType    string `json:"token_type"`
+			ExpiresIn    int    `json:"expires_in"`
+		}{
+			Status:       "ok",
+			Msg:          msg,
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(cfg.AccessTokenTTL.Seconds()),
+		},
+	)
+}
+
+// refreshToken reads the refresh token from the cookie or, for clients
+// keeping the tokens themselves, from the {"refresh_token": ...} body.
+func refreshToken(r *http.Request) string {
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		return cookie.Value
+	}
+
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	json.NewDecoder(r.Body).Decode(&body)
+	return body.RefreshToken
+}
+
+// setTokenCookies also sets a new csrf token, see package csrf.
+func setTokenCookies(w http.ResponseWriter, cfg *config.AuthConfig, accessToken, refreshToken string) error {
+	csrfToken, err := csrf.NewToken()
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, newCookie(cfg, "access_token", accessToken, int(cfg.AccessTokenTTL.Seconds()), true))
+	http.SetCookie(w, newCookie(cfg, "refresh_token", refreshToken, int(cfg.RefreshTokenTTL.Seconds()), true))
+	// scripts have to read it to send it back in the header
+	http.SetCookie(w, newCookie(cfg, csrf.CookieName, csrfToken, int(cfg.RefreshTokenTTL.Seconds()), false))
+
+	return nil
+}
+
+func clearTokenCookies(w http.ResponseWriter, cfg *config.AuthConfig) {
+	http.SetCookie(w, newCookie(cfg, "access_token", "", -1, true))
+	http.SetCookie(w, newCookie(cfg, "refresh_token", "", -1, true))
+	http.SetCookie(w, newCookie(cfg, csrf.CookieName, "", -1, false))
+}
+
+func newCookie(cfg *config.AuthConfig, name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     cfg.CookiePath,
+		Domain:   cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   cfg.CookieSecure,
+		HttpOnly: httpOnly,
+	}
+
+	switch strings.ToLower(cfg.CookieSameSite) {
+	case "strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "none":
+		cookie.SameSite = http.SameSiteNoneMode
+	default:
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+
+	return cookie
+}
+
+func clientInfo(r *http.Request) auth.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return auth.ClientInfo{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
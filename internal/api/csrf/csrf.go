@@ -0,0 +1,58 @@
+// Package csrf implements double-submit tokens for requests authenticated
+// with cookies. The token is kept in a cookie scripts of our own pages can
+// read, and unsafe requests have to repeat it in the X-CSRF-Token header
+// (or the csrf_token field of a form). Another site can make the browser
+// send the cookie, but it can not read it.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+const (
+	CookieName = "csrf_token"
+	HeaderName = "X-CSRF-Token"
+	FormField  = "csrf_token"
+)
+
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Token returns the token of the request's cookie, for forms to submit.
+func Token(r *http.Request) string {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// Valid reports whether the request is safe or repeats the cookie's token.
+func Valid(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	token := Token(r)
+	if token == "" {
+		return false
+	}
+
+	submitted := r.Header.Get(HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(FormField)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(submitted)) == 1
+}
@@ -1,44 +1,127 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/MartynyukAlexey/gymshark/internal/api/csrf"
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
 	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
 )
 
-type contextKey string
-
-const (
-	userIDKey contextKey = "user_id"
-)
-
 type middlewareEnv struct {
 	svc    *auth.Service
 	logger *slog.Logger
 }
 
+// RequireAuth accepts a session or a personal api key in the
+// Authorization header.
 func (env *middlewareEnv) RequireAuth(next http.Handler) http.Handler {
+	session := env.RequireSession(next)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("access_token")
+		apiKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(apiKey, auth.APIKeyPrefix) {
+			session.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := env.svc.AuthorizeAPIKey(apiKey)
 		if err != nil {
-			serveError(w, "no access token", http.StatusUnauthorized)
+			switch err {
+			case auth.ErrInvalidAPIKey, auth.ErrAPIKeyExpired:
+				serveError(w, err.Error(), http.StatusUnauthorized)
+			case auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		if !claims.Allows(r.Method) {
+			serveError(w, "api key scope does not allow this request", http.StatusForbidden)
 			return
 		}
 
-		userID, err := env.svc.Authorize(cookie.Value)
+		ctx := reqctx.WithUserID(r.Context(), claims.UserID)
+		ctx = reqctx.WithRoles(ctx, claims.Roles)
+		ctx = reqctx.WithPermissions(ctx, claims.Permissions)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireSession accepts only a session: an access token in the
+// Authorization header, or in the access_token cookie together with a
+// csrf token. Account security settings (api keys, second factors) are not
+// managed with api keys.
+func (env *middlewareEnv) RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			cookie, err := r.Cookie("access_token")
+			if err != nil {
+				serveError(w, "no access token", http.StatusUnauthorized)
+				return
+			}
+
+			// browsers attach cookies to requests made by other sites too
+			if !csrf.Valid(r) {
+				serveError(w, "invalid csrf token", http.StatusForbidden)
+				return
+			}
+
+			accessToken = cookie.Value
+		}
+
+		claims, err := env.svc.Authorize(accessToken)
 		if err != nil {
-			serveError(w, "invalid access token", http.StatusUnauthorized)
+			switch err {
+			case auth.ErrUserSuspended:
+				serveError(w, err.Error(), http.StatusForbidden)
+			default:
+				serveError(w, "invalid access token", http.StatusUnauthorized)
+			}
+
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		ctx := reqctx.WithUserID(r.Context(), claims.UserID)
+		ctx = reqctx.WithSessionID(ctx, claims.SessionID)
+		ctx = reqctx.WithRoles(ctx, claims.Roles)
+		ctx = reqctx.WithPermissions(ctx, claims.Permissions)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequireRole must be wrapped by RequireAuth or RequireSession.
+func (env *middlewareEnv) RequireRole(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(reqctx.Roles(r.Context()), role) {
+			serveError(w, "access denied", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission must be wrapped by RequireAuth or RequireSession.
+func (env *middlewareEnv) RequirePermission(permission string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(reqctx.Permissions(r.Context()), permission) {
+			serveError(w, "access denied", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func serveError(w http.ResponseWriter, msg string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 
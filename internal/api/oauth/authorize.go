@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/csrf"
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var consentTemplate = template.Must(template.ParseFS(templates, "templates/consent.html"))
+
+func HandleAuthorize(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		authorizeResp, err := svc.BeginAuthorization(reqctx.UserID(r.Context()), &auth.AuthorizeReq{
+			ResponseType:        query.Get("response_type"),
+			ClientID:            query.Get("client_id"),
+			RedirectURI:         query.Get("redirect_uri"),
+			Scope:               query.Get("scope"),
+			State:               query.Get("state"),
+			Nonce:               query.Get("nonce"),
+			CodeChallenge:       query.Get("code_challenge"),
+			CodeChallengeMethod: query.Get("code_challenge_method"),
+		})
+		if err != nil {
+			serveAuthorizeError(w, r, err)
+			return
+		}
+
+		if authorizeResp.Consent == nil {
+			http.Redirect(w, r, authorizeResp.RedirectURL, http.StatusFound)
+			return
+		}
+
+		// the consent screen must not be framed by other sites
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+		w.WriteHeader(http.StatusOK)
+
+		// the form is posted with the session cookie, so it carries the csrf token
+		if err := consentTemplate.Execute(w, struct {
+			*auth.ConsentPrompt
+			CSRFToken string
+		}{
+			ConsentPrompt: authorizeResp.Consent,
+			CSRFToken:     csrf.Token(r),
+		}); err != nil {
+			logger.Error("failed to render consent screen", "err", err)
+		}
+	}
+}
+
+func HandleConsent(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		redirectURL, err := svc.FinishAuthorization(reqctx.UserID(r.Context()), &auth.ConsentReq{
+			ConsentToken: r.PostForm.Get("consent"),
+			Approve:      r.PostForm.Get("decision") == "approve",
+		})
+		if err != nil {
+			serveAuthorizeError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+	}
+}
+
+// errors are sent back to the client only once the redirect uri is known to
+// belong to it, otherwise they are shown to the user
+func serveAuthorizeError(w http.ResponseWriter, r *http.Request, err error) {
+	var redirectErr *auth.AuthorizeRedirectError
+	if errors.As(err, &redirectErr) {
+		http.Redirect(w, r, redirectErr.RedirectURL, http.StatusFound)
+		return
+	}
+
+	switch err {
+	case auth.ErrInvalidClient, auth.ErrInvalidRedirectURI, auth.ErrInvalidConsent:
+		serveError(w, err.Error(), http.StatusBadRequest)
+	default:
+		serveError(w, "internal error", http.StatusInternalServerError)
+	}
+}
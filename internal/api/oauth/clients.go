@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/api/reqctx"
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleRegisterClient(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req auth.RegisterClientReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			serveError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		client, err := svc.RegisterClient(reqctx.UserID(r.Context()), &req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidClientMetadata, auth.ErrInvalidRedirectURI, auth.ErrInvalidScope, auth.ErrUnsupportedGrantType:
+				serveError(w, err.Error(), http.StatusBadRequest)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string                  `json:"status"`
+				Client auth.RegisterClientResp `json:"client"`
+			}{
+				Status: "ok",
+				Client: client,
+			},
+		)
+	}
+}
+
+func HandleClients(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clients, err := svc.Clients(reqctx.UserID(r.Context()))
+		if err != nil {
+			serveError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status  string             `json:"status"`
+				Clients []auth.OAuthClient `json:"clients"`
+			}{
+				Status:  "ok",
+				Clients: clients,
+			},
+		)
+	}
+}
+
+func HandleDeleteClient(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			serveError(w, "invalid client id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.DeleteClient(reqctx.UserID(r.Context()), clientID); err != nil {
+			switch err {
+			case auth.ErrClientNotFound:
+				serveError(w, err.Error(), http.StatusNotFound)
+			default:
+				serveError(w, "internal error", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(
+			struct {
+				Status string `json:"status"`
+				Msg    string `json:"message"`
+			}{
+				Status: "ok",
+				Msg:    "client was deleted",
+			},
+		)
+	}
+}
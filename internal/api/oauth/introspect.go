@@ -0,0 +1,80 @@
+package oauth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleIntrospect(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			serveOAuthError(w, "invalid_request", "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req := auth.IntrospectReq{
+			Token: r.PostForm.Get("token"),
+		}
+
+		var ok bool
+		if req.ClientID, req.ClientSecret, ok = clientCredentials(r); !ok {
+			serveOAuthError(w, "invalid_client", "", http.StatusUnauthorized)
+			return
+		}
+
+		introspectResp, err := svc.Introspect(&req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidClient:
+				serveOAuthError(w, "invalid_client", "", http.StatusUnauthorized)
+			default:
+				serveOAuthError(w, "server_error", "", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(introspectResp)
+	}
+}
+
+// HandleRevoke answers 200 for unknown tokens too, the client can not
+// do anything about them.
+func HandleRevoke(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			serveOAuthError(w, "invalid_request", "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req := auth.RevokeReq{
+			Token: r.PostForm.Get("token"),
+		}
+
+		var ok bool
+		if req.ClientID, req.ClientSecret, ok = clientCredentials(r); !ok {
+			serveOAuthError(w, "invalid_client", "", http.StatusUnauthorized)
+			return
+		}
+
+		if err := svc.Revoke(&req); err != nil {
+			switch err {
+			case auth.ErrInvalidClient:
+				serveOAuthError(w, "invalid_client", "", http.StatusUnauthorized)
+			default:
+				serveOAuthError(w, "server_error", "", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
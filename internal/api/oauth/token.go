@@ -0,0 +1,98 @@
+package oauth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleToken(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			serveOAuthError(w, "invalid_request", "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		req := auth.TokenReq{
+			GrantType:    r.PostForm.Get("grant_type"),
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			Scope:        r.PostForm.Get("scope"),
+			Client:       clientInfo(r),
+		}
+
+		var ok bool
+		if req.ClientID, req.ClientSecret, ok = clientCredentials(r); !ok {
+			serveOAuthError(w, "invalid_client", "", http.StatusUnauthorized)
+			return
+		}
+
+		tokenResp, err := svc.Token(&req)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidClient:
+				serveOAuthError(w, "invalid_client", "", http.StatusUnauthorized)
+			case auth.ErrInvalidGrant:
+				serveOAuthError(w, "invalid_grant", "", http.StatusBadRequest)
+			case auth.ErrInvalidScope:
+				serveOAuthError(w, "invalid_scope", "", http.StatusBadRequest)
+			case auth.ErrUnsupportedGrantType:
+				serveOAuthError(w, "unsupported_grant_type", "", http.StatusBadRequest)
+			default:
+				serveOAuthError(w, "server_error", "", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(tokenResp)
+	}
+}
+
+// clientCredentials reads the client from the basic authorization header or
+// from the body, using both is an error.
+func clientCredentials(r *http.Request) (string, string, bool) {
+	id, secret, basic := r.BasicAuth()
+	if !basic {
+		return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), true
+	}
+
+	if r.PostForm.Get("client_secret") != "" {
+		return "", "", false
+	}
+
+	// credentials are form-encoded before they are put into the header
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return "", "", false
+	}
+
+	secret, err = url.QueryUnescape(secret)
+	if err != nil {
+		return "", "", false
+	}
+
+	return id, secret, true
+}
+
+func clientInfo(r *http.Request) auth.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return auth.ClientInfo{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
@@ -0,0 +1,40 @@
+package oauth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
+)
+
+func HandleUserInfo(svc *auth.Service, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			serveOAuthError(w, "invalid_token", "", http.StatusUnauthorized)
+			return
+		}
+
+		userInfo, err := svc.UserInfo(accessToken)
+		if err != nil {
+			switch err {
+			case auth.ErrInvalidAccessToken, auth.ErrAccessTokenExpired, auth.ErrAccessTokenRevoked, auth.ErrUserSuspended, auth.ErrUserNotFound:
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				serveOAuthError(w, "invalid_token", "", http.StatusUnauthorized)
+			default:
+				serveOAuthError(w, "server_error", "", http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(userInfo)
+	}
+}
@@ -0,0 +1,41 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func serveError(w http.ResponseWriter, msg string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(
+		struct {
+			Status string `json:"status"`
+			Msg    string `json:"message"`
+		}{
+			Status: "error",
+			Msg:    msg,
+		},
+	)
+}
+
+// serveOAuthError writes an error in the RFC 6749 format expected by
+// OAuth clients.
+func serveOAuthError(w http.ResponseWriter, code string, description string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(
+		struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description,omitempty"`
+		}{
+			Error:       code,
+			Description: description,
+		},
+	)
+}
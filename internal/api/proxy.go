@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustProxies replaces r.RemoteAddr with the client address reported in
+// X-Forwarded-For when the request came through one of the trusted reverse
+// proxies. Every proxy appends the address it got the request from, so the
+// header is read from the right and the first hop that is not a trusted
+// proxy is the client; anything to the left of it could be made up by the
+// client itself. Without trusted proxies the header is ignored.
+func TrustProxies(trusted []netip.Prefix, next http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil || !isTrusted(addr.Addr()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := addr.Addr()
+
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0 && isTrusted(client); i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// a garbled hop can not be attributed, stop at the last proxy
+				break
+			}
+
+			client = hop
+		}
+
+		r.RemoteAddr = net.JoinHostPort(client.Unmap().String(), "0")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseTrustedProxies accepts CIDR ranges and single addresses.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, err
+			}
+
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustProxies(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   []string
+		wantRemoteAddr string
+	}{
+		{
+			name:           "direct client",
+			remoteAddr:     "203.0.113.7:5000",
+			wantRemoteAddr: "203.0.113.7:5000",
+		},
+		{
+			name:           "direct client forging the header",
+			remoteAddr:     "203.0.113.7:5000",
+			forwardedFor:   []string{"198.51.100.1"},
+			wantRemoteAddr: "203.0.113.7:5000",
+		},
+		{
+			name:           "through a trusted proxy",
+			remoteAddr:     "10.1.2.3:5000",
+			forwardedFor:   []string{"203.0.113.7"},
+			wantRemoteAddr: "203.0.113.7:0",
+		},
+		{
+			name:           "through a chain of trusted proxies",
+			remoteAddr:     "10.1.2.3:5000",
+			forwardedFor:   []string{"203.0.113.7, 192.0.2.1", "10.9.9.9"},
+			wantRemoteAddr: "203.0.113.7:0",
+		},
+		{
+			name:           "client prepending a forged hop",
+			remoteAddr:     "10.1.2.3:5000",
+			forwardedFor:   []string{"198.51.100.1, 203.0.113.7"},
+			wantRemoteAddr: "203.0.113.7:0",
+		},
+		{
+			name:           "garbled hop",
+			remoteAddr:     "10.1.2.3:5000",
+			forwardedFor:   []string{"203.0.113.7, unknown"},
+			wantRemoteAddr: "10.1.2.3:0",
+		},
+		{
+			name:           "trusted proxy without the header",
+			remoteAddr:     "10.1.2.3:5000",
+			wantRemoteAddr: "10.1.2.3:0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := TrustProxies(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.wantRemoteAddr {
+				t.Errorf("got %q, want %q", got, tt.wantRemoteAddr)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("accepted an invalid range")
+	}
+
+	if _, err := ParseTrustedProxies([]string{"proxy.internal"}); err == nil {
+		t.Error("accepted a host name")
+	}
+}
@@ -0,0 +1,57 @@
+package reqctx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type contextKey string
+
+const (
+	userIDKey    contextKey = "user_id"
+	sessionIDKey contextKey = "session_id"
+
+	rolesKey       contextKey = "roles"
+	permissionsKey contextKey = "permissions"
+)
+
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID returns the id of the user authenticated by RequireAuth.
+func UserID(ctx context.Context) uuid.UUID {
+	userID, _ := ctx.Value(userIDKey).(uuid.UUID)
+	return userID
+}
+
+func WithSessionID(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// SessionID returns the refresh token branch the access token was issued for.
+func SessionID(ctx context.Context) uuid.UUID {
+	sessionID, _ := ctx.Value(sessionIDKey).(uuid.UUID)
+	return sessionID
+}
+
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+// Roles returns the roles of the authenticated user.
+func Roles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return roles
+}
+
+func WithPermissions(ctx context.Context, permissions []string) context.Context {
+	return context.WithValue(ctx, permissionsKey, permissions)
+}
+
+// Permissions returns the permissions granted by the roles of the authenticated user.
+func Permissions(ctx context.Context) []string {
+	permissions, _ := ctx.Value(permissionsKey).([]string)
+	return permissions
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/MartynyukAlexey/gymshark/internal/api/auth"
+	"github.com/MartynyukAlexey/gymshark/internal/api/oauth"
 	"github.com/MartynyukAlexey/gymshark/internal/service"
 )
 
@@ -16,10 +17,80 @@ func Routes(service *service.Service, logger *slog.Logger) http.Handler {
 		logger: logger,
 	}
 
+	mux.Handle("GET /.well-known/jwks.json", auth.HandleJWKS(service.Auth, logger))
+
+	// the authorization server needs RS256 or EdDSA, see auth.Service.OAuthEnabled
+	if service.Auth.OAuthEnabled() {
+		mux.Handle("GET /.well-known/openid-configuration", oauth.HandleDiscovery(service.Auth, logger))
+
+		mux.Handle("GET /oauth/authorize", m.RequireSession(oauth.HandleAuthorize(service.Auth, logger)))
+		mux.Handle("POST /oauth/authorize", m.RequireSession(oauth.HandleConsent(service.Auth, logger)))
+		mux.Handle("POST /oauth/token", oauth.HandleToken(service.Auth, logger))
+		mux.Handle("POST /oauth/introspect", oauth.HandleIntrospect(service.Auth, logger))
+		mux.Handle("POST /oauth/revoke", oauth.HandleRevoke(service.Auth, logger))
+		mux.Handle("GET /oauth/userinfo", oauth.HandleUserInfo(service.Auth, logger))
+
+		mux.Handle("GET /api/v1/oauth/clients", m.RequireSession(m.RequirePermission("oauth_clients:manage", oauth.HandleClients(service.Auth, logger))))
+		mux.Handle("POST /api/v1/oauth/clients", m.RequireSession(m.RequirePermission("oauth_clients:manage", oauth.HandleRegisterClient(service.Auth, logger))))
+		mux.Handle("DELETE /api/v1/oauth/clients/{id}", m.RequireSession(m.RequirePermission("oauth_clients:manage", oauth.HandleDeleteClient(service.Auth, logger))))
+	}
+
 	mux.Handle("POST /api/v1/register", auth.HandleRegistration(service.Auth, logger))
 	mux.Handle("POST /api/v1/confirm", auth.HandleConfirmation(service.Auth, logger))
+	mux.Handle("POST /api/v1/confirm/resend", auth.HandleResendConfirmation(service.Auth, logger))
 	mux.Handle("POST /api/v1/login", auth.HandleLogin(service.Auth, logger))
+	mux.Handle("POST /api/v1/unlock", auth.HandleUnlock(service.Auth, logger))
+	mux.Handle("POST /api/v1/login/email", auth.HandleBeginEmailLogin(service.Auth, logger))
+	mux.Handle("POST /api/v1/login/email/verify", auth.HandleFinishEmailLogin(service.Auth, logger))
+	mux.Handle("POST /api/v1/login/mfa", auth.HandleLoginMFA(service.Auth, logger))
+	mux.Handle("POST /api/v1/login/passkey/begin", auth.HandleBeginPasskeyLogin(service.Auth, logger))
+	mux.Handle("POST /api/v1/login/passkey/finish", auth.HandleFinishPasskeyLogin(service.Auth, logger))
+	mux.Handle("GET /api/v1/oidc/{provider}/login", auth.HandleBeginOIDCLogin(service.Auth, logger))
+	mux.Handle("GET /api/v1/oidc/{provider}/callback", auth.HandleOIDCCallback(service.Auth, logger))
 	mux.Handle("POST /api/v1/refresh", auth.HandleRefresh(service.Auth, logger))
+	mux.Handle("POST /api/v1/logout", auth.HandleLogout(service.Auth, logger))
+	mux.Handle("POST /api/v1/logout-all", m.RequireSession(auth.HandleLogoutAll(service.Auth, logger)))
+
+	mux.Handle("POST /api/v1/password/forgot", auth.HandleForgotPassword(service.Auth, logger))
+	mux.Handle("POST /api/v1/password/reset", auth.HandleResetPassword(service.Auth, logger))
+
+	mux.Handle("DELETE /api/v1/me", m.RequireSession(auth.HandleDeleteAccount(service.Auth, logger)))
+	mux.Handle("GET /api/v1/me/restore", auth.HandleRestoreAccount(service.Auth, logger))
+
+	mux.Handle("POST /api/v1/me/password", m.RequireSession(auth.HandleChangePassword(service.Auth, logger)))
+	mux.Handle("POST /api/v1/me/email", m.RequireSession(auth.HandleChangeEmail(service.Auth, logger)))
+	mux.Handle("POST /api/v1/me/email/confirm", m.RequireSession(auth.HandleConfirmEmailChange(service.Auth, logger)))
+	mux.Handle("GET /api/v1/me/email/cancel", auth.HandleCancelEmailChange(service.Auth, logger))
+
+	mux.Handle("PUT /api/v1/me/sign-in-alerts", m.RequireSession(auth.HandleUpdateSignInAlerts(service.Auth, logger)))
+	mux.Handle("GET /api/v1/sign-ins/report", auth.HandleReportSignIn(service.Auth, logger))
+	mux.Handle("GET /api/v1/me/security-events", m.RequireSession(auth.HandleSecurityEvents(service.Auth, logger)))
+
+	mux.Handle("GET /api/v1/sessions", m.RequireSession(auth.HandleSessions(service.Auth, logger)))
+	mux.Handle("DELETE /api/v1/sessions/{branch}", m.RequireSession(auth.HandleRevokeSession(service.Auth, logger)))
+
+	mux.Handle("POST /api/v1/mfa/totp/enroll", m.RequireSession(auth.HandleEnrollTOTP(service.Auth, logger)))
+	mux.Handle("POST /api/v1/mfa/totp/confirm", m.RequireSession(auth.HandleConfirmTOTP(service.Auth, logger)))
+	mux.Handle("POST /api/v1/mfa/totp/disable", m.RequireSession(auth.HandleDisableTOTP(service.Auth, logger)))
+
+	mux.Handle("GET /api/v1/passkeys", m.RequireSession(auth.HandlePasskeys(service.Auth, logger)))
+	mux.Handle("DELETE /api/v1/passkeys/{id}", m.RequireSession(auth.HandleDeletePasskey(service.Auth, logger)))
+	mux.Handle("POST /api/v1/passkeys/register/begin", m.RequireSession(auth.HandleBeginPasskeyRegistration(service.Auth, logger)))
+	mux.Handle("POST /api/v1/passkeys/register/finish", m.RequireSession(auth.HandleFinishPasskeyRegistration(service.Auth, logger)))
+
+	mux.Handle("GET /api/v1/api-keys", m.RequireSession(auth.HandleAPIKeys(service.Auth, logger)))
+	mux.Handle("POST /api/v1/api-keys", m.RequireSession(auth.HandleCreateAPIKey(service.Auth, logger)))
+	mux.Handle("DELETE /api/v1/api-keys/{id}", m.RequireSession(auth.HandleRevokeAPIKey(service.Auth, logger)))
+
+	mux.Handle("GET /api/v1/roles", m.RequireAuth(m.RequirePermission("roles:read", auth.HandleRoles(service.Auth, logger))))
+	mux.Handle("GET /api/v1/admin/users/{id}/roles", m.RequireAuth(m.RequirePermission("roles:read", auth.HandleUserRoles(service.Auth, logger))))
+	mux.Handle("POST /api/v1/admin/users/{id}/roles", m.RequireSession(m.RequirePermission("roles:manage", auth.HandleGrantRole(service.Auth, logger))))
+	mux.Handle("DELETE /api/v1/admin/users/{id}/roles/{role}", m.RequireSession(m.RequirePermission("roles:manage", auth.HandleRevokeRole(service.Auth, logger))))
+
+	mux.Handle("GET /api/v1/admin/security-events", m.RequireAuth(m.RequirePermission("audit:read", auth.HandleQuerySecurityEvents(service.Auth, logger))))
+	mux.Handle("POST /api/v1/admin/users/{id}/revoke-tokens", m.RequireSession(m.RequirePermission("users:manage", auth.HandleRevokeUserTokens(service.Auth, logger))))
+	mux.Handle("POST /api/v1/admin/users/{id}/suspend", m.RequireSession(m.RequirePermission("users:suspend", auth.HandleSuspendUser(service.Auth, logger))))
+	mux.Handle("POST /api/v1/admin/users/{id}/unsuspend", m.RequireSession(m.RequirePermission("users:suspend", auth.HandleUnsuspendUser(service.Auth, logger))))
 
 	mux.Handle("GET /api/v1/test", m.RequireAuth(auth.HandleTest(service.Auth, logger)))
 
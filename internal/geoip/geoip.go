@@ -0,0 +1,129 @@
+// Package geoip looks up the approximate location of an ip address in a
+// local csv database of address ranges, such as the free DB-IP "IP to
+// City Lite" one. Each row holds the first and the last address of a range
+// followed by the continent, country, region and city:
+//
+//	1.0.0.0,1.0.0.255,OC,AU,Queensland,South Brisbane,...
+//
+// Extra columns are ignored. IPv4 and IPv6 ranges may be mixed.
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// String joins the known parts, e.g. "Berlin, Land Berlin, DE".
+func (l Location) String() string {
+	var parts []string
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+type ipRange struct {
+	first    netip.Addr
+	last     netip.Addr
+	location Location
+}
+
+// DB is an in-memory copy of the database, ranges sorted by first address.
+type DB struct {
+	ranges []ipRange
+}
+
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	db := &DB{}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read geoip database: %w", err)
+		}
+
+		if len(record) < 6 {
+			return nil, fmt.Errorf("geoip database line %d: expected at least 6 columns", line)
+		}
+
+		first, err := netip.ParseAddr(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("geoip database line %d: %w", line, err)
+		}
+
+		last, err := netip.ParseAddr(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("geoip database line %d: %w", line, err)
+		}
+
+		db.ranges = append(db.ranges, ipRange{
+			first: first,
+			last:  last,
+			location: Location{
+				Country: record[3],
+				Region:  record[4],
+				City:    record[5],
+			},
+		})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].first.Less(db.ranges[j].first)
+	})
+
+	return db, nil
+}
+
+// Lookup finds the range holding the address. A nil DB knows nothing.
+func (db *DB) Lookup(ip string) (Location, bool) {
+	if db == nil {
+		return Location{}, false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Location{}, false
+	}
+
+	// ranges store IPv4 addresses in the plain form
+	addr = addr.Unmap()
+
+	// the last range starting at or before the address
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].first)
+	}) - 1
+
+	if i < 0 || db.ranges[i].last.Less(addr) || db.ranges[i].first.BitLen() != addr.BitLen() {
+		return Location{}, false
+	}
+
+	return db.ranges[i].location, true
+}
@@ -0,0 +1,191 @@
+// Package hashing hashes passwords and other secrets into self-describing
+// strings: argon2id hashes use the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>), bcrypt hashes keep their
+// own $2a$ format. The algorithm and its parameters are read back from the
+// hash, so hashes made with older settings keep working and can be
+// upgraded with NeedsRehash.
+package hashing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrMismatchedHash = errors.New("hash does not match the secret")
+	ErrUnknownFormat  = errors.New("unknown hash format")
+)
+
+type Argon2Params struct {
+	// in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type Hasher struct {
+	// algorithm new hashes are made with
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+func NewHasher(cfg *config.AuthConfig) (*Hasher, error) {
+	h := &Hasher{
+		Algorithm:  cfg.PasswordHashAlgorithm,
+		BcryptCost: cfg.BcryptCost,
+		Argon2: Argon2Params{
+			Memory:      uint32(cfg.Argon2Memory),
+			Iterations:  uint32(cfg.Argon2Iterations),
+			Parallelism: uint8(cfg.Argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+
+	switch h.Algorithm {
+	case AlgorithmArgon2id:
+		if h.Argon2.Memory == 0 || h.Argon2.Iterations == 0 || h.Argon2.Parallelism == 0 {
+			return nil, errors.New("argon2id memory, iterations and parallelism must be positive")
+		}
+	case AlgorithmBcrypt:
+		if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", h.Algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *Hasher) Hash(secret []byte) ([]byte, error) {
+	if h.Algorithm == AlgorithmBcrypt {
+		return bcrypt.GenerateFromPassword(secret, h.BcryptCost)
+	}
+
+	salt := make([]byte, h.Argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey(secret, salt, h.Argon2.Iterations, h.Argon2.Memory, h.Argon2.Parallelism, h.Argon2.KeyLength)
+
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Argon2.Memory,
+		h.Argon2.Iterations,
+		h.Argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// Compare returns nil if the secret matches the hash and ErrMismatchedHash
+// if it does not, whatever algorithm the hash was made with.
+func (h *Hasher) Compare(hash, secret []byte) error {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword(hash, secret)
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrMismatchedHash
+		}
+
+		return err
+	}
+
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey(secret, salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedHash
+	}
+
+	return nil
+}
+
+// NeedsRehash reports whether the hash was made with another algorithm or
+// other parameters than the current ones.
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	if isBcrypt(hash) {
+		if h.Algorithm != AlgorithmBcrypt {
+			return true
+		}
+
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || cost != h.BcryptCost
+	}
+
+	if h.Algorithm != AlgorithmArgon2id {
+		return true
+	}
+
+	params, _, key, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != h.Argon2.Memory ||
+		params.Iterations != h.Argon2.Iterations ||
+		params.Parallelism != h.Argon2.Parallelism ||
+		uint32(len(key)) != h.Argon2.KeyLength
+}
+
+func isBcrypt(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2a$")) ||
+		bytes.HasPrefix(hash, []byte("$2b$")) ||
+		bytes.HasPrefix(hash, []byte("$2y$"))
+}
+
+func parseArgon2id(hash []byte) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,83 @@
+package hashing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+)
+
+// The benchmarks measure how long a password hash takes, to tune
+// PASSWORD_HASH_ALGORITHM, BCRYPT_COST and the ARGON2_* variables for the
+// hardware the service runs on. Every password login pays this cost once,
+// so aim for a few hundred milliseconds at most. The settings from the
+// environment are measured along with a few reference ones:
+//
+//	ARGON2_MEMORY=131072 go test -run - -bench . ./internal/hashing
+
+var password = []byte("correct horse battery staple")
+
+func benchmarkHashers(b *testing.B) map[string]*Hasher {
+	b.Helper()
+
+	settings := map[string]*config.AuthConfig{
+		"configured": config.GetConfig().Auth,
+	}
+
+	for _, memory := range []int{19 * 1024, 64 * 1024} {
+		settings[fmt.Sprintf("argon2id/m=%d,t=3,p=2", memory)] = &config.AuthConfig{
+			PasswordHashAlgorithm: AlgorithmArgon2id,
+			Argon2Memory:          memory,
+			Argon2Iterations:      3,
+			Argon2Parallelism:     2,
+		}
+	}
+
+	for _, cost := range []int{10, 12} {
+		settings[fmt.Sprintf("bcrypt/cost=%d", cost)] = &config.AuthConfig{
+			PasswordHashAlgorithm: AlgorithmBcrypt,
+			BcryptCost:            cost,
+		}
+	}
+
+	hashers := make(map[string]*Hasher)
+	for name, cfg := range settings {
+		hasher, err := NewHasher(cfg)
+		if err != nil {
+			b.Fatalf("%s: %v", name, err)
+		}
+
+		hashers[name] = hasher
+	}
+
+	return hashers
+}
+
+func BenchmarkHash(b *testing.B) {
+	for name, hasher := range benchmarkHashers(b) {
+		b.Run(name, func(b *testing.B) {
+			for range b.N {
+				if _, err := hasher.Hash(password); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCompare(b *testing.B) {
+	for name, hasher := range benchmarkHashers(b) {
+		hash, err := hasher.Hash(password)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			for range b.N {
+				if err := hasher.Compare(hash, password); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+// Package oidc is an OpenID Connect relying party client: authorization code
+// flow with PKCE, and ID token validation against the provider's JWKS.
+// Provider metadata is discovered lazily from the issuer, so nothing is
+// fetched until the first login.
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+// keys are fetched again on an unknown kid, but not more often than this
+const jwksRefreshInterval = time.Minute
+
+type Provider struct {
+	Name string
+
+	cfg    *config.OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *discovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the ID token claims used to find or create the local user.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+}
+
+func NewProviders(cfg *config.OIDCConfig) map[string]*Provider {
+	providers := make(map[string]*Provider, len(cfg.Providers))
+
+	for name, providerCfg := range cfg.Providers {
+		providers[name] = &Provider{
+			Name:   name,
+			cfg:    providerCfg,
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	}
+
+	return providers
+}
+
+// NewVerifier returns a random PKCE code verifier (RFC 7636).
+func NewVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthCodeURL is the provider page the user is redirected to.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	authURL, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	// keep parameters the provider put into the endpoint itself
+	for key, values := range authURL.Query() {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange redeems the authorization code and returns the validated
+// claims of the ID token.
+func (p *Provider) Exchange(code, verifier, nonce string) (*Claims, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	// public clients identify themselves in the body
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: token endpoint returned %d", ErrExchangeFailed, resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if tokenResp.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id token in response", ErrExchangeFailed)
+	}
+
+	return p.verifyIDToken(tokenResp.IDToken, nonce)
+}
+
+func (p *Provider) verifyIDToken(rawIDToken, nonce string) (*Claims, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	// signature, exp, iat and nbf
+	token, err := jwt.Parse(rawIDToken, p.keyfunc)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	if iss, _ := claims["iss"].(string); iss != d.Issuer {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, ErrInvalidIDToken
+	}
+
+	// with several audiences the token must be issued to us
+	if aud, ok := claims["aud"].([]interface{}); ok && len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, ErrInvalidIDToken
+		}
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	result := &Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.GivenName, _ = claims["given_name"].(string)
+	result.FamilyName, _ = claims["family_name"].(string)
+	result.Name, _ = claims["name"].(string)
+
+	// some providers send the flag as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+
+	if result.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return result, nil
+}
+
+func (p *Provider) keyfunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case "RS256", "ES256", "EdDSA":
+	default:
+		return nil, ErrInvalidIDToken
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := p.getKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (p *Provider) getDiscovery() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	if err := p.getJSON(strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("issuer mismatch in discovery document: %q", d.Issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("incomplete discovery document")
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *Provider) getKey(kid string) (crypto.PublicKey, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	// the provider may have rotated its keys
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, ErrInvalidIDToken
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := p.getJSON(d.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	p.keys = make(map[string]crypto.PublicKey, len(jwks.Keys))
+	p.keysFetchedAt = time.Now()
+
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		if key, err := k.publicKey(); err == nil {
+			p.keys[k.KeyID] = key
+		}
+	}
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	return nil, ErrInvalidIDToken
+}
+
+// lookupKey finds a key by kid, a token without kid is accepted only if
+// the provider publishes a single key
+func (p *Provider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) getJSON(url string, v any) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+
+	return nil
+}
+
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+
+	N     string `json:"n"`
+	E     string `json:"e"`
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch {
+	case k.KeyType == "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decode(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case k.KeyType == "EC" && k.Curve == "P-256":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid ec point")
+		}
+
+		return key, nil
+	case k.KeyType == "OKP" && k.Curve == "Ed25519":
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+}
@@ -0,0 +1,71 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachRule looks the password up in a local copy of the Have I Been
+// Pwned range files, nothing is sent over the network. Dir holds one file
+// per 5 character SHA-1 prefix, named "21BD1" or "21BD1.txt", with lines
+// of "<35 character suffix>:<count>", as the k-anonymity api serves them.
+// A missing range file counts as no breach, so a partial copy is fine.
+type BreachRule struct {
+	Dir string
+}
+
+func (r *BreachRule) Check(password string, user UserInfo) (*Violation, error) {
+	breached, err := r.breached(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if breached {
+		return &Violation{Rule: "breached", Message: "password appeared in a data breach"}, nil
+	}
+
+	return nil, nil
+}
+
+func (r *BreachRule) breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := r.openRange(prefix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+
+		// padded responses have fake entries with a zero count
+		if strings.EqualFold(lineSuffix, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+func (r *BreachRule) openRange(prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(r.Dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(filepath.Join(r.Dir, prefix+".txt"))
+	}
+
+	return file, err
+}
@@ -0,0 +1,82 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRange writes a range file with the given lines, "%s" stands for the
+// prefix of the password in the name and for its suffix in the lines.
+func writeRange(t *testing.T, dir string, name string, password string, lines ...string) {
+	t.Helper()
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	content := strings.ReplaceAll(strings.Join(lines, "\r\n"), "%s", hash[5:])
+	if err := os.WriteFile(filepath.Join(dir, strings.ReplaceAll(name, "%s", hash[:5])), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const breachedPassword = "hunter2"
+
+func TestBreachRule(t *testing.T) {
+	const other = "0000000000000000000000000000000000A"
+
+	tests := []struct {
+		name  string
+		file  string
+		lines []string
+		want  bool
+	}{
+		{
+			name:  "breached",
+			file:  "%s",
+			lines: []string{other + ":3", "%s:42"},
+			want:  true,
+		},
+		{
+			name:  "not in the range",
+			file:  "%s",
+			lines: []string{other + ":3"},
+		},
+		{
+			name:  "padding entry",
+			file:  "%s",
+			lines: []string{other + ":3", "%s:0"},
+		},
+		{
+			name:  "txt file",
+			file:  "%s.txt",
+			lines: []string{"%s:7"},
+			want:  true,
+		},
+		{
+			// a partial copy of the files
+			name:  "no range file",
+			file:  "FFFFF",
+			lines: []string{"%s:42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeRange(t, dir, tt.file, breachedPassword, tt.lines...)
+
+			violation, err := (&BreachRule{Dir: dir}).Check(breachedPassword, UserInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := violation != nil; got != tt.want {
+				t.Errorf("breached: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package password checks new passwords against a policy made of
+// independent rules: length, estimated strength, personal information and
+// known breaches. Every rule is checked, so the user sees all the problems
+// at once.
+package password
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+)
+
+// bcrypt ignores everything past the first 72 bytes
+const bcryptMaxLength = 72
+
+// Violation is a rule the password broke.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// UserInfo is what is known about the owner of the password, it must not
+// be guessable from it.
+type UserInfo struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+type Rule interface {
+	// nil if the password follows the rule
+	Check(password string, user UserInfo) (*Violation, error)
+}
+
+type Policy struct {
+	Rules []Rule
+}
+
+func NewPolicy(cfg *config.AuthConfig) *Policy {
+	maxLength := cfg.PasswordMaxLength
+	if cfg.PasswordHashAlgorithm == "bcrypt" {
+		maxLength = min(maxLength, bcryptMaxLength)
+	}
+
+	rules := []Rule{
+		&LengthRule{
+			Min: cfg.PasswordMinLength,
+			Max: maxLength,
+		},
+		&StrengthRule{MinScore: cfg.PasswordMinScore},
+		&PersonalInfoRule{},
+	}
+
+	if cfg.BreachedPasswordsDir != "" {
+		rules = append(rules, &BreachRule{Dir: cfg.BreachedPasswordsDir})
+	}
+
+	return &Policy{Rules: rules}
+}
+
+// Check returns every violation of the policy, none if the password is fine.
+func (p *Policy) Check(password string, user UserInfo) ([]Violation, error) {
+	var violations []Violation
+
+	for _, rule := range p.Rules {
+		violation, err := rule.Check(password, user)
+		if err != nil {
+			return nil, err
+		}
+
+		if violation != nil {
+			violations = append(violations, *violation)
+		}
+	}
+
+	return violations, nil
+}
+
+// LengthRule counts characters for the minimum and bytes for the maximum,
+// which is what the hash function limits.
+type LengthRule struct {
+	Min int
+	Max int
+}
+
+func (r *LengthRule) Check(password string, user UserInfo) (*Violation, error) {
+	if utf8.RuneCountInString(password) < r.Min {
+		return &Violation{Rule: "min_length", Message: "password is too short"}, nil
+	}
+
+	if r.Max > 0 && len(password) > r.Max {
+		return &Violation{Rule: "max_length", Message: "password is too long"}, nil
+	}
+
+	return nil, nil
+}
+
+type StrengthRule struct {
+	// 0 to 4, see Score
+	MinScore int
+}
+
+func (r *StrengthRule) Check(password string, user UserInfo) (*Violation, error) {
+	if Score(password) < r.MinScore {
+		return &Violation{Rule: "strength", Message: "password is too easy to guess"}, nil
+	}
+
+	return nil, nil
+}
+
+// PersonalInfoRule rejects passwords containing the user's email or name.
+type PersonalInfoRule struct{}
+
+func (r *PersonalInfoRule) Check(password string, user UserInfo) (*Violation, error) {
+	normalized := normalize(password)
+
+	localPart, _, _ := strings.Cut(user.Email, "@")
+
+	for _, info := range []string{user.Email, localPart, user.FirstName, user.LastName} {
+		// short names would reject too much
+		if utf8.RuneCountInString(info) < 3 {
+			continue
+		}
+
+		if strings.Contains(normalized, normalize(info)) {
+			return &Violation{Rule: "personal_info", Message: "password must not contain your email or name"}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// normalize lowercases and undoes common character substitutions, so that
+// "P4ssw0rd" is seen as "password". It maps rune to rune, the result has
+// as many runes as s.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if l, ok := leet[r]; ok {
+			return l
+		}
+		return r
+	}, s)
+}
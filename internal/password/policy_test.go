@@ -0,0 +1,170 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+)
+
+func violated(violations []Violation, rule string) bool {
+	for _, violation := range violations {
+		if violation.Rule == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestPolicyMaxLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		maxLength int
+		password  string
+		want      bool
+	}{
+		{
+			name:      "bcrypt within 72 bytes",
+			algorithm: "bcrypt",
+			maxLength: 128,
+			password:  strings.Repeat("x", 72),
+		},
+		{
+			name:      "bcrypt over 72 bytes",
+			algorithm: "bcrypt",
+			maxLength: 128,
+			password:  strings.Repeat("x", 73),
+			want:      true,
+		},
+		{
+			// 37 characters but 74 bytes
+			name:      "bcrypt counts bytes",
+			algorithm: "bcrypt",
+			maxLength: 128,
+			password:  strings.Repeat("ж", 37),
+			want:      true,
+		},
+		{
+			name:      "bcrypt with a lower max",
+			algorithm: "bcrypt",
+			maxLength: 64,
+			password:  strings.Repeat("x", 65),
+			want:      true,
+		},
+		{
+			name:      "argon2id over 72 bytes",
+			algorithm: "argon2id",
+			maxLength: 128,
+			password:  strings.Repeat("x", 100),
+		},
+		{
+			name:      "argon2id over the max",
+			algorithm: "argon2id",
+			maxLength: 128,
+			password:  strings.Repeat("x", 129),
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPolicy(&config.AuthConfig{
+				PasswordHashAlgorithm: tt.algorithm,
+				PasswordMinLength:     8,
+				PasswordMaxLength:     tt.maxLength,
+			})
+
+			violations, err := policy.Check(tt.password, UserInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := violated(violations, "max_length"); got != tt.want {
+				t.Errorf("max_length violated: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyReportsEveryViolation(t *testing.T) {
+	policy := NewPolicy(&config.AuthConfig{
+		PasswordMinLength: 8,
+		PasswordMaxLength: 72,
+		PasswordMinScore:  3,
+	})
+
+	violations, err := policy.Check("jane", UserInfo{Email: "jane@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rule := range []string{"min_length", "strength", "personal_info"} {
+		if !violated(violations, rule) {
+			t.Errorf("%s not reported, got %v", rule, violations)
+		}
+	}
+
+	violations, err = policy.Check("correct horse battery staple", UserInfo{Email: "jane@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(violations) != 0 {
+		t.Errorf("got %v for a strong password", violations)
+	}
+}
+
+func TestPersonalInfoRule(t *testing.T) {
+	user := UserInfo{
+		Email:     "jdoe@example.com",
+		FirstName: "Jane",
+		LastName:  "Al",
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{name: "email", password: "my jdoe@example.com pass", want: true},
+		{name: "local part", password: "xx-jdoe-xx", want: true},
+		{name: "first name", password: "ilovejane99", want: true},
+		{name: "capitalized", password: "JANE-in-the-gym", want: true},
+		{name: "leetspeak", password: "j4n3 rocks", want: true},
+		{name: "leetspeak email", password: "jd0e@3x4mpl3.c0m", want: true},
+		// names shorter than 3 characters are not checked
+		{name: "short last name", password: "always albatross"},
+		{name: "unrelated", password: "correct horse battery staple"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation, err := (&PersonalInfoRule{}).Check(tt.password, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := violation != nil; got != tt.want {
+				t.Errorf("violated: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"P4ssw0rd":   "password",
+		"$3cr37":     "secret",
+		"@dm1n":      "admin",
+		"Жанна":      "жанна",
+		"plain text": "plain text",
+	}
+
+	for in, want := range tests {
+		if got := normalize(in); got != want {
+			t.Errorf("normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
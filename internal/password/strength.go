@@ -0,0 +1,231 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score estimates how hard the password is to guess, from 0 (trivial) to
+// 4 (strong), with the thresholds zxcvbn uses. The password is split from
+// left to right into the cheapest known patterns (common words, years,
+// repeats, sequences, keyboard runs), whatever is left is brute-forced one
+// character at a time, and the guesses of all the parts are multiplied.
+func Score(password string) int {
+	guesses := math.Log10(estimateGuesses(password))
+
+	switch {
+	case guesses <= 3:
+		return 0
+	case guesses <= 6:
+		return 1
+	case guesses <= 8:
+		return 2
+	case guesses <= 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// patterns shorter than that are brute-forced
+const minPatternLength = 3
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"qazwsxedcrfvtgbyhnujmik,ol.p;/",
+}
+
+// a handful of the most used passwords and words they are built from
+var commonWords = []string{
+	"password", "passw", "qwerty", "letmein", "welcome", "admin", "login",
+	"iloveyou", "monkey", "dragon", "master", "sunshine", "princess",
+	"football", "baseball", "soccer", "shadow", "superman", "batman",
+	"trustno", "starwars", "whatever", "freedom", "secret", "hello",
+	"love", "test", "user", "abc", "qwe", "asd", "zxc",
+	"gym", "gymshark", "fitness", "workout", "muscle", "strong", "power",
+}
+
+func estimateGuesses(password string) float64 {
+	runes := []rune(password)
+	normalized := []rune(normalize(password))
+
+	guesses := 1.0
+	for i := 0; i < len(runes); {
+		n, g := matchPattern(runes, normalized, i)
+		guesses *= g
+		i += n
+	}
+
+	return guesses
+}
+
+// matchPattern returns the length of the pattern starting at i and the
+// guesses it takes.
+func matchPattern(runes, normalized []rune, i int) (int, float64) {
+	if n := matchWord(normalized, i); n > 0 {
+		guesses := 1000.0
+		if hasUpper(runes[i : i+n]) {
+			guesses *= 2
+		}
+		if strings.ToLower(string(runes[i:i+n])) != string(normalized[i:i+n]) {
+			// some characters were substituted
+			guesses *= 2
+		}
+		return n, guesses
+	}
+
+	if n := matchYear(runes, i); n > 0 {
+		return n, 200
+	}
+
+	if n := matchRepeat(runes, i); n > 0 {
+		return n, cardinality(runes[i]) * float64(n)
+	}
+
+	if n := matchSequence(runes, i); n > 0 {
+		return n, 20 * float64(n)
+	}
+
+	if n := matchKeyboard(runes, i); n > 0 {
+		return n, 50 * float64(n)
+	}
+
+	return 1, cardinality(runes[i])
+}
+
+// matchWord finds the longest common word at i.
+func matchWord(normalized []rune, i int) int {
+	rest := string(normalized[i:])
+
+	longest := 0
+	for _, word := range commonWords {
+		if len(word) >= minPatternLength && len(word) > longest && strings.HasPrefix(rest, word) {
+			longest = len(word)
+		}
+	}
+
+	return longest
+}
+
+func matchYear(runes []rune, i int) int {
+	if i+4 > len(runes) {
+		return 0
+	}
+
+	year := string(runes[i : i+4])
+	if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) {
+		return 4
+	}
+
+	return 0
+}
+
+func matchRepeat(runes []rune, i int) int {
+	n := 1
+	for i+n < len(runes) && runes[i+n] == runes[i] {
+		n++
+	}
+
+	if n < minPatternLength {
+		return 0
+	}
+
+	return n
+}
+
+// matchSequence finds runs like "abcd", "4321" or "acegi".
+func matchSequence(runes []rune, i int) int {
+	if i+1 >= len(runes) {
+		return 0
+	}
+
+	step := runes[i+1] - runes[i]
+	if step == 0 || step > 2 || step < -2 {
+		return 0
+	}
+
+	n := 2
+	for i+n < len(runes) && runes[i+n]-runes[i+n-1] == step {
+		n++
+	}
+
+	if n < minPatternLength {
+		return 0
+	}
+
+	return n
+}
+
+// matchKeyboard finds runs of adjacent keys, in either direction.
+func matchKeyboard(runes []rune, i int) int {
+	longest := 0
+
+	for _, row := range keyboardRows {
+		for _, r := range []string{row, reverse(row)} {
+			n := 0
+			for i+n < len(runes) {
+				if !strings.Contains(r, strings.ToLower(string(runes[i:i+n+1]))) {
+					break
+				}
+				n++
+			}
+
+			longest = max(longest, n)
+		}
+	}
+
+	if longest < minPatternLength {
+		return 0
+	}
+
+	return longest
+}
+
+// cardinality is the size of the character class a brute force has to try.
+func cardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r):
+		return 26
+	case unicode.IsUpper(r):
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,43 @@
+package password
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     int
+	}{
+		{name: "empty", password: "", want: 0},
+		{name: "common word", password: "password", want: 0},
+		{name: "common phrase", password: "iloveyou", want: 0},
+		{name: "repeat", password: "aaaaaaaa", want: 0},
+		{name: "sequence", password: "12345678", want: 0},
+		{name: "year", password: "1990", want: 0},
+		{name: "leetspeak word", password: "P4ssw0rd", want: 1},
+		{name: "keyboard run", password: "qwertyuiop", want: 1},
+		{name: "word and year", password: "gymshark2024", want: 1},
+		{name: "random", password: "kx7mq2vl9p", want: 4},
+		{name: "random with symbols", password: "xK9#mQ2$vL7!", want: 4},
+		{name: "passphrase", password: "correct horse battery staple", want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.password); got != tt.want {
+				t.Errorf("Score(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreSubstitutions(t *testing.T) {
+	// substitutions and capitals cost a guesser little
+	plain := estimateGuesses("password")
+
+	for _, password := range []string{"Password", "p4ssword", "P@$$w0rd"} {
+		if got := estimateGuesses(password); got > 4*plain {
+			t.Errorf("%q takes %.0f guesses, want at most %.0f", password, got, 4*plain)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// Deleted accounts wait AccountDeletionGracePeriod before they are purged.
+// Until then the email stays taken and the account can be restored with
+// the link from the confirmation email; the purge then removes the user
+// with everything that references it and the avatar. The audit log is
+// kept, it is append-only, but loses the ip and user agent of the events.
+
+type DeleteAccountReq struct {
+	Password string `json:"password"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// DeleteAccount deletes the account of the user after checking the
+// password again, every session and access token is revoked. Accounts
+// without a password, created through an external provider, have to set
+// one with ForgotPassword first. Owners of oauth clients have to delete
+// them first, the purge would take them down with the account and leave
+// the partner apps broken.
+func (s *Service) DeleteAccount(userID uuid.UUID, req *DeleteAccountReq) error {
+	if err := s.checkAccountAttempts(userID); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	if len(user.PasswordHash) == 0 {
+		return ErrInvalidPassword
+	}
+
+	if err := s.Hasher.Compare(user.PasswordHash, []byte(req.Password)); err != nil {
+		if err != hashing.ErrMismatchedHash {
+			s.Logger.Error("failed to verify password", "err", err)
+			return err
+		}
+
+		s.recordFailedAttempt(user, req.Client.IP)
+		return ErrInvalidPassword
+	}
+
+	clients, err := s.Storage.OAuthClient.GetAllByOwner(user.ID)
+	if err != nil {
+		s.Logger.Error("failed to get oauth clients", "err", err)
+		return err
+	}
+
+	if len(clients) > 0 {
+		return ErrOwnsOAuthClients
+	}
+
+	restoreSecret, restoreHash, err := generateSelectorSecret()
+	if err != nil {
+		s.Logger.Error("failed to generate restore token", "err", err)
+		return err
+	}
+
+	purgeAt := time.Now().Add(s.Cfg.AccountDeletionGracePeriod)
+
+	// the code is saved first, a deleted account must never lack the link
+	code := &models.Code{
+		UserID:    user.ID,
+		Hash:      restoreHash,
+		Scope:     models.CodeScopeRestore,
+		ExpiresAt: purgeAt,
+	}
+
+	if err := s.Storage.Code.Insert(code); err != nil {
+		s.Logger.Error("failed to save restore code", "err", err)
+		return err
+	}
+
+	if err := s.Storage.User.MarkDeleted(user.ID); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to mark user deleted", "err", err)
+		return err
+	}
+
+	if err := s.LogoutAll(user.ID, req.Client); err != nil {
+		return err
+	}
+
+	if err := s.deleteLoginCodes(user.ID); err != nil {
+		return err
+	}
+
+	s.Logger.Info("account deleted", "user_id", user.ID)
+
+	restoreURL := s.Cfg.AppURL + "/api/v1/me/restore?" + url.Values{
+		"token": {formatSelectorToken(code.ID, restoreSecret)},
+	}.Encode()
+
+	go func() {
+		if err := s.Mailer.SendAccountDeletedEmail(user.Email, purgeAt, restoreURL); err != nil {
+			s.Logger.Error("failed to send account deleted email", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// RestoreAccount undoes DeleteAccount, it is called from the link in the
+// confirmation email. The user has to log in again.
+func (s *Service) RestoreAccount(restoreToken string) error {
+	codeID, secret, err := parseSelectorToken(restoreToken)
+	if err != nil {
+		return ErrInvalidRestoreToken
+	}
+
+	code, err := s.Storage.Code.GetByID(codeID)
+	if err != nil {
+		if err == models.ErrCodeNotFound {
+			return ErrInvalidRestoreToken
+		}
+
+		s.Logger.Error("failed to get restore code", "err", err)
+		return err
+	}
+
+	if code.Scope != models.CodeScopeRestore {
+		return ErrInvalidRestoreToken
+	}
+
+	if !verifySelectorSecret(code.Hash, secret) {
+		return ErrInvalidRestoreToken
+	}
+
+	if code.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidRestoreToken
+	}
+
+	if err := s.Storage.Code.Consume(code.ID); err != nil {
+		if err == models.ErrCodeNotFound {
+			return ErrInvalidRestoreToken
+		}
+
+		s.Logger.Error("failed to delete restore code", "err", err)
+		return err
+	}
+
+	if err := s.Storage.User.Restore(code.UserID); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrInvalidRestoreToken
+		}
+
+		s.Logger.Error("failed to restore user", "err", err)
+		return err
+	}
+
+	s.Logger.Info("account restored", "user_id", code.UserID)
+	return nil
+}
+
+// PurgeDeletedAccounts erases the accounts whose grace period is over. An
+// account that fails is left for the next run.
+func (s *Service) PurgeDeletedAccounts() error {
+	users, err := s.Storage.User.GetAllDeletedBefore(time.Now().Add(-s.Cfg.AccountDeletionGracePeriod))
+	if err != nil {
+		s.Logger.Error("failed to get deleted users", "err", err)
+		return err
+	}
+
+	for _, user := range users {
+		if err := s.purgeAccount(user); err != nil {
+			s.Logger.Error("failed to purge account", "err", err, "user_id", user.ID)
+			continue
+		}
+
+		s.Logger.Info("account purged", "user_id", user.ID)
+	}
+
+	return nil
+}
+
+// purgeAccount removes the avatar first: once the row is gone nothing
+// points to it anymore.
+func (s *Service) purgeAccount(user *models.User) error {
+	if user.AvatarID != "" {
+		if err := s.Storage.Avatar.Delete(user.AvatarID); err != nil {
+			return err
+		}
+	}
+
+	// attempts are not keyed by a foreign key
+	if err := s.Storage.Attempt.Reset(accountAttemptKey(user.ID)); err != nil {
+		return err
+	}
+
+	// the rest references the user and goes with it, a user restored in
+	// the meantime is left alone
+	if err := s.Storage.User.Purge(user.ID); err != nil && err != models.ErrUserNotFound {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+func TestDeleteAccountOwnsOAuthClients(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+
+	client := &models.OAuthClient{OwnerID: user.ID, Name: "partner"}
+	if err := s.Storage.OAuthClient.Insert(client); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &DeleteAccountReq{Password: testPassword, Client: testClient}
+
+	if err := s.DeleteAccount(user.ID, req); err != ErrOwnsOAuthClients {
+		t.Fatalf("got %v, want %v", err, ErrOwnsOAuthClients)
+	}
+
+	if user, _ := s.Storage.User.GetByID(user.ID); user.State != models.UserStateActive {
+		t.Fatalf("got state %s, want the account kept", user.State)
+	}
+
+	if err := s.Storage.OAuthClient.Delete(user.ID, client.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteAccount(user.ID, req); err != nil {
+		t.Fatalf("delete account without clients: %v", err)
+	}
+}
+
+func TestDeleteAccountDropsPendingLogins(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	enableTOTP(t, s, user.ID)
+
+	challenge := login(t, s, "user@example.com")
+
+	if err := s.DeleteAccount(user.ID, &DeleteAccountReq{Password: testPassword, Client: testClient}); err != nil {
+		t.Fatalf("delete account: %v", err)
+	}
+
+	// the challenge is checked before the code
+	_, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: "000000", Client: testClient})
+	if err != ErrInvalidMFAToken {
+		t.Errorf("mfa challenge of a deleted account: got %v, want %v", err, ErrInvalidMFAToken)
+	}
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	s, db := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	login(t, s, "user@example.com")
+
+	if err := s.DeleteAccount(user.ID, &DeleteAccountReq{Password: testPassword, Client: testClient}); err != nil {
+		t.Fatalf("delete account: %v", err)
+	}
+
+	s.Cfg.AccountDeletionGracePeriod = 0
+
+	if err := s.PurgeDeletedAccounts(); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	if _, err := s.Storage.User.GetByID(user.ID); err != models.ErrUserNotFound {
+		t.Fatalf("got %v, want the user purged", err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	kept := 0
+	for _, event := range db.events {
+		if event.UserID.UUID != user.ID {
+			continue
+		}
+
+		kept++
+		if event.IP != "" || event.UserAgent != "" {
+			t.Errorf("%s event kept the client %q, %q", event.Type, event.IP, event.UserAgent)
+		}
+	}
+
+	if kept == 0 {
+		t.Error("the audit log of the account was dropped")
+	}
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// api keys have the form gsk_<key_id>.<secret>. Like other selector tokens
+// the secret is hashed with sha256 instead of the password hasher: it is
+// random and long enough not to need a slow hash.
+const APIKeyPrefix = "gsk_"
+
+// APIKeyScopes are the scopes a key may be limited to: read keys may only
+// make safe (GET, HEAD) requests.
+var APIKeyScopes = []string{"read", "write"}
+
+// the last use is written at most once per interval
+const apiKeyLastUsedInterval = time.Minute
+
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type CreateAPIKeyReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// empty for keys that never expire
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateAPIKeyResp struct {
+	APIKey
+	// shown only once
+	Key string `json:"key"`
+}
+
+type APIKeyClaims struct {
+	UserID      uuid.UUID
+	KeyID       uuid.UUID
+	Scopes      []string
+	Roles       []string
+	Permissions []string
+}
+
+// Allows reports whether the scopes of the key permit a request with the method.
+func (c *APIKeyClaims) Allows(method string) bool {
+	if slices.Contains(c.Scopes, "write") {
+		return true
+	}
+
+	switch method {
+	case "GET", "HEAD":
+		return slices.Contains(c.Scopes, "read")
+	default:
+		return false
+	}
+}
+
+func (s *Service) CreateAPIKey(userID uuid.UUID, req *CreateAPIKeyReq) (CreateAPIKeyResp, error) {
+	if err := validateCreateAPIKeyReq(req); err != nil {
+		return CreateAPIKeyResp{}, err
+	}
+
+	secret, err := generateCode(32)
+	if err != nil {
+		s.Logger.Error("failed to generate api key", "err", err)
+		return CreateAPIKeyResp{}, err
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Hash:      hash[:],
+		Name:      req.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.Storage.APIKey.Insert(key); err != nil {
+		s.Logger.Error("failed to save api key", "err", err)
+		return CreateAPIKeyResp{}, err
+	}
+
+	return CreateAPIKeyResp{
+		APIKey: newAPIKey(key),
+		Key:    APIKeyPrefix + formatSelectorToken(key.ID, secret),
+	}, nil
+}
+
+func (s *Service) APIKeys(userID uuid.UUID) ([]APIKey, error) {
+	keys, err := s.Storage.APIKey.GetAllByUser(userID)
+	if err != nil {
+		s.Logger.Error("failed to get api keys", "err", err)
+		return nil, err
+	}
+
+	result := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, newAPIKey(key))
+	}
+
+	return result, nil
+}
+
+func (s *Service) RevokeAPIKey(userID uuid.UUID, keyID uuid.UUID) error {
+	if err := s.Storage.APIKey.Delete(userID, keyID); err != nil {
+		if err == models.ErrAPIKeyNotFound {
+			return ErrAPIKeyNotFound
+		}
+
+		s.Logger.Error("failed to delete api key", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// AuthorizeAPIKey is the counterpart of Authorize for api keys.
+func (s *Service) AuthorizeAPIKey(apiKey string) (*APIKeyClaims, error) {
+	selector, ok := strings.CutPrefix(apiKey, APIKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	keyID, secret, err := parseSelectorToken(selector)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.Storage.APIKey.GetByID(keyID)
+	if err != nil {
+		if err == models.ErrAPIKeyNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+
+		s.Logger.Error("failed to get api key", "err", err)
+		return nil, err
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(hash[:], key.Hash) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	// unlike access tokens keys live long, so the user is checked every time
+	user, err := s.Storage.User.GetByID(key.UserID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return nil, err
+	}
+
+	if user.State == models.UserStateSuspended {
+		return nil, ErrUserSuspended
+	}
+
+	if user.State != models.UserStateActive {
+		return nil, ErrInvalidAPIKey
+	}
+
+	roles, permissions, err := s.Storage.Role.GetByUser(user.ID)
+	if err != nil {
+		s.Logger.Error("failed to get roles of user", "err", err)
+		return nil, err
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := s.Storage.APIKey.UpdateLastUsed(key.ID, time.Now()); err != nil {
+			s.Logger.Error("failed to update api key last use", "err", err)
+		}
+	}
+
+	return &APIKeyClaims{
+		UserID:      key.UserID,
+		KeyID:       key.ID,
+		Scopes:      key.Scopes,
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
+
+func newAPIKey(key *models.APIKey) APIKey {
+	return APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
+
+func validateCreateAPIKeyReq(req *CreateAPIKeyReq) error {
+	if len(req.Name) == 0 || len(req.Name) > 100 {
+		return ErrInvalidAPIKeyName
+	}
+
+	if len(req.Scopes) == 0 {
+		return ErrInvalidScope
+	}
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			return ErrInvalidScope
+		}
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidExpiry
+	}
+
+	return nil
+}
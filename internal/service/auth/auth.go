@@ -7,52 +7,150 @@ import (
 	"github.com/google/uuid"
 )
 
-func (s *Service) Authorize(accessToken string) (uuid.UUID, error) {
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidAccessToken
-		}
-		return s.Cfg.JWTKey, nil
-	})
+type Claims struct {
+	TokenID     string
+	UserID      uuid.UUID
+	SessionID   uuid.UUID
+	Roles       []string
+	Permissions []string
+	ExpiresAt   time.Time
+}
+
+func (s *Service) Authorize(accessToken string) (*Claims, error) {
+	token, err := jwt.Parse(accessToken, s.Keys.Keyfunc)
 
 	if err != nil {
 		s.Logger.Error("failed to parse access token", "err", err)
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
+	}
+
+	// tokens of partner apps are checked with AuthorizeClient
+	if _, ok := claims["client_id"]; ok {
+		return nil, ErrInvalidAccessToken
 	}
 
 	expirationClaim, ok := claims["exp"]
 	if !ok {
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
 	}
 
 	expirationFloat, ok := expirationClaim.(float64)
 	if !ok {
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
 	}
 
 	if int64(expirationFloat) < time.Now().Unix() {
-		return uuid.Nil, ErrAccessTokenExpired
+		return nil, ErrAccessTokenExpired
 	}
 
 	userIDClaim, ok := claims["sub"]
 	if !ok {
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
 	}
 
 	userIDString, ok := userIDClaim.(string)
 	if !ok {
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
 	}
 
 	userID, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, ErrInvalidAccessToken
+		return nil, ErrInvalidAccessToken
+	}
+
+	sessionIDClaim, ok := claims["sid"]
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	sessionIDString, ok := sessionIDClaim.(string)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	sessionID, err := uuid.Parse(sessionIDString)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	tokenID, ok := claims["jti"].(string)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	issuedAt, ok := claims["iat"].(float64)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if err := s.checkAccessTokenRevoked(tokenID, uuid.NullUUID{UUID: userID, Valid: true}, uuid.NullUUID{UUID: sessionID, Valid: true}, time.Unix(int64(issuedAt), 0)); err != nil {
+		return nil, err
+	}
+
+	roles, ok := stringsClaim(claims["roles"])
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	permissions, ok := stringsClaim(claims["perms"])
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return &Claims{
+		TokenID:     tokenID,
+		UserID:      userID,
+		SessionID:   sessionID,
+		Roles:       roles,
+		Permissions: permissions,
+		ExpiresAt:   time.Unix(int64(expirationFloat), 0),
+	}, nil
+}
+
+// issueAccessToken signs an access token with the current roles of the
+// user, so role changes apply from the next refresh.
+func (s *Service) issueAccessToken(userID uuid.UUID, sessionID uuid.UUID) (string, error) {
+	roles, permissions, err := s.Storage.Role.GetByUser(userID)
+	if err != nil {
+		s.Logger.Error("failed to get roles of user", "err", err)
+		return "", err
+	}
+
+	accessToken, err := generateAccessToken(userID, sessionID, roles, permissions, s.Cfg.AccessTokenTTL, s.Keys)
+	if err != nil {
+		s.Logger.Error("failed to generate access token", "err", err)
+		return "", err
+	}
+
+	return accessToken, nil
+}
+
+// stringsClaim reads a claim holding a list of strings, a missing claim is
+// an empty list.
+func stringsClaim(claim interface{}) ([]string, bool) {
+	if claim == nil {
+		return nil, true
+	}
+
+	values, ok := claim.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+
+		result = append(result, s)
 	}
 
-	return userID, nil
+	return result, true
 }
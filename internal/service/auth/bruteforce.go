@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// Failed logins, confirmation and second factor codes are counted per
+// account and per ip. After a few failures every next attempt has to wait
+// a growing delay, and once the limit is reached the account (or the ip)
+// is locked for a while. A locked account can be unlocked early with the
+// code from the unlock email.
+
+type UnlockReq struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+
+	Client ClientInfo `json:"-"`
+}
+
+func accountAttemptKey(userID uuid.UUID) string {
+	return "account:" + userID.String()
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkAttempts refuses the attempt while the key is locked (lockedErr) or
+// the delay after its last failure has not passed yet.
+func (s *Service) checkAttempts(key string, lockedErr error) error {
+	attempt, err := s.Storage.Attempt.Get(key)
+	if err != nil {
+		s.Logger.Error("failed to get login attempts", "err", err)
+		return err
+	}
+
+	now := time.Now()
+
+	if attempt.LockedUntil.After(now) {
+		return lockedErr
+	}
+
+	if attempt.LastFailedAt.Add(s.attemptDelay(attempt.Failures)).After(now) {
+		return ErrTooManyAttempts
+	}
+
+	return nil
+}
+
+// attemptDelay doubles with every failure past FailuresBeforeDelay.
+func (s *Service) attemptDelay(failures int) time.Duration {
+	n := failures - s.Cfg.FailuresBeforeDelay
+	if n < 0 {
+		return 0
+	}
+
+	if n > 16 {
+		return s.Cfg.MaxAttemptDelay
+	}
+
+	return min(time.Second<<n, s.Cfg.MaxAttemptDelay)
+}
+
+func (s *Service) checkIPAttempts(ip string) error {
+	return s.checkAttempts(ipAttemptKey(ip), ErrTooManyAttempts)
+}
+
+func (s *Service) checkAccountAttempts(userID uuid.UUID) error {
+	return s.checkAttempts(accountAttemptKey(userID), ErrAccountLocked)
+}
+
+// recordFailedAttempt counts a failure against the ip and, if the user is
+// known, against the account, and locks whichever reached its limit.
+// Counting is best effort: errors are logged, the caller still reports
+// the original failure.
+func (s *Service) recordFailedAttempt(user *models.User, ip string) {
+	now := time.Now()
+
+	attempt, err := s.Storage.Attempt.RecordFailure(ipAttemptKey(ip), s.Cfg.AttemptWindow)
+	if err != nil {
+		s.Logger.Error("failed to record failed attempt", "err", err)
+	} else if attempt.Failures >= s.Cfg.MaxIPFailures {
+		until := now.Add(s.Cfg.LockoutDuration)
+
+		if err := s.Storage.Attempt.Lock(attempt.Key, until); err != nil {
+			s.Logger.Error("failed to lock ip", "err", err)
+		} else {
+			s.Logger.Warn("ip locked out", "ip", ip, "failures", attempt.Failures, "until", until)
+		}
+	}
+
+	if user == nil {
+		return
+	}
+
+	attempt, err = s.Storage.Attempt.RecordFailure(accountAttemptKey(user.ID), s.Cfg.AttemptWindow)
+	if err != nil {
+		s.Logger.Error("failed to record failed attempt", "err", err)
+		return
+	}
+
+	if attempt.Failures < s.Cfg.MaxAccountFailures {
+		return
+	}
+
+	until := now.Add(s.Cfg.LockoutDuration)
+
+	if err := s.Storage.Attempt.Lock(attempt.Key, until); err != nil {
+		s.Logger.Error("failed to lock account", "err", err)
+		return
+	}
+
+	s.Logger.Warn("account locked out", "user_id", user.ID, "ip", ip, "failures", attempt.Failures, "until", until)
+
+	if err := s.issueUnlockCode(user); err != nil {
+		s.Logger.Error("failed to issue unlock code", "err", err)
+	}
+}
+
+// resetAttempts forgets the failures of the account after a successful
+// attempt. The ip counter is kept, one valid account must not reset it.
+func (s *Service) resetAttempts(userID uuid.UUID) error {
+	if err := s.Storage.Attempt.Reset(accountAttemptKey(userID)); err != nil {
+		s.Logger.Error("failed to reset login attempts", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// issueUnlockCode emails the user a code for Unlock.
+func (s *Service) issueUnlockCode(user *models.User) error {
+	code, err := generateCode(8)
+	if err != nil {
+		return err
+	}
+
+	codeHash, err := s.Hasher.Hash([]byte(code))
+	if err != nil {
+		return err
+	}
+
+	t := &models.Code{
+		UserID:    user.ID,
+		Hash:      codeHash,
+		Scope:     models.CodeScopeUnlock,
+		ExpiresAt: time.Now().Add(s.Cfg.LockoutDuration),
+	}
+
+	if err := s.Storage.Code.Insert(t); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.Mailer.SendUnlockEmail(user.Email, code); err != nil {
+			s.Logger.Error("failed to send unlock email", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Service) Unlock(req *UnlockReq) error {
+	if err := validateUnlockReq(req); err != nil {
+		return err
+	}
+
+	if err := s.checkIPAttempts(req.Client.IP); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			s.recordFailedAttempt(nil, req.Client.IP)
+			return ErrInvalidCode
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return err
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeUnlock)
+	if err != nil {
+		s.Logger.Error("failed to get unlock codes", "err", err)
+		return err
+	}
+
+	for _, code := range codes {
+		if err := s.Hasher.Compare(code.Hash, []byte(req.Code)); err != nil {
+			if err != hashing.ErrMismatchedHash {
+				s.Logger.Error("failed to verify unlock code", "err", err)
+				return err
+			}
+
+			continue
+		}
+
+		if code.ExpiresAt.Before(time.Now()) {
+			return ErrCodeExpired
+		}
+
+		if err := s.Storage.Code.DeleteAllByUser(user.ID, models.CodeScopeUnlock); err != nil {
+			s.Logger.Error("failed to delete unlock codes", "err", err)
+			return err
+		}
+
+		if err := s.resetAttempts(user.ID); err != nil {
+			return err
+		}
+
+		s.Logger.Info("account unlocked", "user_id", user.ID, "ip", req.Client.IP)
+		return nil
+	}
+
+	// only the ip is counted, wrong unlock codes must not lock the account again
+	s.recordFailedAttempt(nil, req.Client.IP)
+	return ErrInvalidCode
+}
+
+// PruneAttempts drops counters that are neither recent nor locked.
+func (s *Service) PruneAttempts() error {
+	if err := s.Storage.Attempt.DeleteAllStale(time.Now().Add(-s.Cfg.AttemptWindow)); err != nil {
+		s.Logger.Error("failed to prune login attempts", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func validateUnlockReq(req *UnlockReq) error {
+	_, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	if len(req.Code) == 0 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
@@ -4,13 +4,15 @@ import (
 	"net/mail"
 	"time"
 
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
 	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type ConfirmReq struct {
 	Email string `json:"email"`
 	Code  string `json:"code"`
+
+	Client ClientInfo `json:"-"`
 }
 
 func (s *Service) Confirm(req *ConfirmReq) error {
@@ -18,9 +20,14 @@ func (s *Service) Confirm(req *ConfirmReq) error {
 		return err
 	}
 
+	if err := s.checkIPAttempts(req.Client.IP); err != nil {
+		return err
+	}
+
 	user, err := s.Storage.User.GetByEmail(req.Email)
 	if err != nil {
 		if err == models.ErrUserNotFound {
+			s.recordFailedAttempt(nil, req.Client.IP)
 			return ErrUserNotFound
 		}
 
@@ -33,6 +40,13 @@ func (s *Service) Confirm(req *ConfirmReq) error {
 		return ErrUserAlreadyConfirmed
 	case models.UserStateDeleted:
 		return ErrUserNotFound
+	case models.UserStateSuspended:
+		// activating would lift the suspension
+		return ErrUserSuspended
+	}
+
+	if err := s.checkAccountAttempts(user.ID); err != nil {
+		return err
 	}
 
 	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeConfirm)
@@ -42,8 +56,8 @@ func (s *Service) Confirm(req *ConfirmReq) error {
 	}
 
 	for _, code := range codes {
-		if err := bcrypt.CompareHashAndPassword(code.Hash, []byte(req.Code)); err != nil {
-			if err != bcrypt.ErrMismatchedHashAndPassword {
+		if err := s.Hasher.Compare(code.Hash, []byte(req.Code)); err != nil {
+			if err != hashing.ErrMismatchedHash {
 				s.Logger.Error("failed to verify confirmation code", "err", err)
 				return err
 			}
@@ -63,13 +77,70 @@ func (s *Service) Confirm(req *ConfirmReq) error {
 				return err
 			}
 
-			return nil
+			// the other codes sent to the user must not work anymore
+			if err := s.Storage.Code.DeleteAllByUser(user.ID, models.CodeScopeConfirm); err != nil {
+				s.Logger.Error("failed to delete confirmation codes", "err", err)
+				return err
+			}
+
+			s.recordAuthEvent(models.AuthEventConfirm, user.ID, req.Client, nil)
+
+			return s.resetAttempts(user.ID)
 		}
 	}
 
+	s.recordFailedAttempt(user, req.Client.IP)
+	s.recordAuthEvent(models.AuthEventConfirm, user.ID, req.Client, ErrInvalidCode)
 	return ErrInvalidCode
 }
 
+type ResendConfirmationReq struct {
+	Email string `json:"email"`
+}
+
+// ResendConfirmation replaces the confirmation codes of a pending account
+// with a new one. Unknown and confirmed accounts, as well as requests made
+// within the cooldown, are silently ignored, so the response does not
+// reveal whether the email is registered.
+func (s *Service) ResendConfirmation(req *ResendConfirmationReq) error {
+	if err := validateResendConfirmationReq(req); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return nil
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return err
+	}
+
+	if user.State != models.UserStatePending {
+		return nil
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeConfirm)
+	if err != nil {
+		s.Logger.Error("failed to get confirmation codes", "err", err)
+		return err
+	}
+
+	for _, code := range codes {
+		if code.CreatedAt.Add(s.Cfg.ConfirmResendCooldown).After(time.Now()) {
+			return nil
+		}
+	}
+
+	if err := s.Storage.Code.DeleteAllByUser(user.ID, models.CodeScopeConfirm); err != nil {
+		s.Logger.Error("failed to delete confirmation codes", "err", err)
+		return err
+	}
+
+	return s.issueConfirmationCode(user)
+}
+
 func validateConfirmReq(req *ConfirmReq) error {
 	_, err := mail.ParseAddress(req.Email)
 	if err != nil {
@@ -82,3 +153,12 @@ func validateConfirmReq(req *ConfirmReq) error {
 
 	return nil
 }
+
+func validateResendConfirmationReq(req *ResendConfirmationReq) error {
+	_, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type ChangeEmailReq struct {
+	NewEmail string `json:"new_email"`
+	// the address is what a password reset is sent to, so changing it
+	// takes the password like ChangePassword does
+	Password string `json:"password"`
+
+	Client ClientInfo `json:"-"`
+}
+
+type ConfirmEmailChangeReq struct {
+	Code string `json:"code"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// ChangeEmail starts an email change: the new address gets a code for
+// ConfirmEmailChange, the current one gets a link to cancel the change.
+// A new request replaces the pending one once the cooldown has passed.
+func (s *Service) ChangeEmail(userID uuid.UUID, req *ChangeEmailReq) error {
+	if err := validateChangeEmailReq(req); err != nil {
+		return err
+	}
+
+	if err := s.checkAccountAttempts(userID); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	// accounts created through an external provider have no password,
+	// they can set one with ForgotPassword
+	if len(user.PasswordHash) == 0 {
+		return ErrInvalidPassword
+	}
+
+	if err := s.Hasher.Compare(user.PasswordHash, []byte(req.Password)); err != nil {
+		if err != hashing.ErrMismatchedHash {
+			s.Logger.Error("failed to verify password", "err", err)
+			return err
+		}
+
+		s.recordFailedAttempt(user, req.Client.IP)
+		return ErrInvalidPassword
+	}
+
+	if strings.EqualFold(user.Email, req.NewEmail) {
+		return ErrEmailUnchanged
+	}
+
+	// every request mails both addresses
+	pending, err := s.Storage.EmailChange.GetByUser(user.ID)
+	if err != nil && err != models.ErrEmailChangeNotFound {
+		s.Logger.Error("failed to get email change", "err", err)
+		return err
+	}
+
+	if pending != nil && pending.CreatedAt.Add(s.Cfg.EmailChangeCooldown).After(time.Now()) {
+		return ErrEmailChangeTooSoon
+	}
+
+	if err := s.resetAttempts(user.ID); err != nil {
+		return err
+	}
+
+	code, err := generateCode(8)
+	if err != nil {
+		s.Logger.Error("failed to generate email change code", "err", err)
+		return err
+	}
+
+	codeHash, err := s.Hasher.Hash([]byte(code))
+	if err != nil {
+		s.Logger.Error("failed to hash email change code", "err", err)
+		return err
+	}
+
+	cancelSecret, cancelHash, err := generateSelectorSecret()
+	if err != nil {
+		s.Logger.Error("failed to generate cancel token", "err", err)
+		return err
+	}
+
+	change := &models.EmailChange{
+		UserID:     user.ID,
+		NewEmail:   req.NewEmail,
+		CodeHash:   codeHash,
+		CancelHash: cancelHash,
+		ExpiresAt:  time.Now().Add(s.Cfg.EmailChangeTTL),
+	}
+
+	if err := s.Storage.EmailChange.Upsert(change); err != nil {
+		s.Logger.Error("failed to save email change", "err", err)
+		return err
+	}
+
+	cancelURL := s.Cfg.AppURL + "/api/v1/me/email/cancel?" + url.Values{
+		"token": {formatSelectorToken(change.ID, cancelSecret)},
+	}.Encode()
+
+	go func() {
+		if err := s.Mailer.SendEmailChangeCodeEmail(change.NewEmail, code); err != nil {
+			s.Logger.Error("failed to send email change code", "err", err)
+		}
+
+		if err := s.Mailer.SendEmailChangeNoticeEmail(user.Email, change.NewEmail, cancelURL); err != nil {
+			s.Logger.Error("failed to send email change notice", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// ConfirmEmailChange swaps the email once the new address is confirmed
+// and revokes every session of the user.
+func (s *Service) ConfirmEmailChange(userID uuid.UUID, req *ConfirmEmailChangeReq) error {
+	if len(req.Code) == 0 {
+		return ErrInvalidCode
+	}
+
+	if err := s.checkAccountAttempts(userID); err != nil {
+		return err
+	}
+
+	change, err := s.Storage.EmailChange.GetByUser(userID)
+	if err != nil {
+		if err == models.ErrEmailChangeNotFound {
+			return ErrEmailChangeNotFound
+		}
+
+		s.Logger.Error("failed to get email change", "err", err)
+		return err
+	}
+
+	if err := s.Hasher.Compare(change.CodeHash, []byte(req.Code)); err != nil {
+		if err != hashing.ErrMismatchedHash {
+			s.Logger.Error("failed to verify email change code", "err", err)
+			return err
+		}
+
+		user, err := s.Storage.User.GetByID(userID)
+		if err != nil {
+			s.Logger.Error("failed to get user by id", "err", err)
+			return err
+		}
+
+		s.recordFailedAttempt(user, req.Client.IP)
+		return ErrInvalidCode
+	}
+
+	if change.ExpiresAt.Before(time.Now()) {
+		return ErrCodeExpired
+	}
+
+	// the address could have been registered since the change was requested
+	if err := s.Storage.EmailChange.Apply(change.ID); err != nil {
+		switch err {
+		case models.ErrDuplicateEmail:
+			return ErrUserAlreadyExists
+		case models.ErrEmailChangeNotFound:
+			return ErrEmailChangeNotFound
+		}
+
+		s.Logger.Error("failed to apply email change", "err", err)
+		return err
+	}
+
+	s.Logger.Info("email changed", "user_id", userID)
+
+	if err := s.resetAttempts(userID); err != nil {
+		return err
+	}
+
+	return s.LogoutAll(userID, req.Client)
+}
+
+// CancelEmailChange drops the pending change, it is called from the link
+// sent to the current address.
+func (s *Service) CancelEmailChange(cancelToken string) error {
+	changeID, secret, err := parseSelectorToken(cancelToken)
+	if err != nil {
+		return ErrInvalidCancelToken
+	}
+
+	change, err := s.Storage.EmailChange.GetByID(changeID)
+	if err != nil {
+		if err == models.ErrEmailChangeNotFound {
+			return ErrInvalidCancelToken
+		}
+
+		s.Logger.Error("failed to get email change", "err", err)
+		return err
+	}
+
+	if !verifySelectorSecret(change.CancelHash, secret) {
+		return ErrInvalidCancelToken
+	}
+
+	if err := s.Storage.EmailChange.DeleteByID(change.ID); err != nil {
+		if err == models.ErrEmailChangeNotFound {
+			return ErrInvalidCancelToken
+		}
+
+		s.Logger.Error("failed to delete email change", "err", err)
+		return err
+	}
+
+	s.Logger.Info("email change cancelled", "user_id", change.UserID)
+	return nil
+}
+
+func validateChangeEmailReq(req *ChangeEmailReq) error {
+	_, err := mail.ParseAddress(req.NewEmail)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	if len(req.Password) == 0 {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
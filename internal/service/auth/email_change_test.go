@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+func pendingEmailChange(t *testing.T, s *Service, userID uuid.UUID) *models.EmailChange {
+	t.Helper()
+
+	change, err := s.Storage.EmailChange.GetByUser(userID)
+	if err != nil && err != models.ErrEmailChangeNotFound {
+		t.Fatal(err)
+	}
+
+	return change
+}
+
+func TestChangeEmailPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, s *Service, user *models.User)
+		password string
+		want     error
+	}{
+		{
+			name:     "missing password",
+			password: "",
+			want:     ErrInvalidPassword,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong password",
+			want:     ErrInvalidPassword,
+		},
+		{
+			name: "account without a password",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				s.Storage.User.UpdatePassword(user.ID, []byte{})
+			},
+			password: testPassword,
+			want:     ErrInvalidPassword,
+		},
+		{
+			name: "account locked",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				lock(t, s, accountAttemptKey(user.ID))
+			},
+			password: testPassword,
+			want:     ErrAccountLocked,
+		},
+		{
+			name:     "right password",
+			password: testPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			user := createUser(t, s, "user@example.com")
+
+			if tt.setup != nil {
+				tt.setup(t, s, user)
+			}
+
+			err := s.ChangeEmail(user.ID, &ChangeEmailReq{
+				NewEmail: "new@example.com",
+				Password: tt.password,
+				Client:   testClient,
+			})
+
+			if err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if change := pendingEmailChange(t, s, user.ID); (change != nil) != (tt.want == nil) {
+				t.Errorf("got pending change %v, want one only if the request was accepted", change)
+			}
+		})
+	}
+}
+
+func TestChangeEmailLocksAccount(t *testing.T) {
+	s, _ := newTestService(t)
+	s.Cfg.MaxAccountFailures = 3
+	user := createUser(t, s, "user@example.com")
+
+	req := &ChangeEmailReq{NewEmail: "new@example.com", Password: "wrong password", Client: testClient}
+
+	for range s.Cfg.MaxAccountFailures {
+		if err := s.ChangeEmail(user.ID, req); err != ErrInvalidPassword {
+			t.Fatalf("got %v, want %v", err, ErrInvalidPassword)
+		}
+	}
+
+	// the right password does not help once the account is locked
+	req.Password = testPassword
+
+	if err := s.ChangeEmail(user.ID, req); err != ErrAccountLocked {
+		t.Fatalf("got %v, want %v", err, ErrAccountLocked)
+	}
+}
+
+func TestChangeEmailCooldown(t *testing.T) {
+	s, _ := newTestService(t)
+	s.Cfg.EmailChangeTTL = time.Hour
+	s.Cfg.EmailChangeCooldown = time.Hour
+	user := createUser(t, s, "user@example.com")
+
+	req := &ChangeEmailReq{NewEmail: "new@example.com", Password: testPassword, Client: testClient}
+
+	if err := s.ChangeEmail(user.ID, req); err != nil {
+		t.Fatalf("change email: %v", err)
+	}
+
+	first := pendingEmailChange(t, s, user.ID)
+
+	req.NewEmail = "other@example.com"
+
+	if err := s.ChangeEmail(user.ID, req); err != ErrEmailChangeTooSoon {
+		t.Fatalf("within the cooldown: got %v, want %v", err, ErrEmailChangeTooSoon)
+	}
+
+	if change := pendingEmailChange(t, s, user.ID); change.ID != first.ID {
+		t.Error("the pending change was replaced within the cooldown")
+	}
+
+	s.Cfg.EmailChangeCooldown = 0
+
+	if err := s.ChangeEmail(user.ID, req); err != nil {
+		t.Fatalf("after the cooldown: %v", err)
+	}
+
+	if change := pendingEmailChange(t, s, user.ID); change.NewEmail != "other@example.com" {
+		t.Errorf("got pending change to %s, want it replaced", change.NewEmail)
+	}
+}
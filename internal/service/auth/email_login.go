@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type BeginEmailLoginReq struct {
+	Email string `json:"email"`
+}
+
+type FinishEmailLoginReq struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// BeginEmailLogin emails the user a one-time login code, replacing the
+// previous ones. Unknown and inactive accounts, as well as requests made
+// within the cooldown, are silently ignored, so the response does not
+// reveal whether the email is registered.
+func (s *Service) BeginEmailLogin(req *BeginEmailLoginReq) error {
+	if err := validateBeginEmailLoginReq(req); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return nil
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return err
+	}
+
+	if user.State != models.UserStateActive {
+		return nil
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeLogin)
+	if err != nil {
+		s.Logger.Error("failed to get login codes", "err", err)
+		return err
+	}
+
+	for _, code := range codes {
+		if code.CreatedAt.Add(s.Cfg.LoginCodeCooldown).After(time.Now()) {
+			return nil
+		}
+	}
+
+	if err := s.Storage.Code.DeleteAllByUser(user.ID, models.CodeScopeLogin); err != nil {
+		s.Logger.Error("failed to delete login codes", "err", err)
+		return err
+	}
+
+	code, err := generateCode(8)
+	if err != nil {
+		s.Logger.Error("failed to generate login code", "err", err)
+		return err
+	}
+
+	codeHash, err := s.Hasher.Hash([]byte(code))
+	if err != nil {
+		s.Logger.Error("failed to hash login code", "err", err)
+		return err
+	}
+
+	if err := s.Storage.Code.Insert(&models.Code{
+		UserID:    user.ID,
+		Hash:      codeHash,
+		Scope:     models.CodeScopeLogin,
+		ExpiresAt: time.Now().Add(s.Cfg.LoginCodeTTL),
+	}); err != nil {
+		s.Logger.Error("failed to save login code", "err", err)
+		return err
+	}
+
+	go func() {
+		if err := s.Mailer.SendLoginCodeEmail(user.Email, code, s.Cfg.LoginCodeTTL); err != nil {
+			s.Logger.Error("failed to send login code email", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// FinishEmailLogin exchanges the login code for the token pair. The code
+// stands in for the password, so the second factor is still asked for.
+func (s *Service) FinishEmailLogin(req *FinishEmailLoginReq) (LoginResp, error) {
+	if err := validateFinishEmailLoginReq(req); err != nil {
+		return LoginResp{}, err
+	}
+
+	if err := s.checkIPAttempts(req.Client.IP); err != nil {
+		return LoginResp{}, err
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			s.recordFailedAttempt(nil, req.Client.IP)
+			s.recordAuthEvent(models.AuthEventLogin, uuid.Nil, req.Client, ErrInvalidCode)
+			return LoginResp{}, ErrInvalidCode
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return LoginResp{}, err
+	}
+
+	if user.State != models.UserStateActive {
+		return LoginResp{}, ErrInvalidCode
+	}
+
+	if err := s.checkAccountAttempts(user.ID); err != nil {
+		return LoginResp{}, err
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeLogin)
+	if err != nil {
+		s.Logger.Error("failed to get login codes", "err", err)
+		return LoginResp{}, err
+	}
+
+	for _, code := range codes {
+		if err := s.Hasher.Compare(code.Hash, []byte(req.Code)); err != nil {
+			if err != hashing.ErrMismatchedHash {
+				s.Logger.Error("failed to verify login code", "err", err)
+				return LoginResp{}, err
+			}
+
+			continue
+		}
+
+		if code.ExpiresAt.Before(time.Now()) {
+			return LoginResp{}, ErrCodeExpired
+		}
+
+		// a concurrent request may have used the code already
+		if err := s.Storage.Code.Consume(code.ID); err != nil {
+			if err == models.ErrCodeNotFound {
+				return LoginResp{}, ErrInvalidCode
+			}
+
+			s.Logger.Error("failed to consume login code", "err", err)
+			return LoginResp{}, err
+		}
+
+		if err := s.resetAttempts(user.ID); err != nil {
+			return LoginResp{}, err
+		}
+
+		return s.completeLogin(user.ID, req.Client)
+	}
+
+	s.recordFailedAttempt(user, req.Client.IP)
+	s.recordAuthEvent(models.AuthEventLogin, user.ID, req.Client, ErrInvalidCode)
+	return LoginResp{}, ErrInvalidCode
+}
+
+func validateBeginEmailLoginReq(req *BeginEmailLoginReq) error {
+	_, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	return nil
+}
+
+func validateFinishEmailLoginReq(req *FinishEmailLoginReq) error {
+	_, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	if len(req.Code) == 0 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
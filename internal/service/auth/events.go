@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+const (
+	defaultEventsLimit = 50
+	maxEventsLimit     = 200
+)
+
+// SecurityEvent is an entry of the audit log of authentication events.
+type SecurityEvent struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	Type      string     `json:"type"`
+	Outcome   string     `json:"outcome"`
+	Detail    string     `json:"detail,omitempty"`
+	UserAgent string     `json:"user_agent"`
+	IP        string     `json:"ip"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SecurityEventsReq filters the audit log, zero fields match any event.
+type SecurityEventsReq struct {
+	UserID  uuid.NullUUID
+	Type    string
+	Outcome string
+	IP      string
+	Since   time.Time
+	Until   time.Time
+
+	// the next_cursor of the previous page
+	Before uuid.NullUUID
+	Limit  int
+}
+
+type SecurityEventsResp struct {
+	Events []SecurityEvent `json:"events"`
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var authEventTypes = []models.AuthEventType{
+	models.AuthEventRegister,
+	models.AuthEventConfirm,
+	models.AuthEventLogin,
+	models.AuthEventRefresh,
+	models.AuthEventRefreshReuse,
+	models.AuthEventLogout,
+	models.AuthEventLogoutAll,
+	models.AuthEventPasswordChange,
+	models.AuthEventPasswordReset,
+	models.AuthEventSuspend,
+	models.AuthEventUnsuspend,
+}
+
+// recordAuthEvent appends an event to the audit log, a failed one if
+// failure is set. userID is uuid.Nil when the attempt did not match an
+// account. Like recordFailedAttempt it is best effort: errors are logged,
+// the request is not failed because of them.
+func (s *Service) recordAuthEvent(eventType models.AuthEventType, userID uuid.UUID, client ClientInfo, failure error) {
+	event := &models.AuthEvent{
+		UserID:    uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil},
+		Type:      eventType,
+		Outcome:   models.AuthEventSuccess,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	}
+
+	if failure != nil {
+		event.Outcome = models.AuthEventFailure
+		event.Detail = failure.Error()
+	}
+
+	if err := s.Storage.AuthEvent.Insert(event); err != nil {
+		s.Logger.Error("failed to record auth event", "err", err, "type", eventType)
+	}
+}
+
+// recordAdminEvent appends an action an administrator took on the user's
+// account to the audit log, best effort like recordAuthEvent.
+func (s *Service) recordAdminEvent(eventType models.AuthEventType, actorID uuid.UUID, userID uuid.UUID, client ClientInfo, detail string) {
+	event := &models.AuthEvent{
+		UserID:    uuid.NullUUID{UUID: userID, Valid: true},
+		ActorID:   uuid.NullUUID{UUID: actorID, Valid: true},
+		Type:      eventType,
+		Outcome:   models.AuthEventSuccess,
+		Detail:    detail,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	}
+
+	if err := s.Storage.AuthEvent.Insert(event); err != nil {
+		s.Logger.Error("failed to record auth event", "err", err, "type", eventType)
+	}
+}
+
+// SecurityEvents returns the history of the user's own account.
+func (s *Service) SecurityEvents(userID uuid.UUID, req *SecurityEventsReq) (SecurityEventsResp, error) {
+	req.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+
+	return s.QuerySecurityEvents(req)
+}
+
+// QuerySecurityEvents searches the audit log of every account, newest
+// events first.
+func (s *Service) QuerySecurityEvents(req *SecurityEventsReq) (SecurityEventsResp, error) {
+	if err := validateSecurityEventsReq(req); err != nil {
+		return SecurityEventsResp{}, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultEventsLimit
+	}
+
+	events, err := s.Storage.AuthEvent.GetAll(&models.AuthEventFilter{
+		UserID:  req.UserID,
+		Type:    models.AuthEventType(req.Type),
+		Outcome: models.AuthEventOutcome(req.Outcome),
+		IP:      req.IP,
+		Since:   req.Since,
+		Until:   req.Until,
+		Before:  req.Before,
+		Limit:   limit,
+	})
+	if err != nil {
+		s.Logger.Error("failed to get auth events", "err", err)
+		return SecurityEventsResp{}, err
+	}
+
+	resp := SecurityEventsResp{
+		Events: make([]SecurityEvent, 0, len(events)),
+	}
+
+	for _, event := range events {
+		result := SecurityEvent{
+			ID:        event.ID,
+			Type:      string(event.Type),
+			Outcome:   string(event.Outcome),
+			Detail:    event.Detail,
+			UserAgent: event.UserAgent,
+			IP:        event.IP,
+			CreatedAt: event.CreatedAt,
+		}
+
+		if event.UserID.Valid {
+			result.UserID = &event.UserID.UUID
+		}
+
+		if event.ActorID.Valid {
+			result.ActorID = &event.ActorID.UUID
+		}
+
+		resp.Events = append(resp.Events, result)
+	}
+
+	if len(events) == limit {
+		resp.NextCursor = events[len(events)-1].ID.String()
+	}
+
+	return resp, nil
+}
+
+func validateSecurityEventsReq(req *SecurityEventsReq) error {
+	if req.Limit < 0 || req.Limit > maxEventsLimit {
+		return ErrInvalidEventFilter
+	}
+
+	if req.Type != "" && !slices.Contains(authEventTypes, models.AuthEventType(req.Type)) {
+		return ErrInvalidEventFilter
+	}
+
+	switch models.AuthEventOutcome(req.Outcome) {
+	case "", models.AuthEventSuccess, models.AuthEventFailure:
+	default:
+		return ErrInvalidEventFilter
+	}
+
+	if !req.Since.IsZero() && !req.Until.IsZero() && !req.Since.Before(req.Until) {
+		return ErrInvalidEventFilter
+	}
+
+	return nil
+}
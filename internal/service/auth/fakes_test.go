@@ -0,0 +1,1182 @@
+package auth
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/memory"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// fakeDB is an in-memory stand-in for postgres. Rows are copied in and out,
+// like they would be by the driver, and deleting a user cascades.
+type fakeDB struct {
+	mu sync.Mutex
+
+	users    map[uuid.UUID]*models.User
+	codes    map[uuid.UUID]*models.Code
+	tokens   []*models.Token
+	totps    map[uuid.UUID]*models.TOTP
+	passkeys []*models.Passkey
+	idents   []*models.Identity
+	states   map[uuid.UUID]*models.OIDCState
+	clients  map[uuid.UUID]*models.OAuthClient
+	changes  map[uuid.UUID]*models.EmailChange
+	revoked  map[string]*models.RevokedToken
+	events   []*models.AuthEvent
+	signIns  map[uuid.UUID]*models.SignIn
+	userRole map[uuid.UUID][]string
+	rolePerm map[string][]string
+	avatars  []string
+
+	// clock of created_at, strictly increasing so that the order is stable
+	now time.Time
+}
+
+func newFakeStorage() (*storage.Storage, *fakeDB) {
+	db := &fakeDB{
+		users:    make(map[uuid.UUID]*models.User),
+		codes:    make(map[uuid.UUID]*models.Code),
+		totps:    make(map[uuid.UUID]*models.TOTP),
+		states:   make(map[uuid.UUID]*models.OIDCState),
+		clients:  make(map[uuid.UUID]*models.OAuthClient),
+		changes:  make(map[uuid.UUID]*models.EmailChange),
+		revoked:  make(map[string]*models.RevokedToken),
+		signIns:  make(map[uuid.UUID]*models.SignIn),
+		userRole: make(map[uuid.UUID][]string),
+		// the grants of the migrations
+		rolePerm: map[string][]string{
+			models.RoleMember:   {},
+			models.RoleTrainer:  {"members:read"},
+			models.RoleGymAdmin: {"members:read", "roles:read", "roles:manage", "oauth_clients:manage", "users:suspend"},
+			models.RoleSuperadmin: {
+				"members:read", "roles:read", "roles:manage", "oauth_clients:manage",
+				"users:manage", "audit:read", "users:suspend",
+			},
+		},
+	}
+
+	return &storage.Storage{
+		User:         &fakeUsers{db},
+		Code:         &fakeCodes{db},
+		Token:        &fakeTokens{db},
+		TOTP:         &fakeTOTPs{db},
+		Passkey:      &fakePasskeys{db},
+		Identity:     &fakeIdentities{db},
+		OIDCState:    &fakeOIDCStates{db},
+		OAuthClient:  &fakeOAuthClients{db},
+		EmailChange:  &fakeEmailChanges{db},
+		Role:         &fakeRoles{db},
+		Attempt:      memory.NewAttemptStorage(),
+		RevokedToken: &fakeRevokedTokens{db},
+		AuthEvent:    &fakeAuthEvents{db},
+		SignIn:       &fakeSignIns{db},
+		Avatar:       &fakeAvatars{db},
+	}, db
+}
+
+func (db *fakeDB) tick() time.Time {
+	now := time.Now()
+	if !now.After(db.now) {
+		now = db.now.Add(time.Microsecond)
+	}
+
+	db.now = now
+	return now
+}
+
+type fakeUsers struct{ db *fakeDB }
+
+func (s *fakeUsers) Insert(user *models.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, u := range s.db.users {
+		if u.Email == user.Email {
+			return models.ErrDuplicateEmail
+		}
+	}
+
+	user.ID = uuid.New()
+	user.SignInAlerts = true
+	user.CreatedAt = s.db.tick()
+	user.UpdatedAt = user.CreatedAt
+	if user.State == "" {
+		user.State = models.UserStatePending
+	}
+
+	row := *user
+	s.db.users[user.ID] = &row
+	return nil
+}
+
+func (s *fakeUsers) GetByID(id uuid.UUID) (*models.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[id]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	result := *user
+	return &result, nil
+}
+
+func (s *fakeUsers) GetByEmail(email string) (*models.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, user := range s.db.users {
+		if user.Email == email {
+			result := *user
+			return &result, nil
+		}
+	}
+
+	return nil, models.ErrUserNotFound
+}
+
+// update runs fn on the user if it is in one of the states (any if none).
+func (s *fakeUsers) update(id uuid.UUID, states []models.UserState, fn func(*models.User)) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[id]
+	if !ok || (len(states) > 0 && !slices.Contains(states, user.State)) {
+		return models.ErrUserNotFound
+	}
+
+	fn(user)
+	return nil
+}
+
+func (s *fakeUsers) UpdateStatus(id uuid.UUID, state models.UserState) error {
+	return s.update(id, nil, func(u *models.User) { u.State = state })
+}
+
+func (s *fakeUsers) UpdatePassword(id uuid.UUID, passwordHash []byte) error {
+	return s.update(id, nil, func(u *models.User) { u.PasswordHash = passwordHash })
+}
+
+func (s *fakeUsers) ResetPassword(id uuid.UUID, codeID uuid.UUID, passwordHash []byte) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	code, ok := s.db.codes[codeID]
+	if !ok || code.UserID != id || code.Scope != models.CodeScopeReset || !code.ExpiresAt.After(time.Now()) {
+		return models.ErrCodeNotFound
+	}
+
+	delete(s.db.codes, codeID)
+	s.db.users[id].PasswordHash = passwordHash
+	s.db.deleteTokens(func(t *models.Token) bool { return t.UserID == id })
+	return nil
+}
+
+func (s *fakeUsers) UpdateSignInAlerts(id uuid.UUID, enabled bool) error {
+	return s.update(id, nil, func(u *models.User) { u.SignInAlerts = enabled })
+}
+
+func (s *fakeUsers) Suspend(id uuid.UUID, reason string, suspendedBy uuid.UUID, until *time.Time) error {
+	return s.update(id, []models.UserState{models.UserStateActive, models.UserStateSuspended}, func(u *models.User) {
+		u.State = models.UserStateSuspended
+		u.SuspensionReason = reason
+		u.SuspendedBy = uuid.NullUUID{UUID: suspendedBy, Valid: true}
+		u.SuspendedUntil = until
+	})
+}
+
+func (s *fakeUsers) Unsuspend(id uuid.UUID) error {
+	return s.update(id, []models.UserState{models.UserStateSuspended}, func(u *models.User) {
+		u.State = models.UserStateActive
+		u.SuspensionReason = ""
+		u.SuspendedBy = uuid.NullUUID{}
+		u.SuspendedUntil = nil
+	})
+}
+
+func (s *fakeUsers) UnsuspendAllExpired() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, u := range s.db.users {
+		if u.State == models.UserStateSuspended && u.SuspendedUntil != nil && !u.SuspendedUntil.After(time.Now()) {
+			u.State = models.UserStateActive
+			u.SuspensionReason = ""
+			u.SuspendedBy = uuid.NullUUID{}
+			u.SuspendedUntil = nil
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeUsers) MarkDeleted(id uuid.UUID) error {
+	return s.update(id, []models.UserState{models.UserStateActive}, func(u *models.User) {
+		now := time.Now()
+		u.State = models.UserStateDeleted
+		u.DeletedAt = &now
+	})
+}
+
+func (s *fakeUsers) Restore(id uuid.UUID) error {
+	return s.update(id, []models.UserState{models.UserStateDeleted}, func(u *models.User) {
+		u.State = models.UserStateActive
+		u.DeletedAt = nil
+	})
+}
+
+func (s *fakeUsers) GetAllDeletedBefore(before time.Time) ([]*models.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var users []*models.User
+	for _, u := range s.db.users {
+		if u.State == models.UserStateDeleted && u.DeletedAt != nil && !u.DeletedAt.After(before) {
+			result := *u
+			users = append(users, &result)
+		}
+	}
+
+	return users, nil
+}
+
+func (s *fakeUsers) DeleteByID(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[id]; !ok {
+		return models.ErrUserNotFound
+	}
+
+	s.db.deleteUser(id)
+	return nil
+}
+
+func (s *fakeUsers) Purge(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[id]
+	if !ok || user.State != models.UserStateDeleted {
+		return models.ErrUserNotFound
+	}
+
+	s.db.deleteUser(id)
+
+	for _, event := range s.db.events {
+		if event.UserID.Valid && event.UserID.UUID == id {
+			event.UserAgent, event.IP = "", ""
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeUsers) DeleteByEmail(email string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, u := range s.db.users {
+		if u.Email == email {
+			s.db.deleteUser(id)
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeUsers) DeleteByEmailIfPending(email string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, u := range s.db.users {
+		if u.Email == email && u.State == models.UserStatePending {
+			s.db.deleteUser(id)
+		}
+	}
+
+	return nil
+}
+
+// deleteUser removes the user and what references it, the caller holds mu.
+func (db *fakeDB) deleteUser(id uuid.UUID) {
+	delete(db.users, id)
+	delete(db.totps, id)
+	delete(db.userRole, id)
+	delete(db.changes, id)
+	db.passkeys = slices.DeleteFunc(db.passkeys, func(p *models.Passkey) bool { return p.UserID == id })
+	db.idents = slices.DeleteFunc(db.idents, func(i *models.Identity) bool { return i.UserID == id })
+
+	for clientID, client := range db.clients {
+		if client.OwnerID == id {
+			delete(db.clients, clientID)
+		}
+	}
+
+	for codeID, code := range db.codes {
+		if code.UserID == id {
+			delete(db.codes, codeID)
+		}
+	}
+
+	for signInID, signIn := range db.signIns {
+		if signIn.UserID == id {
+			delete(db.signIns, signInID)
+		}
+	}
+
+	db.deleteTokens(func(t *models.Token) bool { return t.UserID == id })
+}
+
+type fakeCodes struct{ db *fakeDB }
+
+func (s *fakeCodes) Insert(code *models.Code) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	code.ID = uuid.New()
+	code.CreatedAt = s.db.tick()
+
+	row := *code
+	s.db.codes[code.ID] = &row
+	return nil
+}
+
+func (s *fakeCodes) GetByID(id uuid.UUID) (*models.Code, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	code, ok := s.db.codes[id]
+	if !ok {
+		return nil, models.ErrCodeNotFound
+	}
+
+	result := *code
+	return &result, nil
+}
+
+func (s *fakeCodes) GetAllByUser(userID uuid.UUID, scope models.CodeScope) ([]*models.Code, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var codes []*models.Code
+	for _, code := range s.db.codes {
+		if code.UserID == userID && code.Scope == scope {
+			result := *code
+			codes = append(codes, &result)
+		}
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].CreatedAt.Before(codes[j].CreatedAt)
+	})
+
+	return codes, nil
+}
+
+func (s *fakeCodes) DeleteByID(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.codes, id)
+	return nil
+}
+
+func (s *fakeCodes) Consume(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.codes[id]; !ok {
+		return models.ErrCodeNotFound
+	}
+
+	delete(s.db.codes, id)
+	return nil
+}
+
+func (s *fakeCodes) DeleteAllByUser(userID uuid.UUID, scope models.CodeScope) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, code := range s.db.codes {
+		if code.UserID == userID && code.Scope == scope {
+			delete(s.db.codes, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeCodes) DeleteAllExpired() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, code := range s.db.codes {
+		if code.ExpiresAt.Before(time.Now()) {
+			delete(s.db.codes, id)
+		}
+	}
+
+	return nil
+}
+
+type fakeTokens struct{ db *fakeDB }
+
+func (s *fakeTokens) Insert(token *models.Token) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	token.ID = uuid.New()
+	token.CreatedAt = s.db.tick()
+
+	row := *token
+	s.db.tokens = append(s.db.tokens, &row)
+	return nil
+}
+
+func (s *fakeTokens) InsertChild(parentID uuid.UUID, token *models.Token) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	i := slices.IndexFunc(s.db.tokens, func(t *models.Token) bool { return t.ID == parentID })
+	if i < 0 || s.db.tokens[i].Status != models.TokenStatusActive {
+		return models.ErrTokenNotFound
+	}
+
+	s.db.tokens[i].Status = models.TokenStatusUsed
+
+	token.ID = uuid.New()
+	token.Branch = s.db.tokens[i].Branch
+	token.CreatedAt = s.db.tick()
+
+	row := *token
+	s.db.tokens = append(s.db.tokens, &row)
+	return nil
+}
+
+func (s *fakeTokens) GetByID(id uuid.UUID) (*models.Token, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, token := range s.db.tokens {
+		if token.ID == id {
+			result := *token
+			return &result, nil
+		}
+	}
+
+	return nil, models.ErrTokenNotFound
+}
+
+func (s *fakeTokens) GetSessions(userID uuid.UUID) ([]*models.Session, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var sessions []*models.Session
+	for _, token := range s.db.tokens {
+		if token.UserID != userID || token.Scope != models.TokenScopeRefresh ||
+			token.Status != models.TokenStatusActive || !token.ExpiresAt.After(time.Now()) {
+			continue
+		}
+
+		session := &models.Session{
+			Branch:     token.Branch,
+			UserAgent:  token.UserAgent,
+			IP:         token.IP,
+			ClientID:   token.ClientID,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.CreatedAt,
+		}
+
+		for _, first := range s.db.tokens {
+			if first.Branch == token.Branch && first.CreatedAt.Before(session.CreatedAt) {
+				session.CreatedAt = first.CreatedAt
+			}
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (s *fakeTokens) GetAllByUserScope(userID uuid.UUID, scope models.TokenScope) ([]*models.Token, error) {
+	return s.getAll(func(t *models.Token) bool { return t.UserID == userID && t.Scope == scope })
+}
+
+func (s *fakeTokens) getAll(match func(*models.Token) bool) ([]*models.Token, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var tokens []*models.Token
+	for _, token := range s.db.tokens {
+		if match(token) {
+			result := *token
+			tokens = append(tokens, &result)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (s *fakeTokens) DeleteAllByUser(userID uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.deleteTokens(func(t *models.Token) bool { return t.UserID == userID })
+	return nil
+}
+
+func (s *fakeTokens) DeleteAllByBranch(userID uuid.UUID, branch uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.deleteTokens(func(t *models.Token) bool { return t.UserID == userID && t.Branch == branch })
+	return nil
+}
+
+func (s *fakeTokens) DeleteAllByUserExceptBranch(userID uuid.UUID, branch uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.deleteTokens(func(t *models.Token) bool { return t.UserID == userID && t.Branch != branch })
+	return nil
+}
+
+// deleteTokens drops the matching tokens, the caller holds mu.
+func (db *fakeDB) deleteTokens(match func(*models.Token) bool) {
+	db.tokens = slices.DeleteFunc(db.tokens, match)
+}
+
+type fakeTOTPs struct{ db *fakeDB }
+
+func (s *fakeTOTPs) Upsert(totp *models.TOTP) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row := *totp
+	row.CreatedAt = s.db.tick()
+	s.db.totps[totp.UserID] = &row
+	return nil
+}
+
+func (s *fakeTOTPs) GetByUser(userID uuid.UUID) (*models.TOTP, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	totp, ok := s.db.totps[userID]
+	if !ok {
+		return nil, models.ErrTOTPNotFound
+	}
+
+	result := *totp
+	return &result, nil
+}
+
+func (s *fakeTOTPs) Enable(userID uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	totp, ok := s.db.totps[userID]
+	if !ok {
+		return models.ErrTOTPNotFound
+	}
+
+	totp.Enabled = true
+	return nil
+}
+
+func (s *fakeTOTPs) UpdateLastUsedStep(userID uuid.UUID, step int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	totp, ok := s.db.totps[userID]
+	if !ok {
+		return models.ErrTOTPNotFound
+	}
+
+	if step <= totp.LastUsedStep {
+		return models.ErrTOTPStepUsed
+	}
+
+	totp.LastUsedStep = step
+	return nil
+}
+
+func (s *fakeTOTPs) Delete(userID uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.totps, userID)
+	return nil
+}
+
+type fakePasskeys struct{ db *fakeDB }
+
+func (s *fakePasskeys) Insert(passkey *models.Passkey) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, p := range s.db.passkeys {
+		if bytes.Equal(p.ID, passkey.ID) {
+			return models.ErrDuplicatePasskey
+		}
+	}
+
+	passkey.CreatedAt = s.db.tick()
+	passkey.LastUsedAt = passkey.CreatedAt
+
+	row := *passkey
+	s.db.passkeys = append(s.db.passkeys, &row)
+	return nil
+}
+
+func (s *fakePasskeys) GetByID(id []byte) (*models.Passkey, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, p := range s.db.passkeys {
+		if bytes.Equal(p.ID, id) {
+			result := *p
+			return &result, nil
+		}
+	}
+
+	return nil, models.ErrPasskeyNotFound
+}
+
+func (s *fakePasskeys) GetAllByUser(userID uuid.UUID) ([]*models.Passkey, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var passkeys []*models.Passkey
+	for _, p := range s.db.passkeys {
+		if p.UserID == userID {
+			result := *p
+			passkeys = append(passkeys, &result)
+		}
+	}
+
+	return passkeys, nil
+}
+
+func (s *fakePasskeys) UpdateSignCount(id []byte, signCount uint32) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, p := range s.db.passkeys {
+		if bytes.Equal(p.ID, id) {
+			p.SignCount = signCount
+			p.LastUsedAt = s.db.tick()
+			return nil
+		}
+	}
+
+	return models.ErrPasskeyNotFound
+}
+
+func (s *fakePasskeys) Delete(userID uuid.UUID, id []byte) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	i := slices.IndexFunc(s.db.passkeys, func(p *models.Passkey) bool {
+		return p.UserID == userID && bytes.Equal(p.ID, id)
+	})
+
+	if i < 0 {
+		return models.ErrPasskeyNotFound
+	}
+
+	s.db.passkeys = slices.Delete(s.db.passkeys, i, i+1)
+	return nil
+}
+
+type fakeIdentities struct{ db *fakeDB }
+
+func (s *fakeIdentities) Insert(identity *models.Identity) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	identity.CreatedAt = s.db.tick()
+
+	row := *identity
+	s.db.idents = append(s.db.idents, &row)
+	return nil
+}
+
+func (s *fakeIdentities) Get(provider string, subject string) (*models.Identity, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, identity := range s.db.idents {
+		if identity.Provider == provider && identity.Subject == subject {
+			result := *identity
+			return &result, nil
+		}
+	}
+
+	return nil, models.ErrIdentityNotFound
+}
+
+type fakeOIDCStates struct{ db *fakeDB }
+
+func (s *fakeOIDCStates) Insert(state *models.OIDCState) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	state.ID = uuid.New()
+	state.CreatedAt = s.db.tick()
+
+	row := *state
+	s.db.states[state.ID] = &row
+	return nil
+}
+
+func (s *fakeOIDCStates) GetByID(id uuid.UUID) (*models.OIDCState, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	state, ok := s.db.states[id]
+	if !ok {
+		return nil, models.ErrOIDCStateNotFound
+	}
+
+	result := *state
+	return &result, nil
+}
+
+func (s *fakeOIDCStates) DeleteByID(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.states[id]; !ok {
+		return models.ErrOIDCStateNotFound
+	}
+
+	delete(s.db.states, id)
+	return nil
+}
+
+type fakeRoles struct{ db *fakeDB }
+
+func (s *fakeRoles) GetAll() ([]*models.Role, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var roles []*models.Role
+	for name, permissions := range s.db.rolePerm {
+		roles = append(roles, &models.Role{Name: name, Permissions: permissions})
+	}
+
+	return roles, nil
+}
+
+func (s *fakeRoles) GetPermissionsByRole(role string) ([]string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	permissions, ok := s.db.rolePerm[role]
+	if !ok {
+		return nil, models.ErrRoleNotFound
+	}
+
+	return permissions, nil
+}
+
+func (s *fakeRoles) GetByUser(userID uuid.UUID) ([]string, []string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	roles := slices.Clone(s.db.userRole[userID])
+
+	var permissions []string
+	for _, role := range roles {
+		for _, permission := range s.db.rolePerm[role] {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	return roles, permissions, nil
+}
+
+func (s *fakeRoles) CountUsers(role string) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	count := 0
+	for _, roles := range s.db.userRole {
+		if slices.Contains(roles, role) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *fakeRoles) Grant(userID uuid.UUID, role string, grantedBy uuid.NullUUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.rolePerm[role]; !ok {
+		return models.ErrRoleNotFound
+	}
+
+	if !slices.Contains(s.db.userRole[userID], role) {
+		s.db.userRole[userID] = append(s.db.userRole[userID], role)
+	}
+
+	return nil
+}
+
+func (s *fakeRoles) Revoke(userID uuid.UUID, role string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	i := slices.Index(s.db.userRole[userID], role)
+	if i < 0 {
+		return models.ErrUserRoleNotFound
+	}
+
+	s.db.userRole[userID] = slices.Delete(s.db.userRole[userID], i, i+1)
+	return nil
+}
+
+type fakeRevokedTokens struct{ db *fakeDB }
+
+func (s *fakeRevokedTokens) Upsert(token *models.RevokedToken) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row := *token
+	if old, ok := s.db.revoked[token.Key]; ok {
+		if old.RevokedAt.After(row.RevokedAt) {
+			row.RevokedAt = old.RevokedAt
+		}
+
+		if old.ExpiresAt.After(row.ExpiresAt) {
+			row.ExpiresAt = old.ExpiresAt
+		}
+	}
+
+	s.db.revoked[token.Key] = &row
+	return nil
+}
+
+func (s *fakeRevokedTokens) Get(key string) (*models.RevokedToken, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	token, ok := s.db.revoked[key]
+	if !ok || !token.ExpiresAt.After(time.Now()) {
+		return nil, models.ErrRevokedTokenNotFound
+	}
+
+	result := *token
+	return &result, nil
+}
+
+func (s *fakeRevokedTokens) GetAll() ([]*models.RevokedToken, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var tokens []*models.RevokedToken
+	for _, token := range s.db.revoked {
+		if token.ExpiresAt.After(time.Now()) {
+			result := *token
+			tokens = append(tokens, &result)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (s *fakeRevokedTokens) DeleteAllExpired() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for key, token := range s.db.revoked {
+		if !token.ExpiresAt.After(time.Now()) {
+			delete(s.db.revoked, key)
+		}
+	}
+
+	return nil
+}
+
+type fakeAuthEvents struct{ db *fakeDB }
+
+func (s *fakeAuthEvents) Insert(event *models.AuthEvent) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	event.ID = uuid.New()
+	event.CreatedAt = s.db.tick()
+
+	row := *event
+	s.db.events = append(s.db.events, &row)
+	return nil
+}
+
+func (s *fakeAuthEvents) GetAll(filter *models.AuthEventFilter) ([]*models.AuthEvent, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var events []*models.AuthEvent
+	for i := len(s.db.events) - 1; i >= 0; i-- {
+		event := s.db.events[i]
+
+		if filter.UserID.Valid && event.UserID != filter.UserID {
+			continue
+		}
+
+		if filter.Type != "" && event.Type != filter.Type {
+			continue
+		}
+
+		if filter.Outcome != "" && event.Outcome != filter.Outcome {
+			continue
+		}
+
+		result := *event
+		events = append(events, &result)
+	}
+
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+
+	return events, nil
+}
+
+func (s *fakeAuthEvents) GetDeviceHistory(userID uuid.UUID, userAgent string, ip string) (*models.DeviceHistory, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	history := &models.DeviceHistory{}
+	for _, event := range s.db.events {
+		if event.UserID != (uuid.NullUUID{UUID: userID, Valid: true}) || event.Type != models.AuthEventLogin || event.Outcome != models.AuthEventSuccess {
+			continue
+		}
+
+		history.HasLogins = true
+		history.UserAgentSeen = history.UserAgentSeen || event.UserAgent == userAgent
+		history.IPSeen = history.IPSeen || event.IP == ip
+	}
+
+	return history, nil
+}
+
+type fakeSignIns struct{ db *fakeDB }
+
+func (s *fakeSignIns) Insert(signIn *models.SignIn) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	signIn.ID = uuid.New()
+	signIn.CreatedAt = s.db.tick()
+
+	row := *signIn
+	s.db.signIns[signIn.ID] = &row
+	return nil
+}
+
+func (s *fakeSignIns) GetByID(id uuid.UUID) (*models.SignIn, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	signIn, ok := s.db.signIns[id]
+	if !ok {
+		return nil, models.ErrSignInNotFound
+	}
+
+	result := *signIn
+	return &result, nil
+}
+
+func (s *fakeSignIns) DeleteByID(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.signIns[id]; !ok {
+		return models.ErrSignInNotFound
+	}
+
+	delete(s.db.signIns, id)
+	return nil
+}
+
+func (s *fakeSignIns) DeleteAllExpired() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, signIn := range s.db.signIns {
+		if !signIn.ExpiresAt.After(time.Now()) {
+			delete(s.db.signIns, id)
+		}
+	}
+
+	return nil
+}
+
+type fakeOAuthClients struct{ db *fakeDB }
+
+func (s *fakeOAuthClients) Insert(client *models.OAuthClient) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	client.ID = uuid.New()
+	client.CreatedAt = s.db.tick()
+
+	row := *client
+	s.db.clients[client.ID] = &row
+	return nil
+}
+
+func (s *fakeOAuthClients) GetByID(id uuid.UUID) (*models.OAuthClient, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	client, ok := s.db.clients[id]
+	if !ok {
+		return nil, models.ErrOAuthClientNotFound
+	}
+
+	row := *client
+	return &row, nil
+}
+
+func (s *fakeOAuthClients) GetAllByOwner(ownerID uuid.UUID) ([]*models.OAuthClient, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var clients []*models.OAuthClient
+	for _, client := range s.db.clients {
+		if client.OwnerID == ownerID {
+			row := *client
+			clients = append(clients, &row)
+		}
+	}
+
+	return clients, nil
+}
+
+func (s *fakeOAuthClients) Delete(ownerID uuid.UUID, id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	client, ok := s.db.clients[id]
+	if !ok || client.OwnerID != ownerID {
+		return models.ErrOAuthClientNotFound
+	}
+
+	delete(s.db.clients, id)
+	return nil
+}
+
+// fakeEmailChanges keeps the changes by user, a user has at most one.
+type fakeEmailChanges struct{ db *fakeDB }
+
+func (s *fakeEmailChanges) Upsert(change *models.EmailChange) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	change.ID = uuid.New()
+	change.CreatedAt = s.db.tick()
+
+	row := *change
+	s.db.changes[change.UserID] = &row
+	return nil
+}
+
+func (s *fakeEmailChanges) GetByID(id uuid.UUID) (*models.EmailChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, change := range s.db.changes {
+		if change.ID == id {
+			row := *change
+			return &row, nil
+		}
+	}
+
+	return nil, models.ErrEmailChangeNotFound
+}
+
+func (s *fakeEmailChanges) GetByUser(userID uuid.UUID) (*models.EmailChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	change, ok := s.db.changes[userID]
+	if !ok {
+		return nil, models.ErrEmailChangeNotFound
+	}
+
+	row := *change
+	return &row, nil
+}
+
+func (s *fakeEmailChanges) Apply(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for userID, change := range s.db.changes {
+		if change.ID != id {
+			continue
+		}
+
+		for _, user := range s.db.users {
+			if user.Email == change.NewEmail {
+				return models.ErrDuplicateEmail
+			}
+		}
+
+		delete(s.db.changes, userID)
+
+		if user, ok := s.db.users[userID]; ok {
+			user.Email = change.NewEmail
+			user.UpdatedAt = s.db.tick()
+		}
+
+		return nil
+	}
+
+	return models.ErrEmailChangeNotFound
+}
+
+func (s *fakeEmailChanges) DeleteByID(id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for userID, change := range s.db.changes {
+		if change.ID == id {
+			delete(s.db.changes, userID)
+			return nil
+		}
+	}
+
+	return models.ErrEmailChangeNotFound
+}
+
+type fakeAvatars struct{ db *fakeDB }
+
+func (s *fakeAvatars) Delete(id string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.avatars = append(s.db.avatars, id)
+	return nil
+}
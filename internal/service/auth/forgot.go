@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+// ForgotPassword replaces the reset codes of the user with a new one and
+// sends it. Unknown, pending and deleted accounts, as well as requests made
+// within the cooldown, are silently ignored, so the response does not
+// reveal whether the email is registered.
+func (s *Service) ForgotPassword(req *ForgotPasswordReq) error {
+	if err := validateForgotPasswordReq(req); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return nil
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return err
+	}
+
+	if user.State != models.UserStateActive {
+		return nil
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeReset)
+	if err != nil {
+		s.Logger.Error("failed to get reset codes", "err", err)
+		return err
+	}
+
+	for _, code := range codes {
+		if code.CreatedAt.Add(s.Cfg.ResetCodeCooldown).After(time.Now()) {
+			return nil
+		}
+	}
+
+	// hashing the code takes as long as a password, doing it in the
+	// background keeps registered emails from answering slower
+	go s.issueResetCode(user)
+
+	return nil
+}
+
+// issueResetCode replaces the reset codes of the user with a new one and
+// mails it. Nobody waits for the result, errors are only logged.
+func (s *Service) issueResetCode(user *models.User) {
+	if err := s.Storage.Code.DeleteAllByUser(user.ID, models.CodeScopeReset); err != nil {
+		s.Logger.Error("failed to delete reset codes", "err", err)
+		return
+	}
+
+	code, err := generateCode(8)
+	if err != nil {
+		s.Logger.Error("failed to generate reset code", "err", err)
+		return
+	}
+
+	codeHash, err := s.Hasher.Hash([]byte(code))
+	if err != nil {
+		s.Logger.Error("failed to hash reset code", "err", err)
+		return
+	}
+
+	if err := s.Storage.Code.Insert(&models.Code{
+		UserID:    user.ID,
+		Hash:      codeHash,
+		Scope:     models.CodeScopeReset,
+		ExpiresAt: time.Now().Add(s.Cfg.ResetCodeTTL),
+	}); err != nil {
+		s.Logger.Error("failed to save reset code", "err", err)
+		return
+	}
+
+	if err := s.Mailer.SendPasswordResetEmail(user.Email, code); err != nil {
+		s.Logger.Error("failed to send password reset email", "err", err)
+	}
+}
+
+func validateForgotPasswordReq(req *ForgotPasswordReq) error {
+	_, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+func resetCodes(t *testing.T, s *Service, userID uuid.UUID) []*models.Code {
+	t.Helper()
+
+	codes, err := s.Storage.Code.GetAllByUser(userID, models.CodeScopeReset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return codes
+}
+
+// waitForResetCode waits for ForgotPassword to issue a code other than the
+// previous one in the background.
+func waitForResetCode(t *testing.T, s *Service, userID uuid.UUID, previous *models.Code) *models.Code {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		codes := resetCodes(t, s, userID)
+		if len(codes) == 0 {
+			continue
+		}
+
+		if previous == nil || codes[len(codes)-1].ID != previous.ID {
+			return codes[len(codes)-1]
+		}
+	}
+
+	t.Fatal("no reset code issued")
+	return nil
+}
+
+func TestForgotPasswordCooldown(t *testing.T) {
+	s, _ := newTestService(t)
+	s.Cfg.ResetCodeCooldown = time.Hour
+	user := createUser(t, s, "user@example.com")
+
+	req := &ForgotPasswordReq{Email: "user@example.com"}
+
+	if err := s.ForgotPassword(req); err != nil {
+		t.Fatalf("forgot password: %v", err)
+	}
+
+	first := waitForResetCode(t, s, user.ID, nil)
+
+	// within the cooldown nothing is issued
+	if err := s.ForgotPassword(req); err != nil {
+		t.Fatalf("forgot password again: %v", err)
+	}
+
+	if codes := resetCodes(t, s, user.ID); len(codes) != 1 || codes[0].ID != first.ID {
+		t.Fatalf("got %d codes, want the first one kept", len(codes))
+	}
+
+	s.Cfg.ResetCodeCooldown = 0
+
+	if err := s.ForgotPassword(req); err != nil {
+		t.Fatalf("forgot password after the cooldown: %v", err)
+	}
+
+	waitForResetCode(t, s, user.ID, first)
+
+	if codes := resetCodes(t, s, user.ID); len(codes) != 1 {
+		t.Errorf("got %d codes, want the previous one replaced", len(codes))
+	}
+}
+
+func TestForgotPasswordIgnored(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		state models.UserState
+	}{
+		{
+			name:  "unknown email",
+			email: "nobody@example.com",
+			state: models.UserStateActive,
+		},
+		{
+			name:  "pending",
+			email: "user@example.com",
+			state: models.UserStatePending,
+		},
+		{
+			name:  "suspended",
+			email: "user@example.com",
+			state: models.UserStateSuspended,
+		},
+		{
+			name:  "deleted",
+			email: "user@example.com",
+			state: models.UserStateDeleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			user := createUser(t, s, "user@example.com")
+			s.Storage.User.UpdateStatus(user.ID, tt.state)
+
+			if err := s.ForgotPassword(&ForgotPasswordReq{Email: tt.email}); err != nil {
+				t.Fatalf("got %v, want the request silently ignored", err)
+			}
+
+			// ignored requests return before issuing anything
+			if codes := resetCodes(t, s, user.ID); len(codes) != 0 {
+				t.Errorf("got %d reset codes, want none", len(codes))
+			}
+		})
+	}
+}
+
+func TestForgotPasswordInvalidEmail(t *testing.T) {
+	s, _ := newTestService(t)
+
+	if err := s.ForgotPassword(&ForgotPasswordReq{Email: "user"}); err != ErrInvalidEmail {
+		t.Errorf("got %v, want %v", err, ErrInvalidEmail)
+	}
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+)
+
+// KeySet signs access tokens with a single active key and verifies them
+// against every key that is still trusted. Keys are identified by the kid
+// header, which is the RFC 7638 thumbprint of the public key.
+//
+// To rotate a key, publish the new public key as a verification key first,
+// then switch the signing key and keep the old public key as a verification
+// key for at least AccessTokenTTL.
+type KeySet struct {
+	method jwt.SigningMethod
+
+	// HS256
+	secret []byte
+
+	// RS256 and EdDSA
+	signingKey crypto.PrivateKey
+	signingKID string
+	keys       map[string]*verificationKey
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+	jwk    JWK
+}
+
+// JWK is a public key in the RFC 7517 format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeySet(cfg *config.AuthConfig) (*KeySet, error) {
+	switch cfg.JWTAlgorithm {
+	case jwt.SigningMethodHS256.Alg():
+		return &KeySet{
+			method: jwt.SigningMethodHS256,
+			secret: cfg.JWTKey,
+		}, nil
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg():
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.JWTAlgorithm)
+	}
+
+	if cfg.JWTSigningKeyFile == "" {
+		return nil, fmt.Errorf("signing key file is required for %s", cfg.JWTAlgorithm)
+	}
+
+	signingKey, err := readPrivateKey(cfg.JWTSigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{
+		signingKey: signingKey,
+		keys:       make(map[string]*verificationKey),
+	}
+
+	var publicKey crypto.PublicKey
+	switch key := signingKey.(type) {
+	case *rsa.PrivateKey:
+		ks.method = jwt.SigningMethodRS256
+		publicKey = key.Public()
+	case ed25519.PrivateKey:
+		ks.method = jwt.SigningMethodEdDSA
+		publicKey = key.Public()
+	}
+
+	if ks.method.Alg() != cfg.JWTAlgorithm {
+		return nil, fmt.Errorf("signing key does not match jwt algorithm %s", cfg.JWTAlgorithm)
+	}
+
+	if ks.signingKID, err = ks.addPublicKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	for _, file := range cfg.JWTVerificationKeyFiles {
+		publicKey, err := readPublicKey(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := ks.addPublicKey(publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+
+	if ks.secret != nil {
+		return token.SignedString(ks.secret)
+	}
+
+	token.Header["kid"] = ks.signingKID
+	return token.SignedString(ks.signingKey)
+}
+
+// Symmetric tells whether tokens are signed with the shared HS256 secret,
+// which nobody but us can verify them with.
+func (ks *KeySet) Symmetric() bool {
+	return ks.secret != nil
+}
+
+// Algorithm is the jwt algorithm tokens are signed with.
+func (ks *KeySet) Algorithm() string {
+	return ks.method.Alg()
+}
+
+// Keyfunc resolves the verification key of a token for jwt.Parse.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks.secret != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidAccessToken
+		}
+		return ks.secret, nil
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok || key.method.Alg() != token.Method.Alg() {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return key.key, nil
+}
+
+// JWKS returns the public verification keys. It is empty for HS256.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+
+	// the signing key goes first, so clients that pick the first key
+	// verify fresh tokens
+	if key, ok := ks.keys[ks.signingKID]; ok {
+		jwks.Keys = append(jwks.Keys, key.jwk)
+	}
+
+	for kid, key := range ks.keys {
+		if kid != ks.signingKID {
+			jwks.Keys = append(jwks.Keys, key.jwk)
+		}
+	}
+
+	return jwks
+}
+
+func (ks *KeySet) addPublicKey(publicKey crypto.PublicKey) (string, error) {
+	var key verificationKey
+
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = verificationKey{
+			method: jwt.SigningMethodRS256,
+			key:    publicKey,
+			jwk: JWK{
+				KeyType: "RSA",
+				N:       base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			},
+		}
+	case ed25519.PublicKey:
+		key = verificationKey{
+			method: jwt.SigningMethodEdDSA,
+			key:    publicKey,
+			jwk: JWK{
+				KeyType: "OKP",
+				Curve:   "Ed25519",
+				X:       base64.RawURLEncoding.EncodeToString(publicKey),
+			},
+		}
+	default:
+		return "", errors.New("unsupported public key type")
+	}
+
+	key.jwk.Use = "sig"
+	key.jwk.Algorithm = key.method.Alg()
+	key.jwk.KeyID = thumbprint(key.jwk)
+
+	ks.keys[key.jwk.KeyID] = &key
+
+	return key.jwk.KeyID, nil
+}
+
+// thumbprint computes the RFC 7638 thumbprint of the required members of a key.
+func thumbprint(jwk JWK) string {
+	var members []byte
+
+	switch jwk.KeyType {
+	case "RSA":
+		members, _ = json.Marshal(struct {
+			E       string `json:"e"`
+			KeyType string `json:"kty"`
+			N       string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N})
+	case "OKP":
+		members, _ = json.Marshal(struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X})
+	}
+
+	sum := sha256.Sum256(members)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func readPrivateKey(file string) (crypto.PrivateKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", file, err)
+	}
+
+	switch key.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type in %s", file)
+	}
+}
+
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", file, err)
+	}
+
+	return key, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", file)
+	}
+
+	return block, nil
+}
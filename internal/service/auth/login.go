@@ -4,19 +4,24 @@ import (
 	"net/mail"
 	"time"
 
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
 	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type LoginReq struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+
+	Client ClientInfo `json:"-"`
 }
 
 type LoginResp struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+
+	// set instead of the tokens when the user has to pass the second factor
+	MFAToken string `json:"mfa_token,omitempty"`
 }
 
 func (s *Service) Login(req *LoginReq) (LoginResp, error) {
@@ -24,9 +29,19 @@ func (s *Service) Login(req *LoginReq) (LoginResp, error) {
 		return LoginResp{}, err
 	}
 
+	if err := s.checkIPAttempts(req.Client.IP); err != nil {
+		if err == ErrTooManyAttempts {
+			s.recordAuthEvent(models.AuthEventLogin, uuid.Nil, req.Client, err)
+		}
+
+		return LoginResp{}, err
+	}
+
 	user, err := s.Storage.User.GetByEmail(req.Email)
 	if err != nil {
 		if err == models.ErrUserNotFound {
+			s.recordFailedAttempt(nil, req.Client.IP)
+			s.recordAuthEvent(models.AuthEventLogin, uuid.Nil, req.Client, ErrUserNotFound)
 			return LoginResp{}, ErrUserNotFound
 		}
 
@@ -34,50 +49,150 @@ func (s *Service) Login(req *LoginReq) (LoginResp, error) {
 		return LoginResp{}, err
 	}
 
-	switch user.State {
-	case models.UserStatePending:
-		return LoginResp{}, ErrUserNotConfirmed
-	case models.UserStateDeleted:
-		return LoginResp{}, ErrUserNotFound
+	if err := userStateError(user.State); err != nil {
+		s.recordAuthEvent(models.AuthEventLogin, user.ID, req.Client, err)
+		return LoginResp{}, err
+	}
+
+	if err := s.checkAccountAttempts(user.ID); err != nil {
+		if err == ErrAccountLocked || err == ErrTooManyAttempts {
+			s.recordAuthEvent(models.AuthEventLogin, user.ID, req.Client, err)
+		}
+
+		return LoginResp{}, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(req.Password)); err != nil {
-		if err != bcrypt.ErrMismatchedHashAndPassword {
+	// accounts created through an external provider have no password
+	if len(user.PasswordHash) == 0 {
+		s.recordAuthEvent(models.AuthEventLogin, user.ID, req.Client, ErrInvalidPassword)
+		return LoginResp{}, ErrInvalidPassword
+	}
+
+	if err := s.Hasher.Compare(user.PasswordHash, []byte(req.Password)); err != nil {
+		if err != hashing.ErrMismatchedHash {
 			s.Logger.Error("failed to verify password", "err", err)
 			return LoginResp{}, err
 		}
 
+		s.recordFailedAttempt(user, req.Client.IP)
+		s.recordAuthEvent(models.AuthEventLogin, user.ID, req.Client, ErrInvalidPassword)
 		return LoginResp{}, ErrInvalidPassword
 	}
 
-	accessToken, err := generateAccessToken(user.ID, s.Cfg.AccessTokenTTL, s.Cfg.JWTKey)
+	if err := s.resetAttempts(user.ID); err != nil {
+		return LoginResp{}, err
+	}
+
+	s.upgradePasswordHash(user, req.Password)
+
+	return s.completeLogin(user.ID, req.Client)
+}
+
+// userStateError is the error a login into an account in the state is
+// refused with, nil for active accounts.
+func userStateError(state models.UserState) error {
+	switch state {
+	case models.UserStatePending:
+		return ErrUserNotConfirmed
+	case models.UserStateDeleted:
+		return ErrUserNotFound
+	case models.UserStateSuspended:
+		return ErrUserSuspended
+	}
+
+	return nil
+}
+
+// upgradePasswordHash rehashes a verified password made with older hash
+// settings. It is best effort, the old hash keeps working if it fails.
+func (s *Service) upgradePasswordHash(user *models.User, password string) {
+	if !s.Hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	passHash, err := s.Hasher.Hash([]byte(password))
+	if err != nil {
+		s.Logger.Error("failed to rehash password", "err", err)
+		return
+	}
+
+	if err := s.Storage.User.UpdatePassword(user.ID, passHash); err != nil {
+		s.Logger.Error("failed to update password hash", "err", err)
+		return
+	}
+
+	s.Logger.Info("password hash upgraded", "user_id", user.ID)
+}
+
+// completeLogin is called once the first factor is verified: it opens a
+// session, or starts the second factor challenge if the user has one.
+func (s *Service) completeLogin(userID uuid.UUID, client ClientInfo) (LoginResp, error) {
+	totp, err := s.Storage.TOTP.GetByUser(userID)
+	if err != nil && err != models.ErrTOTPNotFound {
+		s.Logger.Error("failed to get totp", "err", err)
+		return LoginResp{}, err
+	}
+
+	if totp != nil && totp.Enabled {
+		return s.startMFAChallenge(userID)
+	}
+
+	return s.startSession(userID, client)
+}
+
+// startSession opens a new refresh token branch and issues the token pair,
+// every way of logging in ends here. The state is checked once more, the
+// account may have been suspended or deleted while the login was under way.
+func (s *Service) startSession(userID uuid.UUID, client ClientInfo) (LoginResp, error) {
+	user, err := s.Storage.User.GetByID(userID)
 	if err != nil {
-		s.Logger.Error("failed to generate jwt token", "err", err)
+		if err == models.ErrUserNotFound {
+			return LoginResp{}, ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return LoginResp{}, err
+	}
+
+	if user.State != models.UserStateActive {
+		err := userStateError(user.State)
+		s.recordAuthEvent(models.AuthEventLogin, userID, client, err)
 		return LoginResp{}, err
 	}
 
-	refreshToken := uuid.New().String()
-	refreshTokenHash, err := bcrypt.GenerateFromPassword([]byte(refreshToken), bcrypt.DefaultCost)
+	refreshSecret, refreshTokenHash, err := generateSelectorSecret()
 	if err != nil {
-		s.Logger.Error("failed to hash refresh token", "err", err)
+		s.Logger.Error("failed to generate refresh token", "err", err)
 		return LoginResp{}, err
 	}
 
-	if err = s.Storage.Token.Insert(&models.Token{
-		UserID:    user.ID,
+	refreshToken := &models.Token{
+		UserID:    userID,
 		Hash:      refreshTokenHash,
 		Branch:    uuid.New(),
 		Scope:     models.TokenScopeRefresh,
 		Status:    models.TokenStatusActive,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
 		ExpiresAt: time.Now().Add(s.Cfg.RefreshTokenTTL),
-	}); err != nil {
+	}
+
+	if err = s.Storage.Token.Insert(refreshToken); err != nil {
 		s.Logger.Error("failed to save refresh token", "err", err)
 		return LoginResp{}, err
 	}
 
+	accessToken, err := s.issueAccessToken(userID, refreshToken.Branch)
+	if err != nil {
+		return LoginResp{}, err
+	}
+
+	s.checkNewDevice(userID, refreshToken.Branch, client)
+	s.recordAuthEvent(models.AuthEventLogin, userID, client, nil)
+
 	return LoginResp{
 		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		RefreshToken: formatSelectorToken(refreshToken.ID, refreshSecret),
 	}, nil
 }
 
@@ -87,7 +202,8 @@ func validateLoginReq(req *LoginReq) error {
 		return ErrInvalidEmail
 	}
 
-	if len(req.Password) < 8 {
+	// the policy applies to new passwords only, older ones may not meet it
+	if len(req.Password) == 0 {
 		return ErrInvalidPassword
 	}
 
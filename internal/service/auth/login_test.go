@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// lastEvent is the latest entry of the audit log.
+func lastEvent(t *testing.T, db *fakeDB) *models.AuthEvent {
+	t.Helper()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(db.events) == 0 {
+		t.Fatal("no auth events recorded")
+	}
+
+	return db.events[len(db.events)-1]
+}
+
+// checkLoginFailure checks that the rejection was recorded with its reason.
+func checkLoginFailure(t *testing.T, db *fakeDB, userID uuid.UUID, reason error) {
+	t.Helper()
+
+	event := lastEvent(t, db)
+
+	if event.Type != models.AuthEventLogin || event.Outcome != models.AuthEventFailure {
+		t.Errorf("got %s event with outcome %s, want a failed login", event.Type, event.Outcome)
+	}
+
+	if event.Detail != reason.Error() {
+		t.Errorf("got reason %q, want %q", event.Detail, reason)
+	}
+
+	if event.UserID.UUID != userID {
+		t.Errorf("got user %v, want %v", event.UserID.UUID, userID)
+	}
+}
+
+// lock locks the attempt counter the way recordFailedAttempt does.
+func lock(t *testing.T, s *Service, key string) {
+	t.Helper()
+
+	if _, err := s.Storage.Attempt.RecordFailure(key, s.Cfg.AttemptWindow); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Storage.Attempt.Lock(key, time.Now().Add(s.Cfg.LockoutDuration)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginFailureEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, s *Service, user *models.User)
+		email    string
+		password string
+		want     error
+		// the account the event belongs to, if the attempt matched one
+		matched bool
+	}{
+		{
+			name:  "unknown email",
+			email: "nobody@example.com",
+			want:  ErrUserNotFound,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong password",
+			want:     ErrInvalidPassword,
+			matched:  true,
+		},
+		{
+			name: "unconfirmed",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				s.Storage.User.UpdateStatus(user.ID, models.UserStatePending)
+			},
+			want:    ErrUserNotConfirmed,
+			matched: true,
+		},
+		{
+			name: "suspended",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				s.Storage.User.Suspend(user.ID, "spam", uuid.New(), nil)
+			},
+			want:    ErrUserSuspended,
+			matched: true,
+		},
+		{
+			name: "deleted",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				s.Storage.User.UpdateStatus(user.ID, models.UserStateDeleted)
+			},
+			want:    ErrUserNotFound,
+			matched: true,
+		},
+		{
+			name: "account locked",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				lock(t, s, accountAttemptKey(user.ID))
+			},
+			want:    ErrAccountLocked,
+			matched: true,
+		},
+		{
+			name: "ip locked",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				lock(t, s, ipAttemptKey(testClient.IP))
+			},
+			want: ErrTooManyAttempts,
+		},
+		{
+			name: "no password",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				s.Storage.User.UpdatePassword(user.ID, []byte{})
+			},
+			want:    ErrInvalidPassword,
+			matched: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db := newTestService(t)
+			user := createUser(t, s, "user@example.com")
+
+			if tt.setup != nil {
+				tt.setup(t, s, user)
+			}
+
+			req := &LoginReq{Email: "user@example.com", Password: testPassword, Client: testClient}
+			if tt.email != "" {
+				req.Email = tt.email
+			}
+			if tt.password != "" {
+				req.Password = tt.password
+			}
+
+			if _, err := s.Login(req); err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			userID := uuid.Nil
+			if tt.matched {
+				userID = user.ID
+			}
+
+			checkLoginFailure(t, db, userID, tt.want)
+		})
+	}
+}
+
+func TestLoginMFAFailureEvents(t *testing.T) {
+	s, db := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	enableTOTP(t, s, user.ID)
+
+	challenge := login(t, s, "user@example.com")
+
+	if _, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: "000000", Client: testClient}); err != ErrInvalidCode {
+		t.Fatalf("wrong code: got %v, want %v", err, ErrInvalidCode)
+	}
+
+	checkLoginFailure(t, db, user.ID, ErrInvalidCode)
+
+	forged := formatSelectorToken(uuid.New(), "secret")
+	if _, err := s.LoginMFA(&LoginMFAReq{MFAToken: forged, Code: "000000", Client: testClient}); err != ErrInvalidMFAToken {
+		t.Fatalf("forged token: got %v, want %v", err, ErrInvalidMFAToken)
+	}
+
+	checkLoginFailure(t, db, uuid.Nil, ErrInvalidMFAToken)
+}
+
+func TestPasskeyLoginFailureEvents(t *testing.T) {
+	s, db := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	authenticator := registerPasskey(t, s, user.ID)
+
+	if _, err := s.FinishPasskeyLogin(passkeyLoginReq(t, s, "user@example.com", authenticator)); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	// a clone still holding the old counter
+	req := passkeyLoginReq(t, s, "user@example.com", authenticator)
+	authenticator.SignCount = 0
+	req.Credential.Response = authenticator.Get(ceremonyChallenge(req.Ceremony), nil)
+
+	if _, err := s.FinishPasskeyLogin(req); err != ErrInvalidPasskey {
+		t.Fatalf("got %v, want %v", err, ErrInvalidPasskey)
+	}
+
+	checkLoginFailure(t, db, user.ID, errSignCountReplayed)
+
+	// the ceremony began before the suspension
+	req = passkeyLoginReq(t, s, "user@example.com", authenticator)
+	s.Storage.User.Suspend(user.ID, "spam", uuid.New(), nil)
+
+	if _, err := s.FinishPasskeyLogin(req); err != ErrUserSuspended {
+		t.Fatalf("got %v, want %v", err, ErrUserSuspended)
+	}
+
+	checkLoginFailure(t, db, user.ID, ErrUserSuspended)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// Logout revokes the refresh token branch the presented token belongs to.
+func (s *Service) Logout(req *LogoutReq) error {
+	if len(req.RefreshToken) == 0 {
+		return ErrInvalidRefreshToken
+	}
+
+	token, err := s.verifyRefreshToken(req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Storage.Token.DeleteAllByBranch(token.UserID, token.Branch); err != nil {
+		s.Logger.Error("failed to delete tokens for branch", "err", err)
+		return err
+	}
+
+	s.recordAuthEvent(models.AuthEventLogout, token.UserID, req.Client, nil)
+
+	return s.revokeSessionAccessTokens(token.Branch)
+}
+
+// LogoutAll revokes every refresh token branch of the user, along with
+// the access tokens issued so far.
+func (s *Service) LogoutAll(userID uuid.UUID, client ClientInfo) error {
+	if err := s.Storage.Token.DeleteAllByUser(userID); err != nil {
+		s.Logger.Error("failed to delete tokens for user", "err", err)
+		return err
+	}
+
+	s.recordAuthEvent(models.AuthEventLogoutAll, userID, client, nil)
+
+	return s.revokeUserAccessTokens(userID)
+}
+
+// deleteLoginCodes drops the logins of the user that are half way through:
+// mfa challenges, passkey ceremonies and email login codes would still open
+// a session otherwise.
+func (s *Service) deleteLoginCodes(userID uuid.UUID) error {
+	for _, scope := range []models.CodeScope{models.CodeScopeMFA, models.CodeScopeWebAuthn, models.CodeScopeLogin} {
+		if err := s.Storage.Code.DeleteAllByUser(userID, scope); err != nil {
+			s.Logger.Error("failed to delete codes for user", "err", err, "scope", scope)
+			return err
+		}
+	}
+
+	return nil
+}
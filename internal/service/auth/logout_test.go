@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+func TestLogout(t *testing.T) {
+	s, _ := newTestService(t)
+	createUser(t, s, "user@example.com")
+
+	session := login(t, s, "user@example.com")
+	other := login(t, s, "user@example.com")
+
+	if err := s.Logout(&LogoutReq{RefreshToken: session.RefreshToken, Client: testClient}); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient}); err != ErrInvalidRefreshToken {
+		t.Errorf("refresh after logout: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, err := s.Authorize(session.AccessToken); err != ErrAccessTokenRevoked {
+		t.Errorf("access token after logout: got %v, want %v", err, ErrAccessTokenRevoked)
+	}
+
+	// the other session is left alone
+	if _, err := s.Authorize(other.AccessToken); err != nil {
+		t.Errorf("access token of the other session: %v", err)
+	}
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: other.RefreshToken, Client: testClient}); err != nil {
+		t.Errorf("refresh of the other session: %v", err)
+	}
+
+	if err := s.Logout(&LogoutReq{RefreshToken: session.RefreshToken, Client: testClient}); err != ErrInvalidRefreshToken {
+		t.Errorf("second logout: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	s, db := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	createUser(t, s, "other@example.com")
+
+	sessions := []LoginResp{
+		login(t, s, "user@example.com"),
+		login(t, s, "user@example.com"),
+	}
+
+	other := login(t, s, "other@example.com")
+
+	if err := s.LogoutAll(user.ID, testClient); err != nil {
+		t.Fatalf("logout all: %v", err)
+	}
+
+	for i, session := range sessions {
+		if _, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient}); err != ErrInvalidRefreshToken {
+			t.Errorf("refresh of session %d: got %v, want %v", i, err, ErrInvalidRefreshToken)
+		}
+
+		if _, err := s.Authorize(session.AccessToken); err != ErrAccessTokenRevoked {
+			t.Errorf("access token of session %d: got %v, want %v", i, err, ErrAccessTokenRevoked)
+		}
+	}
+
+	left, _ := s.Sessions(user.ID, uuid.Nil)
+	if len(left) != 0 {
+		t.Errorf("%d sessions left after logout all", len(left))
+	}
+
+	// other users keep their sessions
+	if _, err := s.Authorize(other.AccessToken); err != nil {
+		t.Errorf("access token of another user: %v", err)
+	}
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: other.RefreshToken, Client: testClient}); err != nil {
+		t.Errorf("refresh of another user: %v", err)
+	}
+
+	if len(db.events) == 0 || db.events[len(db.events)-1].Type != models.AuthEventRefresh {
+		t.Errorf("refresh of another user was not recorded")
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type LoginMFAReq struct {
+	MFAToken string `json:"mfa_token"`
+	// totp or recovery code
+	Code string `json:"code"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// startMFAChallenge is the first half of a login with two-factor
+// authentication: instead of the token pair the user gets a short-lived
+// mfa token, which LoginMFA exchanges for the tokens.
+func (s *Service) startMFAChallenge(userID uuid.UUID) (LoginResp, error) {
+	secret, hash, err := generateSelectorSecret()
+	if err != nil {
+		s.Logger.Error("failed to generate mfa token", "err", err)
+		return LoginResp{}, err
+	}
+
+	challenge := &models.Code{
+		UserID:    userID,
+		Hash:      hash,
+		Scope:     models.CodeScopeMFA,
+		ExpiresAt: time.Now().Add(s.Cfg.MFATokenTTL),
+	}
+
+	if err := s.Storage.Code.Insert(challenge); err != nil {
+		s.Logger.Error("failed to save mfa challenge", "err", err)
+		return LoginResp{}, err
+	}
+
+	return LoginResp{
+		MFAToken: formatSelectorToken(challenge.ID, secret),
+	}, nil
+}
+
+func (s *Service) LoginMFA(req *LoginMFAReq) (LoginResp, error) {
+	if err := validateLoginMFAReq(req); err != nil {
+		return LoginResp{}, err
+	}
+
+	challengeID, secret, err := parseSelectorToken(req.MFAToken)
+	if err != nil {
+		return LoginResp{}, ErrInvalidMFAToken
+	}
+
+	challenge, err := s.Storage.Code.GetByID(challengeID)
+	if err != nil {
+		if err == models.ErrCodeNotFound {
+			s.recordAuthEvent(models.AuthEventLogin, uuid.Nil, req.Client, ErrInvalidMFAToken)
+			return LoginResp{}, ErrInvalidMFAToken
+		}
+
+		s.Logger.Error("failed to get mfa challenge", "err", err)
+		return LoginResp{}, err
+	}
+
+	if challenge.Scope != models.CodeScopeMFA || !verifySelectorSecret(challenge.Hash, secret) {
+		s.recordAuthEvent(models.AuthEventLogin, uuid.Nil, req.Client, ErrInvalidMFAToken)
+		return LoginResp{}, ErrInvalidMFAToken
+	}
+
+	if challenge.ExpiresAt.Before(time.Now()) {
+		s.recordAuthEvent(models.AuthEventLogin, challenge.UserID, req.Client, ErrMFATokenExpired)
+		return LoginResp{}, ErrMFATokenExpired
+	}
+
+	if err := s.checkIPAttempts(req.Client.IP); err != nil {
+		if err == ErrTooManyAttempts {
+			s.recordAuthEvent(models.AuthEventLogin, challenge.UserID, req.Client, err)
+		}
+
+		return LoginResp{}, err
+	}
+
+	if err := s.checkAccountAttempts(challenge.UserID); err != nil {
+		if err == ErrAccountLocked || err == ErrTooManyAttempts {
+			s.recordAuthEvent(models.AuthEventLogin, challenge.UserID, req.Client, err)
+		}
+
+		return LoginResp{}, err
+	}
+
+	totp, err := s.Storage.TOTP.GetByUser(challenge.UserID)
+	if err != nil {
+		// two-factor authentication was disabled in the meantime
+		if err == models.ErrTOTPNotFound {
+			s.recordAuthEvent(models.AuthEventLogin, challenge.UserID, req.Client, ErrInvalidMFAToken)
+			return LoginResp{}, ErrInvalidMFAToken
+		}
+
+		s.Logger.Error("failed to get totp", "err", err)
+		return LoginResp{}, err
+	}
+
+	if err := s.verifySecondFactor(totp, req.Code); err != nil {
+		if err == ErrInvalidCode {
+			user, err := s.Storage.User.GetByID(challenge.UserID)
+			if err != nil {
+				s.Logger.Error("failed to get user by id", "err", err)
+				return LoginResp{}, err
+			}
+
+			s.recordFailedAttempt(user, req.Client.IP)
+			s.recordAuthEvent(models.AuthEventLogin, user.ID, req.Client, ErrInvalidCode)
+		}
+
+		return LoginResp{}, err
+	}
+
+	if err := s.resetAttempts(challenge.UserID); err != nil {
+		return LoginResp{}, err
+	}
+
+	// a challenge opens a single session, a concurrent request with the
+	// same mfa token loses here
+	if err := s.Storage.Code.Consume(challenge.ID); err != nil {
+		if err == models.ErrCodeNotFound {
+			return LoginResp{}, ErrInvalidMFAToken
+		}
+
+		s.Logger.Error("failed to delete used mfa challenge", "err", err)
+		return LoginResp{}, err
+	}
+
+	return s.startSession(challenge.UserID, req.Client)
+}
+
+func validateLoginMFAReq(req *LoginMFAReq) error {
+	if len(req.MFAToken) == 0 {
+		return ErrInvalidMFAToken
+	}
+
+	if len(req.Code) == 0 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
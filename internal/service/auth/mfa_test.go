@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// enableTOTP turns two-factor authentication on and returns the recovery codes.
+func enableTOTP(t *testing.T, s *Service, userID uuid.UUID) []string {
+	t.Helper()
+
+	if _, err := s.EnrollTOTP(userID); err != nil {
+		t.Fatalf("enroll totp: %v", err)
+	}
+
+	totp, err := s.Storage.TOTP.GetByUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.ConfirmTOTP(userID, &ConfirmTOTPReq{
+		Code: totpCode(totp.Secret, time.Now().Unix()/totpPeriod),
+	})
+
+	if err != nil {
+		t.Fatalf("confirm totp: %v", err)
+	}
+
+	return resp.RecoveryCodes
+}
+
+func TestLoginMFA(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	recoveryCodes := enableTOTP(t, s, user.ID)
+
+	challenge := login(t, s, "user@example.com")
+	if challenge.MFAToken == "" || challenge.AccessToken != "" {
+		t.Fatalf("login with totp enabled: got %+v, want an mfa token only", challenge)
+	}
+
+	if _, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: "wrong", Client: testClient}); err != ErrInvalidCode {
+		t.Errorf("wrong code: got %v, want %v", err, ErrInvalidCode)
+	}
+
+	resp, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: recoveryCodes[0], Client: testClient})
+	if err != nil {
+		t.Fatalf("login mfa: %v", err)
+	}
+
+	if _, err := s.Authorize(resp.AccessToken); err != nil {
+		t.Errorf("access token: %v", err)
+	}
+
+	// the mfa token and the recovery code are burned
+	if _, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: recoveryCodes[1], Client: testClient}); err != ErrInvalidMFAToken {
+		t.Errorf("mfa token reuse: got %v, want %v", err, ErrInvalidMFAToken)
+	}
+
+	challenge = login(t, s, "user@example.com")
+	if _, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: recoveryCodes[0], Client: testClient}); err != ErrInvalidCode {
+		t.Errorf("recovery code reuse: got %v, want %v", err, ErrInvalidCode)
+	}
+}
+
+func TestLoginMFAConcurrent(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	recoveryCodes := enableTOTP(t, s, user.ID)
+
+	challenge := login(t, s, "user@example.com")
+
+	// every request has a valid second factor, only one may open a session
+	var wg sync.WaitGroup
+	errs := make([]error, len(recoveryCodes))
+
+	for i, code := range recoveryCodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: code, Client: testClient})
+		}()
+	}
+
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			succeeded++
+		case ErrInvalidMFAToken:
+		default:
+			t.Errorf("login mfa: %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("%d requests succeeded with the same mfa token, want 1", succeeded)
+	}
+
+	sessions, err := s.Sessions(user.ID, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 1 {
+		t.Errorf("got %d sessions, want 1", len(sessions))
+	}
+}
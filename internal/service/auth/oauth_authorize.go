@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// the authorization endpoint either redirects back to the client right away
+// (the user has already granted the scopes) or shows the consent screen.
+// The screen carries a short-lived signed consent token with the request,
+// which is posted back with the decision of the user.
+
+type AuthorizeReq struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+type AuthorizeResp struct {
+	// set if no consent is needed
+	RedirectURL string
+	Consent     *ConsentPrompt
+}
+
+type ConsentPrompt struct {
+	ClientName   string
+	Scopes       []ConsentScope
+	ConsentToken string
+}
+
+type ConsentScope struct {
+	Name        string
+	Description string
+}
+
+type ConsentReq struct {
+	ConsentToken string
+	Approve      bool
+}
+
+// AuthorizeRedirectError means the request came from a registered client,
+// so the error is reported to the client by redirecting back to it.
+type AuthorizeRedirectError struct {
+	Err         error
+	RedirectURL string
+}
+
+func (e *AuthorizeRedirectError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AuthorizeRedirectError) Unwrap() error {
+	return e.Err
+}
+
+// BeginAuthorization validates the request of the client. Errors with the
+// client or the redirect uri are returned as is, all other errors as
+// *AuthorizeRedirectError.
+func (s *Service) BeginAuthorization(userID uuid.UUID, req *AuthorizeReq) (AuthorizeResp, error) {
+	client, scopes, err := s.validateAuthorizeReq(req)
+	if err != nil {
+		return AuthorizeResp{}, err
+	}
+
+	consent, err := s.Storage.OAuthConsent.Get(userID, client.ID)
+	if err != nil && err != models.ErrOAuthConsentNotFound {
+		s.Logger.Error("failed to get oauth consent", "err", err)
+		return AuthorizeResp{}, err
+	}
+
+	if consent != nil && isSubset(scopes, consent.Scopes) {
+		redirectURL, err := s.issueAuthorizationCode(userID, client, scopes, req)
+		if err != nil {
+			return AuthorizeResp{}, err
+		}
+
+		return AuthorizeResp{RedirectURL: redirectURL}, nil
+	}
+
+	consentToken, err := s.Keys.Sign(jwt.MapClaims{
+		"iss": "gymshark",
+		"typ": "oauth_consent",
+		"sub": userID,
+		"req": req,
+		"exp": time.Now().Add(s.Cfg.OAuthConsentTTL).Unix(),
+		"iat": time.Now().Unix(),
+	})
+	if err != nil {
+		s.Logger.Error("failed to sign consent token", "err", err)
+		return AuthorizeResp{}, err
+	}
+
+	prompt := &ConsentPrompt{
+		ClientName:   client.Name,
+		ConsentToken: consentToken,
+	}
+
+	for _, scope := range scopes {
+		prompt.Scopes = append(prompt.Scopes, ConsentScope{
+			Name:        scope,
+			Description: OAuthScopes[scope],
+		})
+	}
+
+	return AuthorizeResp{Consent: prompt}, nil
+}
+
+// FinishAuthorization records the decision of the user and returns the url
+// to redirect the user back to the client with.
+func (s *Service) FinishAuthorization(userID uuid.UUID, req *ConsentReq) (string, error) {
+	token, err := jwt.Parse(req.ConsentToken, s.Keys.Keyfunc)
+	if err != nil || !token.Valid {
+		return "", ErrInvalidConsent
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidConsent
+	}
+
+	if typ, _ := claims["typ"].(string); typ != "oauth_consent" {
+		return "", ErrInvalidConsent
+	}
+
+	// the consent screen was shown to another user
+	if sub, _ := claims["sub"].(string); sub != userID.String() {
+		return "", ErrInvalidConsent
+	}
+
+	var authorizeReq AuthorizeReq
+	if raw, err := json.Marshal(claims["req"]); err != nil || json.Unmarshal(raw, &authorizeReq) != nil {
+		return "", ErrInvalidConsent
+	}
+
+	// the client may have changed since the screen was shown
+	client, scopes, err := s.validateAuthorizeReq(&authorizeReq)
+	if err != nil {
+		return "", err
+	}
+
+	if !req.Approve {
+		return authorizeErrorURL(&authorizeReq, "access_denied"), nil
+	}
+
+	consent := &models.OAuthConsent{
+		UserID:   userID,
+		ClientID: client.ID,
+		Scopes:   scopes,
+	}
+
+	// the scopes granted earlier stay granted
+	previous, err := s.Storage.OAuthConsent.Get(userID, client.ID)
+	if err != nil && err != models.ErrOAuthConsentNotFound {
+		s.Logger.Error("failed to get oauth consent", "err", err)
+		return "", err
+	}
+
+	if previous != nil {
+		for _, scope := range previous.Scopes {
+			if !slices.Contains(consent.Scopes, scope) {
+				consent.Scopes = append(consent.Scopes, scope)
+			}
+		}
+	}
+
+	if err := s.Storage.OAuthConsent.Upsert(consent); err != nil {
+		s.Logger.Error("failed to save oauth consent", "err", err)
+		return "", err
+	}
+
+	return s.issueAuthorizationCode(userID, client, scopes, &authorizeReq)
+}
+
+func (s *Service) issueAuthorizationCode(userID uuid.UUID, client *models.OAuthClient, scopes []string, req *AuthorizeReq) (string, error) {
+	secret, hash, err := generateSelectorSecret()
+	if err != nil {
+		s.Logger.Error("failed to generate authorization code", "err", err)
+		return "", err
+	}
+
+	code := &models.OAuthCode{
+		ClientID:      client.ID,
+		UserID:        userID,
+		Hash:          hash,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		ExpiresAt:     time.Now().Add(s.Cfg.OAuthCodeTTL),
+	}
+
+	if err := s.Storage.OAuthCode.Insert(code); err != nil {
+		s.Logger.Error("failed to save authorization code", "err", err)
+		return "", err
+	}
+
+	redirectURL, _ := url.Parse(req.RedirectURI)
+
+	query := redirectURL.Query()
+	query.Set("code", formatSelectorToken(code.ID, secret))
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	query.Set("iss", s.Cfg.OAuthIssuer)
+	redirectURL.RawQuery = query.Encode()
+
+	return redirectURL.String(), nil
+}
+
+func (s *Service) validateAuthorizeReq(req *AuthorizeReq) (*models.OAuthClient, []string, error) {
+	clientID, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		return nil, nil, ErrInvalidClient
+	}
+
+	client, err := s.Storage.OAuthClient.GetByID(clientID)
+	if err != nil {
+		if err == models.ErrOAuthClientNotFound {
+			return nil, nil, ErrInvalidClient
+		}
+
+		s.Logger.Error("failed to get oauth client", "err", err)
+		return nil, nil, err
+	}
+
+	// redirect uris are compared exactly, otherwise the code could leak
+	// to a page the client does not control
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return nil, nil, ErrInvalidRedirectURI
+	}
+
+	redirectErr := func(err error, code string) error {
+		return &AuthorizeRedirectError{
+			Err:         err,
+			RedirectURL: authorizeErrorURL(req, code),
+		}
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, redirectErr(ErrUnsupportedResponseType, "unsupported_response_type")
+	}
+
+	if !slices.Contains(client.GrantTypes, GrantTypeAuthorizationCode) {
+		return nil, nil, redirectErr(ErrInvalidClient, "unauthorized_client")
+	}
+
+	// PKCE is required for every client
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) != 43 {
+		return nil, nil, redirectErr(ErrInvalidAuthorizeRequest, "invalid_request")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 || !isSubset(scopes, client.Scopes) {
+		return nil, nil, redirectErr(ErrInvalidScope, "invalid_scope")
+	}
+
+	return client, slices.Compact(slices.Sorted(slices.Values(scopes))), nil
+}
+
+func authorizeErrorURL(req *AuthorizeReq, code string) string {
+	redirectURL, _ := url.Parse(req.RedirectURI)
+
+	query := redirectURL.Query()
+	query.Set("error", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirectURL.RawQuery = query.Encode()
+
+	return redirectURL.String()
+}
+
+func isSubset(scopes []string, granted []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+
+	return true
+}
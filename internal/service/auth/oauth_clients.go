@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// grant types of the token endpoint
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuthScopes are the scopes partner apps may ask for, with the
+// descriptions shown on the consent screen.
+var OAuthScopes = map[string]string{
+	"openid":         "Sign you in with your gymshark account",
+	"profile":        "See your first and last name",
+	"email":          "See your email address",
+	"offline_access": "Stay connected while you are not using the app",
+}
+
+// scopes that only make sense on behalf of a user
+var userOnlyScopes = []string{"openid", "profile", "email", "offline_access"}
+
+type OAuthClient struct {
+	ID           uuid.UUID `json:"client_id"`
+	Name         string    `json:"name"`
+	Public       bool      `json:"public"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	GrantTypes   []string  `json:"grant_types"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type RegisterClientReq struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types"`
+	// apps that can not keep a secret (mobile, single page) get no secret
+	// and must use PKCE
+	Public bool `json:"public"`
+}
+
+type RegisterClientResp struct {
+	OAuthClient
+	// shown only once
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+func (s *Service) RegisterClient(ownerID uuid.UUID, req *RegisterClientReq) (RegisterClientResp, error) {
+	if err := validateRegisterClientReq(req); err != nil {
+		return RegisterClientResp{}, err
+	}
+
+	client := &models.OAuthClient{
+		OwnerID:      ownerID,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+	}
+
+	var secret string
+	if !req.Public {
+		var err error
+		if secret, err = generateCode(32); err != nil {
+			s.Logger.Error("failed to generate client secret", "err", err)
+			return RegisterClientResp{}, err
+		}
+
+		if client.SecretHash, err = s.Hasher.Hash([]byte(secret)); err != nil {
+			s.Logger.Error("failed to hash client secret", "err", err)
+			return RegisterClientResp{}, err
+		}
+	}
+
+	if err := s.Storage.OAuthClient.Insert(client); err != nil {
+		s.Logger.Error("failed to save oauth client", "err", err)
+		return RegisterClientResp{}, err
+	}
+
+	return RegisterClientResp{
+		OAuthClient:  newOAuthClient(client),
+		ClientSecret: secret,
+	}, nil
+}
+
+func (s *Service) Clients(ownerID uuid.UUID) ([]OAuthClient, error) {
+	clients, err := s.Storage.OAuthClient.GetAllByOwner(ownerID)
+	if err != nil {
+		s.Logger.Error("failed to get oauth clients", "err", err)
+		return nil, err
+	}
+
+	result := make([]OAuthClient, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, newOAuthClient(client))
+	}
+
+	return result, nil
+}
+
+// DeleteClient removes the client together with its codes and tokens.
+func (s *Service) DeleteClient(ownerID uuid.UUID, clientID uuid.UUID) error {
+	if err := s.Storage.OAuthClient.Delete(ownerID, clientID); err != nil {
+		if err == models.ErrOAuthClientNotFound {
+			return ErrClientNotFound
+		}
+
+		s.Logger.Error("failed to delete oauth client", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// authenticateClient checks the credentials presented to the token
+// endpoint. Public clients present only their id.
+func (s *Service) authenticateClient(clientID string, secret string) (*models.OAuthClient, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := s.Storage.OAuthClient.GetByID(id)
+	if err != nil {
+		if err == models.ErrOAuthClientNotFound {
+			return nil, ErrInvalidClient
+		}
+
+		s.Logger.Error("failed to get oauth client", "err", err)
+		return nil, err
+	}
+
+	if client.SecretHash == nil {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+
+		return client, nil
+	}
+
+	if err := s.Hasher.Compare(client.SecretHash, []byte(secret)); err != nil {
+		if err != hashing.ErrMismatchedHash {
+			s.Logger.Error("failed to verify client secret", "err", err)
+			return nil, err
+		}
+
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+func newOAuthClient(client *models.OAuthClient) OAuthClient {
+	return OAuthClient{
+		ID:           client.ID,
+		Name:         client.Name,
+		Public:       client.SecretHash == nil,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		GrantTypes:   client.GrantTypes,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+func validateRegisterClientReq(req *RegisterClientReq) error {
+	if len(req.Name) == 0 || len(req.GrantTypes) == 0 {
+		return ErrInvalidClientMetadata
+	}
+
+	for _, grantType := range req.GrantTypes {
+		switch grantType {
+		case GrantTypeAuthorizationCode, GrantTypeRefreshToken:
+		case GrantTypeClientCredentials:
+			// the client itself is the subject, so it has to authenticate
+			if req.Public {
+				return ErrInvalidClientMetadata
+			}
+		default:
+			return ErrUnsupportedGrantType
+		}
+	}
+
+	if slices.Contains(req.GrantTypes, GrantTypeAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		return ErrInvalidRedirectURI
+	}
+
+	for _, redirectURI := range req.RedirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return ErrInvalidRedirectURI
+		}
+	}
+
+	for _, scope := range req.Scopes {
+		if _, ok := OAuthScopes[scope]; !ok {
+			return ErrInvalidScope
+		}
+	}
+
+	if req.RedirectURIs == nil {
+		req.RedirectURIs = []string{}
+	}
+
+	if req.Scopes == nil {
+		req.Scopes = []string{}
+	}
+
+	return nil
+}
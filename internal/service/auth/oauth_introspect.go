@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type IntrospectReq struct {
+	Token string
+
+	ClientID     string
+	ClientSecret string
+}
+
+// IntrospectResp is the RFC 7662 response. Inactive tokens carry nothing
+// but the active flag.
+type IntrospectResp struct {
+	Active    bool   `json:"active"`
+	Subject   string `json:"sub,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
+
+type RevokeReq struct {
+	Token string
+
+	ClientID     string
+	ClientSecret string
+}
+
+// Introspect tells a confidential client whether the token is active.
+// Access tokens of any client can be introspected, so that resource
+// servers can check them, refresh tokens only by the client they were
+// issued to.
+func (s *Service) Introspect(req *IntrospectReq) (IntrospectResp, error) {
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return IntrospectResp{}, err
+	}
+
+	// public clients can not keep a secret, anyone could use them to probe tokens
+	if client.SecretHash == nil {
+		return IntrospectResp{}, ErrInvalidClient
+	}
+
+	if len(req.Token) == 0 {
+		return IntrospectResp{}, nil
+	}
+
+	// the token_type_hint is not needed, the format tells the types apart
+	if isJWT(req.Token) {
+		return s.introspectAccessToken(req.Token), nil
+	}
+
+	return s.introspectRefreshToken(client, req.Token)
+}
+
+func (s *Service) introspectAccessToken(accessToken string) IntrospectResp {
+	if claims, err := s.AuthorizeClient(accessToken); err == nil {
+		subject := claims.ClientID.String()
+		if claims.UserID.Valid {
+			subject = claims.UserID.UUID.String()
+		}
+
+		return IntrospectResp{
+			Active:    true,
+			Subject:   subject,
+			ExpiresAt: claims.ExpiresAt.Unix(),
+			Scope:     strings.Join(claims.Scopes, " "),
+			ClientID:  claims.ClientID.String(),
+			TokenType: "Bearer",
+		}
+	}
+
+	if claims, err := s.Authorize(accessToken); err == nil {
+		return IntrospectResp{
+			Active:    true,
+			Subject:   claims.UserID.String(),
+			ExpiresAt: claims.ExpiresAt.Unix(),
+			TokenType: "Bearer",
+		}
+	}
+
+	return IntrospectResp{}
+}
+
+func (s *Service) introspectRefreshToken(client *models.OAuthClient, refreshToken string) (IntrospectResp, error) {
+	token, err := s.verifyRefreshToken(refreshToken)
+	if err != nil {
+		if err == ErrInvalidRefreshToken {
+			return IntrospectResp{}, nil
+		}
+
+		return IntrospectResp{}, err
+	}
+
+	if token.ClientID != (uuid.NullUUID{UUID: client.ID, Valid: true}) {
+		return IntrospectResp{}, nil
+	}
+
+	if token.Status != models.TokenStatusActive || token.ExpiresAt.Before(time.Now()) {
+		return IntrospectResp{}, nil
+	}
+
+	return IntrospectResp{
+		Active:    true,
+		Subject:   token.UserID.String(),
+		ExpiresAt: token.ExpiresAt.Unix(),
+		Scope:     strings.Join(token.Scopes, " "),
+		ClientID:  client.ID.String(),
+		TokenType: "refresh_token",
+	}, nil
+}
+
+// Revoke revokes the refresh token branch the token belongs to, with the
+// access tokens issued for it. Invalid tokens are not reported, as RFC 7009
+// requires, and neither are tokens of other clients.
+func (s *Service) Revoke(req *RevokeReq) error {
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	if len(req.Token) == 0 {
+		return nil
+	}
+
+	var userID, branch uuid.UUID
+
+	if isJWT(req.Token) {
+		claims, err := s.AuthorizeClient(req.Token)
+		if err != nil || claims.ClientID != client.ID {
+			return nil
+		}
+
+		if err := s.revokeAccessToken(claims.TokenID, claims.ExpiresAt); err != nil {
+			return err
+		}
+
+		if !claims.UserID.Valid || !claims.SessionID.Valid {
+			s.Logger.Info("oauth token revoked", "client_id", client.ID)
+			return nil
+		}
+
+		userID, branch = claims.UserID.UUID, claims.SessionID.UUID
+	} else {
+		token, err := s.verifyRefreshToken(req.Token)
+		if err != nil {
+			if err == ErrInvalidRefreshToken {
+				return nil
+			}
+
+			return err
+		}
+
+		if token.ClientID != (uuid.NullUUID{UUID: client.ID, Valid: true}) {
+			return nil
+		}
+
+		userID, branch = token.UserID, token.Branch
+	}
+
+	if err := s.Storage.Token.DeleteAllByBranch(userID, branch); err != nil {
+		s.Logger.Error("failed to delete tokens for branch", "err", err)
+		return err
+	}
+
+	if err := s.revokeSessionAccessTokens(branch); err != nil {
+		return err
+	}
+
+	s.Logger.Info("oauth token revoked", "client_id", client.ID, "user_id", userID, "branch", branch)
+	return nil
+}
+
+// isJWT reports whether the token looks like a signed jwt rather than a
+// selector token.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
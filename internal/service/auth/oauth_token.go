@@ -0,0 +1,480 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type TokenReq struct {
+	GrantType string
+
+	// authorization_code
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+
+	// refresh_token
+	RefreshToken string
+
+	// client_credentials and refresh_token
+	Scope string
+
+	ClientID     string
+	ClientSecret string
+
+	Client ClientInfo
+}
+
+type TokenResp struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// ClientClaims are the claims of an access token issued to a partner app.
+type ClientClaims struct {
+	TokenID string
+	// the user the app acts for, null for the client credentials grant
+	UserID    uuid.NullUUID
+	SessionID uuid.NullUUID
+	ClientID  uuid.UUID
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+}
+
+// Discovery is the OpenID Connect provider metadata.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+	RevocationEndpoint    string `json:"revocation_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+
+	AuthorizationResponseIssParameterSupported bool `json:"authorization_response_iss_parameter_supported"`
+}
+
+func (s *Service) Token(req *TokenReq) (TokenResp, error) {
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return TokenResp{}, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials:
+	default:
+		return TokenResp{}, ErrUnsupportedGrantType
+	}
+
+	if !slices.Contains(client.GrantTypes, req.GrantType) {
+		return TokenResp{}, ErrUnsupportedGrantType
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(client, req)
+	case GrantTypeRefreshToken:
+		return s.refreshClientToken(client, req)
+	default:
+		return s.issueClientCredentialsToken(client, req)
+	}
+}
+
+func (s *Service) exchangeAuthorizationCode(client *models.OAuthClient, req *TokenReq) (TokenResp, error) {
+	codeID, secret, err := parseSelectorToken(req.Code)
+	if err != nil {
+		return TokenResp{}, ErrInvalidGrant
+	}
+
+	code, err := s.Storage.OAuthCode.GetByID(codeID)
+	if err != nil {
+		if err == models.ErrOAuthCodeNotFound {
+			return TokenResp{}, ErrInvalidGrant
+		}
+
+		s.Logger.Error("failed to get authorization code", "err", err)
+		return TokenResp{}, err
+	}
+
+	if code.ClientID != client.ID {
+		return TokenResp{}, ErrInvalidGrant
+	}
+
+	if !verifySelectorSecret(code.Hash, secret) {
+		return TokenResp{}, ErrInvalidGrant
+	}
+
+	// codes are single use, the delete fails for the slower of two
+	// concurrent exchanges
+	if err := s.Storage.OAuthCode.DeleteByID(code.ID); err != nil {
+		if err == models.ErrOAuthCodeNotFound {
+			return TokenResp{}, ErrInvalidGrant
+		}
+
+		s.Logger.Error("failed to delete authorization code", "err", err)
+		return TokenResp{}, err
+	}
+
+	if code.ExpiresAt.Before(time.Now()) {
+		return TokenResp{}, ErrInvalidGrant
+	}
+
+	if code.RedirectURI != req.RedirectURI || !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier) {
+		return TokenResp{}, ErrInvalidGrant
+	}
+
+	user, err := s.Storage.User.GetByID(code.UserID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return TokenResp{}, ErrInvalidGrant
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return TokenResp{}, err
+	}
+
+	if user.State != models.UserStateActive {
+		return TokenResp{}, ErrInvalidGrant
+	}
+
+	resp := TokenResp{
+		TokenType: "Bearer",
+		ExpiresIn: int64(s.Cfg.AccessTokenTTL.Seconds()),
+		Scope:     strings.Join(code.Scopes, " "),
+	}
+
+	// a refresh token branch is opened only for offline access
+	var sessionID uuid.NullUUID
+	if slices.Contains(code.Scopes, "offline_access") && slices.Contains(client.GrantTypes, GrantTypeRefreshToken) {
+		refreshSecret, refreshTokenHash, err := generateSelectorSecret()
+		if err != nil {
+			s.Logger.Error("failed to generate refresh token", "err", err)
+			return TokenResp{}, err
+		}
+
+		refreshToken := &models.Token{
+			UserID:    user.ID,
+			Hash:      refreshTokenHash,
+			Branch:    uuid.New(),
+			Scope:     models.TokenScopeRefresh,
+			Status:    models.TokenStatusActive,
+			UserAgent: req.Client.UserAgent,
+			IP:        req.Client.IP,
+			ClientID:  uuid.NullUUID{UUID: client.ID, Valid: true},
+			Scopes:    code.Scopes,
+			ExpiresAt: time.Now().Add(s.Cfg.RefreshTokenTTL),
+		}
+
+		if err := s.Storage.Token.Insert(refreshToken); err != nil {
+			s.Logger.Error("failed to save refresh token", "err", err)
+			return TokenResp{}, err
+		}
+
+		sessionID = uuid.NullUUID{UUID: refreshToken.Branch, Valid: true}
+		resp.RefreshToken = formatSelectorToken(refreshToken.ID, refreshSecret)
+	}
+
+	resp.AccessToken, err = generateClientAccessToken(user.ID.String(), client.ID, sessionID, code.Scopes, s.Cfg.AccessTokenTTL, s.Keys)
+	if err != nil {
+		s.Logger.Error("failed to generate access token", "err", err)
+		return TokenResp{}, err
+	}
+
+	if slices.Contains(code.Scopes, "openid") {
+		resp.IDToken, err = s.generateIDToken(user, client.ID, code.Nonce, code.Scopes)
+		if err != nil {
+			s.Logger.Error("failed to generate id token", "err", err)
+			return TokenResp{}, err
+		}
+	}
+
+	return resp, nil
+}
+
+// refreshClientToken rotates the refresh token like Refresh does for our
+// own apps. The new access token may be narrowed to fewer scopes.
+func (s *Service) refreshClientToken(client *models.OAuthClient, req *TokenReq) (TokenResp, error) {
+	token, newRefreshToken, err := s.rotateRefreshToken(req.RefreshToken, uuid.NullUUID{UUID: client.ID, Valid: true}, req.Client)
+	if err != nil {
+		switch err {
+		case ErrInvalidRefreshToken, ErrRefreshTokenExpired, ErrUserNotFound, ErrUserSuspended:
+			return TokenResp{}, ErrInvalidGrant
+		default:
+			return TokenResp{}, err
+		}
+	}
+
+	scopes := token.Scopes
+	if req.Scope != "" {
+		scopes = strings.Fields(req.Scope)
+		if !isSubset(scopes, token.Scopes) {
+			return TokenResp{}, ErrInvalidScope
+		}
+	}
+
+	accessToken, err := generateClientAccessToken(token.UserID.String(), client.ID, uuid.NullUUID{UUID: token.Branch, Valid: true}, scopes, s.Cfg.AccessTokenTTL, s.Keys)
+	if err != nil {
+		s.Logger.Error("failed to generate access token", "err", err)
+		return TokenResp{}, err
+	}
+
+	return TokenResp{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.Cfg.AccessTokenTTL.Seconds()),
+		RefreshToken: newRefreshToken,
+		Scope:        strings.Join(scopes, " "),
+	}, nil
+}
+
+// issueClientCredentialsToken issues a token to the client acting for itself.
+func (s *Service) issueClientCredentialsToken(client *models.OAuthClient, req *TokenReq) (TokenResp, error) {
+	scopes := strings.Fields(req.Scope)
+	if !isSubset(scopes, client.Scopes) {
+		return TokenResp{}, ErrInvalidScope
+	}
+
+	for _, scope := range scopes {
+		if slices.Contains(userOnlyScopes, scope) {
+			return TokenResp{}, ErrInvalidScope
+		}
+	}
+
+	accessToken, err := generateClientAccessToken(client.ID.String(), client.ID, uuid.NullUUID{}, scopes, s.Cfg.AccessTokenTTL, s.Keys)
+	if err != nil {
+		s.Logger.Error("failed to generate access token", "err", err)
+		return TokenResp{}, err
+	}
+
+	return TokenResp{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.Cfg.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// OAuthEnabled tells whether the authorization server is served. ID tokens
+// are signed with our key, and clients can only verify them against the
+// JWKS, so HS256 would leave them with tokens nobody outside can check.
+func (s *Service) OAuthEnabled() bool {
+	return !s.Keys.Symmetric()
+}
+
+func (s *Service) generateIDToken(user *models.User, clientID uuid.UUID, nonce string, scopes []string) (string, error) {
+	if !s.OAuthEnabled() {
+		return "", ErrOAuthDisabled
+	}
+
+	claims := jwt.MapClaims{
+		"iss": s.Cfg.OAuthIssuer,
+		"sub": user.ID,
+		"aud": clientID,
+		"exp": time.Now().Add(s.Cfg.AccessTokenTTL).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	for key, value := range userInfoClaims(user, scopes) {
+		claims[key] = value
+	}
+
+	return s.Keys.Sign(claims)
+}
+
+// AuthorizeClient validates an access token issued to a partner app.
+func (s *Service) AuthorizeClient(accessToken string) (*ClientClaims, error) {
+	token, err := jwt.Parse(accessToken, s.Keys.Keyfunc)
+	if err != nil {
+		if validationErr, ok := err.(*jwt.ValidationError); ok && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrAccessTokenExpired
+		}
+
+		return nil, ErrInvalidAccessToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidAccessToken
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	tokenID, ok := claims["jti"].(string)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	issuedAt, ok := claims["iat"].(float64)
+	if !ok {
+		return nil, ErrInvalidAccessToken
+	}
+
+	clientIDString, _ := claims["client_id"].(string)
+	clientID, err := uuid.Parse(clientIDString)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	result := &ClientClaims{
+		TokenID:   tokenID,
+		ClientID:  clientID,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}
+
+	scope, _ := claims["scope"].(string)
+	result.Scopes = strings.Fields(scope)
+
+	// the subject is the client itself for the client credentials grant
+	subject, _ := claims["sub"].(string)
+	if subject != clientIDString {
+		userID, err := uuid.Parse(subject)
+		if err != nil {
+			return nil, ErrInvalidAccessToken
+		}
+
+		result.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	if sid, ok := claims["sid"].(string); ok {
+		sessionID, err := uuid.Parse(sid)
+		if err != nil {
+			return nil, ErrInvalidAccessToken
+		}
+
+		result.SessionID = uuid.NullUUID{UUID: sessionID, Valid: true}
+	}
+
+	if err := s.checkAccessTokenRevoked(tokenID, result.UserID, result.SessionID, time.Unix(int64(issuedAt), 0)); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UserInfo returns the claims about the user the token was issued for,
+// limited to the granted scopes.
+func (s *Service) UserInfo(accessToken string) (map[string]any, error) {
+	claims, err := s.AuthorizeClient(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.UserID.Valid || !slices.Contains(claims.Scopes, "openid") {
+		return nil, ErrInvalidAccessToken
+	}
+
+	user, err := s.Storage.User.GetByID(claims.UserID.UUID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return nil, ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return nil, err
+	}
+
+	if user.State != models.UserStateActive {
+		return nil, ErrUserNotFound
+	}
+
+	info := userInfoClaims(user, claims.Scopes)
+	info["sub"] = user.ID
+
+	return info, nil
+}
+
+func (s *Service) Discovery() Discovery {
+	scopes := make([]string, 0, len(OAuthScopes))
+	for scope := range OAuthScopes {
+		scopes = append(scopes, scope)
+	}
+	slices.Sort(scopes)
+
+	return Discovery{
+		Issuer:                s.Cfg.OAuthIssuer,
+		AuthorizationEndpoint: s.Cfg.OAuthIssuer + "/oauth/authorize",
+		TokenEndpoint:         s.Cfg.OAuthIssuer + "/oauth/token",
+		UserInfoEndpoint:      s.Cfg.OAuthIssuer + "/oauth/userinfo",
+		IntrospectionEndpoint: s.Cfg.OAuthIssuer + "/oauth/introspect",
+		RevocationEndpoint:    s.Cfg.OAuthIssuer + "/oauth/revoke",
+		JWKSURI:               s.Cfg.OAuthIssuer + "/.well-known/jwks.json",
+
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.Keys.Algorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email", "email_verified", "name", "given_name", "family_name"},
+
+		AuthorizationResponseIssParameterSupported: true,
+	}
+}
+
+func userInfoClaims(user *models.User, scopes []string) map[string]any {
+	claims := make(map[string]any)
+
+	if slices.Contains(scopes, "email") {
+		claims["email"] = user.Email
+		// only confirmed users can log in
+		claims["email_verified"] = true
+	}
+
+	if slices.Contains(scopes, "profile") {
+		claims["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+	}
+
+	return claims
+}
+
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
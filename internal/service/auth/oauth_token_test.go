@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+func TestIDTokenSymmetricKey(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+
+	if s.OAuthEnabled() {
+		t.Fatal("authorization server enabled with HS256")
+	}
+
+	if _, err := s.generateIDToken(user, uuid.New(), "", []string{"openid"}); err != ErrOAuthDisabled {
+		t.Errorf("got %v, want %v", err, ErrOAuthDisabled)
+	}
+}
+
+func TestIDTokenAsymmetricKey(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Cfg.JWTAlgorithm = "EdDSA"
+	s.Cfg.JWTSigningKeyFile = keyFile
+
+	if s.Keys, err = NewKeySet(s.Cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.OAuthEnabled() {
+		t.Fatal("authorization server disabled with EdDSA")
+	}
+
+	clientID := uuid.New()
+
+	idToken, err := s.generateIDToken(user, clientID, "nonce", []string{"openid"})
+	if err != nil {
+		t.Fatalf("generate id token: %v", err)
+	}
+
+	// clients verify the token against the published keys only
+	if len(s.Keys.JWKS().Keys) != 1 {
+		t.Fatalf("got %d published keys, want 1", len(s.Keys.JWKS().Keys))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(idToken, claims, s.Keys.Keyfunc); err != nil {
+		t.Fatalf("verify id token: %v", err)
+	}
+
+	if claims["aud"] != clientID.String() || claims["nonce"] != "nonce" {
+		t.Errorf("got claims %v", claims)
+	}
+}
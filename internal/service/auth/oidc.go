@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/oidc"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// login through an external provider is two requests long: the begin call
+// stores the nonce and the PKCE verifier and returns the provider url with
+// the state <state_id>.<secret>, the callback presents the state back
+// together with the authorization code.
+
+type BeginOIDCLoginResp struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+type FinishOIDCLoginReq struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Code     string `json:"code"`
+
+	Client ClientInfo `json:"-"`
+}
+
+func (s *Service) BeginOIDCLogin(providerName string) (BeginOIDCLoginResp, error) {
+	provider, ok := s.OIDC[providerName]
+	if !ok {
+		return BeginOIDCLoginResp{}, ErrUnknownProvider
+	}
+
+	secret, hash, err := generateSelectorSecret()
+	if err != nil {
+		s.Logger.Error("failed to generate oidc state", "err", err)
+		return BeginOIDCLoginResp{}, err
+	}
+
+	nonce, err := generateCode(32)
+	if err != nil {
+		s.Logger.Error("failed to generate oidc nonce", "err", err)
+		return BeginOIDCLoginResp{}, err
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		s.Logger.Error("failed to generate pkce verifier", "err", err)
+		return BeginOIDCLoginResp{}, err
+	}
+
+	state := &models.OIDCState{
+		Provider:  providerName,
+		Hash:      hash,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(s.Cfg.OIDCStateTTL),
+	}
+
+	if err := s.Storage.OIDCState.Insert(state); err != nil {
+		s.Logger.Error("failed to save oidc state", "err", err)
+		return BeginOIDCLoginResp{}, err
+	}
+
+	stateToken := formatSelectorToken(state.ID, secret)
+
+	url, err := provider.AuthCodeURL(stateToken, nonce, verifier)
+	if err != nil {
+		s.Logger.Error("failed to build provider url", "provider", providerName, "err", err)
+		return BeginOIDCLoginResp{}, err
+	}
+
+	return BeginOIDCLoginResp{
+		URL:   url,
+		State: stateToken,
+	}, nil
+}
+
+// FinishOIDCLogin exchanges the authorization code and logs in the user the
+// external identity belongs to. Unknown identities are linked to the active
+// user with the same email or get a new account, both only if the provider
+// verified the email.
+func (s *Service) FinishOIDCLogin(req *FinishOIDCLoginReq) (LoginResp, error) {
+	provider, ok := s.OIDC[req.Provider]
+	if !ok {
+		return LoginResp{}, ErrUnknownProvider
+	}
+
+	state, err := s.finishOIDCState(req.State)
+	if err != nil {
+		return LoginResp{}, err
+	}
+
+	if state.Provider != req.Provider {
+		return LoginResp{}, ErrInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(req.Code, state.Verifier, state.Nonce)
+	if err != nil {
+		s.Logger.Info("oidc login rejected", "provider", req.Provider, "err", err)
+		return LoginResp{}, ErrOIDCLoginFailed
+	}
+
+	user, err := s.userByIdentity(req.Provider, claims)
+	if err != nil {
+		return LoginResp{}, err
+	}
+
+	if err := userStateError(user.State); err != nil {
+		return LoginResp{}, err
+	}
+
+	return s.completeLogin(user.ID, req.Client)
+}
+
+// finishOIDCState burns the state, so every authorization response is
+// accepted at most once.
+func (s *Service) finishOIDCState(stateToken string) (*models.OIDCState, error) {
+	stateID, secret, err := parseSelectorToken(stateToken)
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	state, err := s.Storage.OIDCState.GetByID(stateID)
+	if err != nil {
+		if err == models.ErrOIDCStateNotFound {
+			return nil, ErrInvalidOIDCState
+		}
+
+		s.Logger.Error("failed to get oidc state", "err", err)
+		return nil, err
+	}
+
+	if !verifySelectorSecret(state.Hash, secret) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	if err := s.Storage.OIDCState.DeleteByID(state.ID); err != nil {
+		if err == models.ErrOIDCStateNotFound {
+			return nil, ErrInvalidOIDCState
+		}
+
+		s.Logger.Error("failed to delete oidc state", "err", err)
+		return nil, err
+	}
+
+	if state.ExpiresAt.Before(time.Now()) {
+		return nil, ErrOIDCStateExpired
+	}
+
+	return state, nil
+}
+
+func (s *Service) userByIdentity(providerName string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.Storage.Identity.Get(providerName, claims.Subject)
+	if err != nil && err != models.ErrIdentityNotFound {
+		s.Logger.Error("failed to get identity", "err", err)
+		return nil, err
+	}
+
+	if identity != nil {
+		user, err := s.Storage.User.GetByID(identity.UserID)
+		if err != nil {
+			if err == models.ErrUserNotFound {
+				return nil, ErrUserNotFound
+			}
+
+			s.Logger.Error("failed to get user by id", "err", err)
+			return nil, err
+		}
+
+		return user, nil
+	}
+
+	if claims.Email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	// the email is trusted only if the provider proved it belongs to the
+	// same person, otherwise anyone could take over or squat the address
+	if !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.Storage.User.GetByEmail(claims.Email)
+	if err != nil && err != models.ErrUserNotFound {
+		s.Logger.Error("failed to get user by email", "err", err)
+		return nil, err
+	}
+
+	switch {
+	case user == nil:
+		if user, err = s.registerIdentityUser(claims); err != nil {
+			return nil, err
+		}
+	case user.State != models.UserStateActive:
+		// pending registrations are confirmed or expire on their own,
+		// suspended and deleted accounts keep the email
+		return nil, ErrUserAlreadyExists
+	}
+
+	identity = &models.Identity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		UserID:   user.ID,
+		Email:    claims.Email,
+	}
+
+	if err := s.Storage.Identity.Insert(identity); err != nil {
+		s.Logger.Error("failed to save identity", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// registerIdentityUser creates an active user without a password, the
+// provider already verified the email.
+func (s *Service) registerIdentityUser(claims *oidc.Claims) (*models.User, error) {
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+
+	user := &models.User{
+		Email:        claims.Email,
+		PasswordHash: []byte{},
+		FirstName:    firstName,
+		LastName:     lastName,
+	}
+
+	if err := s.Storage.User.Insert(user); err != nil {
+		if err == models.ErrDuplicateEmail {
+			return nil, ErrUserAlreadyExists
+		}
+
+		s.Logger.Error("failed to save user", "err", err)
+		return nil, err
+	}
+
+	if err := s.Storage.Role.Grant(user.ID, models.RoleMember, uuid.NullUUID{}); err != nil {
+		s.Logger.Error("failed to grant member role", "err", err)
+		return nil, err
+	}
+
+	if err := s.Storage.User.UpdateStatus(user.ID, models.UserStateActive); err != nil {
+		s.Logger.Error("failed to activate user", "err", err)
+		return nil, err
+	}
+
+	user.State = models.UserStateActive
+	return user, nil
+}
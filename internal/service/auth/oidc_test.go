@@ -0,0 +1,481 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+	"github.com/MartynyukAlexey/gymshark/internal/oidc"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+const (
+	idpClientID     = "gymshark"
+	idpClientSecret = "idp secret"
+)
+
+// fakeIdP is an OpenID Connect provider serving discovery, JWKS and the
+// token endpoint. The authorization endpoint is skipped: the test grants
+// codes directly with authorize.
+type fakeIdP struct {
+	*httptest.Server
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*idpCode
+}
+
+type idpCode struct {
+	challenge string
+	idToken   string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+
+	idp := &fakeIdP{
+		key:   newRSAKey(t),
+		codes: make(map[string]*idpCode),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "idp",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != idpClientID || secret != url.QueryEscape(idpClientSecret) {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+
+		idp.mu.Lock()
+		code, ok := idp.codes[r.PostFormValue("code")]
+		delete(idp.codes, r.PostFormValue("code"))
+		idp.mu.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != code.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "unused",
+			"token_type":   "Bearer",
+			"id_token":     code.idToken,
+		})
+	})
+
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+
+	return idp
+}
+
+// authorize plays the user signing in at the provider: it reads the
+// request from the url BeginOIDCLogin returned and grants a code for an ID
+// token with the given claims. forge may change the claims and the signing
+// key before the token is signed.
+func (idp *fakeIdP) authorize(t *testing.T, authURL string, claims jwt.MapClaims, forge func(claims jwt.MapClaims) *rsa.PrivateKey) string {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := u.Query()
+	if query.Get("client_id") != idpClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+
+	token := jwt.MapClaims{
+		"iss":   idp.URL,
+		"aud":   idpClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+
+	for name, value := range claims {
+		token[name] = value
+	}
+
+	key := idp.key
+	if forge != nil {
+		if forged := forge(token); forged != nil {
+			key = forged
+		}
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, token)
+	jwtToken.Header["kid"] = "idp"
+
+	idToken, err := jwtToken.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := generateCode(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp.mu.Lock()
+	idp.codes[code] = &idpCode{challenge: query.Get("code_challenge"), idToken: idToken}
+	idp.mu.Unlock()
+
+	return code
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func newOIDCTestService(t *testing.T, idp *fakeIdP) *Service {
+	t.Helper()
+
+	s, _ := newTestService(t)
+	s.OIDC = oidc.NewProviders(&config.OIDCConfig{
+		Providers: map[string]*config.OIDCProviderConfig{
+			"fake": {
+				Issuer:       idp.URL,
+				ClientID:     idpClientID,
+				ClientSecret: idpClientSecret,
+				RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/fake/callback",
+				Scopes:       []string{"openid", "email", "profile"},
+			},
+		},
+	})
+
+	return s
+}
+
+// oidcLogin runs the whole login with the given ID token claims.
+func oidcLogin(t *testing.T, s *Service, idp *fakeIdP, claims jwt.MapClaims, forge func(claims jwt.MapClaims) *rsa.PrivateKey) (LoginResp, error) {
+	t.Helper()
+
+	begin, err := s.BeginOIDCLogin("fake")
+	if err != nil {
+		t.Fatalf("begin oidc login: %v", err)
+	}
+
+	return s.FinishOIDCLogin(&FinishOIDCLoginReq{
+		Provider: "fake",
+		State:    begin.State,
+		Code:     idp.authorize(t, begin.URL, claims, forge),
+		Client:   testClient,
+	})
+}
+
+func TestOIDCLoginNewAccount(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := newOIDCTestService(t, idp)
+
+	resp, err := oidcLogin(t, s, idp, jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "new@example.com",
+		"email_verified": true,
+		"name":           "Jane Doe",
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("oidc login: %v", err)
+	}
+
+	claims, err := s.Authorize(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+
+	user, err := s.Storage.User.GetByID(claims.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Email != "new@example.com" || user.State != models.UserStateActive || user.FirstName != "Jane" || user.LastName != "Doe" {
+		t.Errorf("got user %+v, want active Jane Doe", user)
+	}
+
+	if len(user.PasswordHash) != 0 {
+		t.Error("account created through a provider has a password")
+	}
+}
+
+func TestOIDCLoginLinksExistingAccount(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := newOIDCTestService(t, idp)
+	user := createUser(t, s, "user@example.com")
+
+	// the provider did not verify the email, the account is not taken over
+	_, err := oidcLogin(t, s, idp, jwt.MapClaims{
+		"sub":   "subject",
+		"email": "user@example.com",
+	}, nil)
+
+	if err != ErrOIDCEmailNotVerified {
+		t.Fatalf("unverified email: got %v, want %v", err, ErrOIDCEmailNotVerified)
+	}
+
+	resp, err := oidcLogin(t, s, idp, jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "user@example.com",
+		"email_verified": "true",
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("oidc login: %v", err)
+	}
+
+	claims, err := s.Authorize(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+
+	if claims.UserID != user.ID {
+		t.Errorf("logged in as %v, want the existing user %v", claims.UserID, user.ID)
+	}
+
+	// the identity is linked now, the email at the provider does not matter
+	resp, err = oidcLogin(t, s, idp, jwt.MapClaims{
+		"sub":   "subject",
+		"email": "changed@example.com",
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("login with the linked identity: %v", err)
+	}
+
+	if claims, err = s.Authorize(resp.AccessToken); err != nil || claims.UserID != user.ID {
+		t.Errorf("logged in as %v (%v), want the existing user %v", claims, err, user.ID)
+	}
+}
+
+func TestOIDCLoginUnverifiedEmail(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := newOIDCTestService(t, idp)
+
+	_, err := oidcLogin(t, s, idp, jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "new@example.com",
+		"email_verified": false,
+	}, nil)
+
+	if err != ErrOIDCEmailNotVerified {
+		t.Fatalf("got %v, want %v", err, ErrOIDCEmailNotVerified)
+	}
+
+	if _, err := s.Storage.User.GetByEmail("new@example.com"); err != models.ErrUserNotFound {
+		t.Errorf("account created for an unverified email: %v", err)
+	}
+
+	if _, err := s.Storage.Identity.Get("fake", "subject"); err != models.ErrIdentityNotFound {
+		t.Errorf("identity linked for an unverified email: %v", err)
+	}
+}
+
+func TestOIDCLoginKeepsPendingRegistration(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := newOIDCTestService(t, idp)
+	user := createUser(t, s, "user@example.com")
+	s.Storage.User.UpdateStatus(user.ID, models.UserStatePending)
+
+	_, err := oidcLogin(t, s, idp, jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "user@example.com",
+		"email_verified": true,
+	}, nil)
+
+	if err != ErrUserAlreadyExists {
+		t.Fatalf("got %v, want %v", err, ErrUserAlreadyExists)
+	}
+
+	kept, err := s.Storage.User.GetByEmail("user@example.com")
+	if err != nil {
+		t.Fatalf("pending registration deleted: %v", err)
+	}
+
+	if kept.ID != user.ID || kept.State != models.UserStatePending {
+		t.Errorf("got user %v in state %s, want the pending registration %v kept", kept.ID, kept.State, user.ID)
+	}
+
+	if _, err := s.Storage.Identity.Get("fake", "subject"); err != models.ErrIdentityNotFound {
+		t.Errorf("identity linked to a pending registration: %v", err)
+	}
+}
+
+func TestOIDCLoginRejected(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := newOIDCTestService(t, idp)
+	createUser(t, s, "user@example.com")
+
+	otherKey := newRSAKey(t)
+
+	tests := []struct {
+		name  string
+		forge func(claims jwt.MapClaims) *rsa.PrivateKey
+	}{
+		{
+			name: "nonce mismatch",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				claims["nonce"] = "another nonce"
+				return nil
+			},
+		},
+		{
+			name: "no nonce",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				delete(claims, "nonce")
+				return nil
+			},
+		},
+		{
+			name: "bad signature",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				return otherKey
+			},
+		},
+		{
+			name: "wrong audience",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				claims["aud"] = "another client"
+				return nil
+			},
+		},
+		{
+			name: "issued to another party",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				claims["aud"] = []string{idpClientID, "another client"}
+				claims["azp"] = "another client"
+				return nil
+			},
+		},
+		{
+			name: "wrong issuer",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				claims["iss"] = "https://evil.example"
+				return nil
+			},
+		},
+		{
+			name: "expired",
+			forge: func(claims jwt.MapClaims) *rsa.PrivateKey {
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := oidcLogin(t, s, idp, jwt.MapClaims{
+				"sub":            "subject",
+				"email":          "user@example.com",
+				"email_verified": true,
+			}, tt.forge)
+
+			if err != ErrOIDCLoginFailed {
+				t.Errorf("got %v, want %v", err, ErrOIDCLoginFailed)
+			}
+		})
+	}
+
+	if _, err := s.Storage.Identity.Get("fake", "subject"); err != models.ErrIdentityNotFound {
+		t.Errorf("identity linked by a rejected login: %v", err)
+	}
+}
+
+func TestOIDCLoginState(t *testing.T) {
+	idp := newFakeIdP(t)
+	s := newOIDCTestService(t, idp)
+
+	claims := jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+
+	begin, err := s.BeginOIDCLogin("fake")
+	if err != nil {
+		t.Fatalf("begin oidc login: %v", err)
+	}
+
+	stateID, _, _ := parseSelectorToken(begin.State)
+
+	// the state of the callback does not match the stored one
+	if _, err := s.FinishOIDCLogin(&FinishOIDCLoginReq{
+		Provider: "fake",
+		State:    formatSelectorToken(stateID, "forged"),
+		Code:     idp.authorize(t, begin.URL, claims, nil),
+		Client:   testClient,
+	}); err != ErrInvalidOIDCState {
+		t.Errorf("forged state: got %v, want %v", err, ErrInvalidOIDCState)
+	}
+
+	if _, err := s.FinishOIDCLogin(&FinishOIDCLoginReq{
+		Provider: "fake",
+		State:    begin.State,
+		Code:     idp.authorize(t, begin.URL, claims, nil),
+		Client:   testClient,
+	}); err != nil {
+		t.Fatalf("oidc login: %v", err)
+	}
+
+	// the state is burned
+	if _, err := s.FinishOIDCLogin(&FinishOIDCLoginReq{
+		Provider: "fake",
+		State:    begin.State,
+		Code:     idp.authorize(t, begin.URL, claims, nil),
+		Client:   testClient,
+	}); err != ErrInvalidOIDCState {
+		t.Errorf("state reuse: got %v, want %v", err, ErrInvalidOIDCState)
+	}
+
+	if _, err := s.FinishOIDCLogin(&FinishOIDCLoginReq{
+		Provider: "unknown",
+		State:    begin.State,
+		Code:     "code",
+		Client:   testClient,
+	}); err != ErrUnknownProvider {
+		t.Errorf("unknown provider: got %v, want %v", err, ErrUnknownProvider)
+	}
+}
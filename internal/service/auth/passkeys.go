@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+	"github.com/MartynyukAlexey/gymshark/internal/webauthn"
+)
+
+// passkey ceremonies are two requests long: the begin call stores a
+// challenge (a webauthn code row) and returns it with a ceremony token
+// <code_id>.<challenge>, the finish call presents the token back together
+// with the authenticator response.
+
+// reason a suspected clone of an authenticator is logged with
+var errSignCountReplayed = errors.New("passkey sign count did not increase")
+
+type Passkey struct {
+	ID         webauthn.Base64URL `json:"id"`
+	Name       string             `json:"name"`
+	CreatedAt  time.Time          `json:"created_at"`
+	LastUsedAt time.Time          `json:"last_used_at"`
+}
+
+type BeginPasskeyRegistrationResp struct {
+	Ceremony string                   `json:"ceremony"`
+	Options  webauthn.CreationOptions `json:"public_key"`
+}
+
+type FinishPasskeyRegistrationReq struct {
+	Ceremony   string `json:"ceremony"`
+	Name       string `json:"name"`
+	Credential struct {
+		RawID    webauthn.Base64URL           `json:"rawId"`
+		Type     string                       `json:"type"`
+		Response webauthn.AttestationResponse `json:"response"`
+	} `json:"credential"`
+}
+
+type BeginPasskeyLoginReq struct {
+	Email string `json:"email"`
+}
+
+type BeginPasskeyLoginResp struct {
+	Ceremony string                  `json:"ceremony"`
+	Options  webauthn.RequestOptions `json:"public_key"`
+}
+
+type FinishPasskeyLoginReq struct {
+	Ceremony   string `json:"ceremony"`
+	Credential struct {
+		RawID    webauthn.Base64URL         `json:"rawId"`
+		Type     string                     `json:"type"`
+		Response webauthn.AssertionResponse `json:"response"`
+	} `json:"credential"`
+
+	Client ClientInfo `json:"-"`
+}
+
+func (s *Service) BeginPasskeyRegistration(userID uuid.UUID) (BeginPasskeyRegistrationResp, error) {
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return BeginPasskeyRegistrationResp{}, ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return BeginPasskeyRegistrationResp{}, err
+	}
+
+	passkeys, err := s.Storage.Passkey.GetAllByUser(userID)
+	if err != nil {
+		s.Logger.Error("failed to get passkeys", "err", err)
+		return BeginPasskeyRegistrationResp{}, err
+	}
+
+	ceremony, challenge, err := s.startCeremony(userID)
+	if err != nil {
+		return BeginPasskeyRegistrationResp{}, err
+	}
+
+	// authenticators that already hold a passkey for the user refuse to create another one
+	exclude := make([][]byte, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		exclude = append(exclude, passkey.ID)
+	}
+
+	return BeginPasskeyRegistrationResp{
+		Ceremony: ceremony,
+		Options: s.webAuthn().NewCreationOptions(challenge, webauthn.User{
+			ID:          user.ID[:],
+			Name:        user.Email,
+			DisplayName: user.FirstName + " " + user.LastName,
+		}, exclude),
+	}, nil
+}
+
+func (s *Service) FinishPasskeyRegistration(userID uuid.UUID, req *FinishPasskeyRegistrationReq) (Passkey, error) {
+	if len(req.Name) == 0 {
+		req.Name = "Passkey"
+	}
+
+	code, challenge, err := s.finishCeremony(req.Ceremony)
+	if err != nil {
+		return Passkey{}, err
+	}
+
+	if code.UserID != userID {
+		return Passkey{}, ErrInvalidCeremony
+	}
+
+	registration, err := s.webAuthn().VerifyRegistration(&req.Credential.Response, challenge)
+	if err != nil {
+		s.Logger.Info("passkey registration rejected", "user_id", userID, "err", err)
+		return Passkey{}, ErrInvalidPasskey
+	}
+
+	passkey := &models.Passkey{
+		ID:        registration.CredentialID,
+		UserID:    userID,
+		Name:      req.Name,
+		PublicKey: registration.PublicKey,
+		SignCount: registration.SignCount,
+	}
+
+	if err := s.Storage.Passkey.Insert(passkey); err != nil {
+		if err == models.ErrDuplicatePasskey {
+			return Passkey{}, ErrPasskeyRegistered
+		}
+
+		s.Logger.Error("failed to save passkey", "err", err)
+		return Passkey{}, err
+	}
+
+	return Passkey{
+		ID:         passkey.ID,
+		Name:       passkey.Name,
+		CreatedAt:  passkey.CreatedAt,
+		LastUsedAt: passkey.LastUsedAt,
+	}, nil
+}
+
+func (s *Service) BeginPasskeyLogin(req *BeginPasskeyLoginReq) (BeginPasskeyLoginResp, error) {
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return BeginPasskeyLoginResp{}, ErrInvalidEmail
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return BeginPasskeyLoginResp{}, ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return BeginPasskeyLoginResp{}, err
+	}
+
+	if err := userStateError(user.State); err != nil {
+		return BeginPasskeyLoginResp{}, err
+	}
+
+	passkeys, err := s.Storage.Passkey.GetAllByUser(user.ID)
+	if err != nil {
+		s.Logger.Error("failed to get passkeys", "err", err)
+		return BeginPasskeyLoginResp{}, err
+	}
+
+	if len(passkeys) == 0 {
+		return BeginPasskeyLoginResp{}, ErrPasskeyNotFound
+	}
+
+	ceremony, challenge, err := s.startCeremony(user.ID)
+	if err != nil {
+		return BeginPasskeyLoginResp{}, err
+	}
+
+	allow := make([][]byte, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		allow = append(allow, passkey.ID)
+	}
+
+	return BeginPasskeyLoginResp{
+		Ceremony: ceremony,
+		Options:  s.webAuthn().NewRequestOptions(challenge, allow),
+	}, nil
+}
+
+// FinishPasskeyLogin verifies the assertion and issues the same token pair as Login.
+func (s *Service) FinishPasskeyLogin(req *FinishPasskeyLoginReq) (LoginResp, error) {
+	code, challenge, err := s.finishCeremony(req.Ceremony)
+	if err != nil {
+		if err == ErrInvalidCeremony || err == ErrCeremonyExpired {
+			s.recordAuthEvent(models.AuthEventLogin, uuid.Nil, req.Client, err)
+		}
+
+		return LoginResp{}, err
+	}
+
+	passkey, err := s.Storage.Passkey.GetByID(req.Credential.RawID)
+	if err != nil {
+		if err == models.ErrPasskeyNotFound {
+			s.recordAuthEvent(models.AuthEventLogin, code.UserID, req.Client, ErrInvalidPasskey)
+			return LoginResp{}, ErrInvalidPasskey
+		}
+
+		s.Logger.Error("failed to get passkey", "err", err)
+		return LoginResp{}, err
+	}
+
+	if passkey.UserID != code.UserID {
+		s.recordAuthEvent(models.AuthEventLogin, code.UserID, req.Client, ErrInvalidPasskey)
+		return LoginResp{}, ErrInvalidPasskey
+	}
+
+	// discoverable credentials report the user they were created for
+	userHandle := req.Credential.Response.UserHandle
+	if len(userHandle) != 0 && !bytes.Equal(userHandle, passkey.UserID[:]) {
+		s.recordAuthEvent(models.AuthEventLogin, passkey.UserID, req.Client, ErrInvalidPasskey)
+		return LoginResp{}, ErrInvalidPasskey
+	}
+
+	signCount, err := s.webAuthn().VerifyAssertion(&req.Credential.Response, challenge, passkey.PublicKey)
+	if err != nil {
+		s.Logger.Info("passkey assertion rejected", "user_id", passkey.UserID, "err", err)
+		s.recordAuthEvent(models.AuthEventLogin, passkey.UserID, req.Client, err)
+		return LoginResp{}, ErrInvalidPasskey
+	}
+
+	// authenticators that keep a counter must increase it on every use,
+	// otherwise the credential was probably cloned
+	if (signCount != 0 || passkey.SignCount != 0) && signCount <= passkey.SignCount {
+		s.Logger.Warn("passkey sign count did not increase", "user_id", passkey.UserID, "stored", passkey.SignCount, "received", signCount)
+		s.recordAuthEvent(models.AuthEventLogin, passkey.UserID, req.Client, errSignCountReplayed)
+		return LoginResp{}, ErrInvalidPasskey
+	}
+
+	if err := s.Storage.Passkey.UpdateSignCount(passkey.ID, signCount); err != nil {
+		s.Logger.Error("failed to update passkey sign count", "err", err)
+		return LoginResp{}, err
+	}
+
+	// startSession refuses accounts that are not active
+	return s.startSession(passkey.UserID, req.Client)
+}
+
+func (s *Service) Passkeys(userID uuid.UUID) ([]Passkey, error) {
+	passkeys, err := s.Storage.Passkey.GetAllByUser(userID)
+	if err != nil {
+		s.Logger.Error("failed to get passkeys", "err", err)
+		return nil, err
+	}
+
+	result := make([]Passkey, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		result = append(result, Passkey{
+			ID:         passkey.ID,
+			Name:       passkey.Name,
+			CreatedAt:  passkey.CreatedAt,
+			LastUsedAt: passkey.LastUsedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Service) DeletePasskey(userID uuid.UUID, id []byte) error {
+	if err := s.Storage.Passkey.Delete(userID, id); err != nil {
+		if err == models.ErrPasskeyNotFound {
+			return ErrPasskeyNotFound
+		}
+
+		s.Logger.Error("failed to delete passkey", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) startCeremony(userID uuid.UUID) (string, []byte, error) {
+	secret, hash, err := generateSelectorSecret()
+	if err != nil {
+		s.Logger.Error("failed to generate webauthn challenge", "err", err)
+		return "", nil, err
+	}
+
+	code := &models.Code{
+		UserID:    userID,
+		Hash:      hash,
+		Scope:     models.CodeScopeWebAuthn,
+		ExpiresAt: time.Now().Add(s.Cfg.WebAuthnCeremonyTTL),
+	}
+
+	if err := s.Storage.Code.Insert(code); err != nil {
+		s.Logger.Error("failed to save webauthn challenge", "err", err)
+		return "", nil, err
+	}
+
+	return formatSelectorToken(code.ID, secret), []byte(secret), nil
+}
+
+// finishCeremony burns the challenge of the ceremony, so every challenge
+// is verified at most once.
+func (s *Service) finishCeremony(ceremony string) (*models.Code, []byte, error) {
+	codeID, secret, err := parseSelectorToken(ceremony)
+	if err != nil {
+		return nil, nil, ErrInvalidCeremony
+	}
+
+	code, err := s.Storage.Code.GetByID(codeID)
+	if err != nil {
+		if err == models.ErrCodeNotFound {
+			return nil, nil, ErrInvalidCeremony
+		}
+
+		s.Logger.Error("failed to get webauthn challenge", "err", err)
+		return nil, nil, err
+	}
+
+	if code.Scope != models.CodeScopeWebAuthn {
+		return nil, nil, ErrInvalidCeremony
+	}
+
+	if !verifySelectorSecret(code.Hash, secret) {
+		return nil, nil, ErrInvalidCeremony
+	}
+
+	// only one of concurrent requests with the same ceremony gets through
+	if err := s.Storage.Code.Consume(code.ID); err != nil {
+		if err == models.ErrCodeNotFound {
+			return nil, nil, ErrInvalidCeremony
+		}
+
+		s.Logger.Error("failed to delete webauthn challenge", "err", err)
+		return nil, nil, err
+	}
+
+	if code.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrCeremonyExpired
+	}
+
+	return code, []byte(secret), nil
+}
+
+func (s *Service) webAuthn() *webauthn.Config {
+	return &webauthn.Config{
+		RPID:    s.Cfg.WebAuthnRPID,
+		RPName:  s.Cfg.WebAuthnRPName,
+		Origins: s.Cfg.WebAuthnOrigins,
+		Timeout: s.Cfg.WebAuthnCeremonyTTL,
+	}
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/webauthn/webauthntest"
+)
+
+// registerPasskey runs the registration ceremony with a new software
+// authenticator.
+func registerPasskey(t *testing.T, s *Service, userID uuid.UUID) *webauthntest.Authenticator {
+	t.Helper()
+
+	authenticator := webauthntest.NewAuthenticator(s.Cfg.WebAuthnRPID, s.Cfg.WebAuthnOrigins[0])
+
+	begin, err := s.BeginPasskeyRegistration(userID)
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+
+	req := &FinishPasskeyRegistrationReq{Ceremony: begin.Ceremony}
+	req.Credential.RawID = authenticator.CredentialID
+	req.Credential.Type = "public-key"
+	req.Credential.Response = authenticator.Create(begin.Options.Challenge)
+
+	if _, err := s.FinishPasskeyRegistration(userID, req); err != nil {
+		t.Fatalf("finish registration: %v", err)
+	}
+
+	return authenticator
+}
+
+// passkeyLoginReq begins a login and answers it with the authenticator.
+func passkeyLoginReq(t *testing.T, s *Service, email string, authenticator *webauthntest.Authenticator) *FinishPasskeyLoginReq {
+	t.Helper()
+
+	begin, err := s.BeginPasskeyLogin(&BeginPasskeyLoginReq{Email: email})
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+
+	req := &FinishPasskeyLoginReq{Ceremony: begin.Ceremony, Client: testClient}
+	req.Credential.RawID = authenticator.CredentialID
+	req.Credential.Type = "public-key"
+	req.Credential.Response = authenticator.Get(begin.Options.Challenge, nil)
+
+	return req
+}
+
+// ceremonyChallenge is the challenge a ceremony token carries.
+func ceremonyChallenge(ceremony string) []byte {
+	_, challenge, _ := parseSelectorToken(ceremony)
+	return []byte(challenge)
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	authenticator := registerPasskey(t, s, user.ID)
+
+	passkeys, err := s.Passkeys(user.ID)
+	if err != nil || len(passkeys) != 1 {
+		t.Fatalf("got passkeys %v, %v, want the registered one", passkeys, err)
+	}
+
+	req := passkeyLoginReq(t, s, "user@example.com", authenticator)
+
+	resp, err := s.FinishPasskeyLogin(req)
+	if err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+
+	if _, err := s.Authorize(resp.AccessToken); err != nil {
+		t.Errorf("access token: %v", err)
+	}
+
+	// the ceremony is burned
+	if _, err := s.FinishPasskeyLogin(req); err != ErrInvalidCeremony {
+		t.Errorf("ceremony reuse: got %v, want %v", err, ErrInvalidCeremony)
+	}
+
+	// the counter moves on with every login
+	if _, err := s.FinishPasskeyLogin(passkeyLoginReq(t, s, "user@example.com", authenticator)); err != nil {
+		t.Errorf("second login: %v", err)
+	}
+}
+
+func TestPasskeyRegistrationRejected(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+
+	tests := []struct {
+		name  string
+		forge func(a *webauthntest.Authenticator, challenge []byte) []byte
+	}{
+		{
+			name: "wrong origin",
+			forge: func(a *webauthntest.Authenticator, challenge []byte) []byte {
+				a.Origin = "https://evil.example"
+				return challenge
+			},
+		},
+		{
+			name: "wrong challenge",
+			forge: func(a *webauthntest.Authenticator, challenge []byte) []byte {
+				return []byte("another challenge")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(s.Cfg.WebAuthnRPID, s.Cfg.WebAuthnOrigins[0])
+
+			begin, err := s.BeginPasskeyRegistration(user.ID)
+			if err != nil {
+				t.Fatalf("begin registration: %v", err)
+			}
+
+			req := &FinishPasskeyRegistrationReq{Ceremony: begin.Ceremony}
+			req.Credential.RawID = authenticator.CredentialID
+			req.Credential.Response = authenticator.Create(tt.forge(authenticator, begin.Options.Challenge))
+
+			if _, err := s.FinishPasskeyRegistration(user.ID, req); err != ErrInvalidPasskey {
+				t.Errorf("got %v, want %v", err, ErrInvalidPasskey)
+			}
+		})
+	}
+
+	if passkeys, _ := s.Passkeys(user.ID); len(passkeys) != 0 {
+		t.Errorf("%d passkeys registered from rejected ceremonies", len(passkeys))
+	}
+}
+
+func TestPasskeyLoginRejected(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	authenticator := registerPasskey(t, s, user.ID)
+
+	// the stored counter is only checked once it is above zero
+	if _, err := s.FinishPasskeyLogin(passkeyLoginReq(t, s, "user@example.com", authenticator)); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		forge func(req *FinishPasskeyLoginReq)
+	}{
+		{
+			name: "wrong origin",
+			forge: func(req *FinishPasskeyLoginReq) {
+				authenticator.Origin = "https://evil.example"
+				defer func() { authenticator.Origin = s.Cfg.WebAuthnOrigins[0] }()
+
+				req.Credential.Response = authenticator.Get(ceremonyChallenge(req.Ceremony), nil)
+			},
+		},
+		{
+			name: "wrong challenge",
+			forge: func(req *FinishPasskeyLoginReq) {
+				// the response to another ceremony of the same user
+				other := passkeyLoginReq(t, s, "user@example.com", authenticator)
+				req.Credential.Response = other.Credential.Response
+			},
+		},
+		{
+			name: "replayed sign count",
+			forge: func(req *FinishPasskeyLoginReq) {
+				// a cloned authenticator signs with a counter that was already seen
+				passkey, err := s.Storage.Passkey.GetByID(authenticator.CredentialID)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				authenticator.SignCount = passkey.SignCount - 1
+				req.Credential.Response = authenticator.Get(ceremonyChallenge(req.Ceremony), nil)
+			},
+		},
+		{
+			name: "foreign user handle",
+			forge: func(req *FinishPasskeyLoginReq) {
+				other := uuid.New()
+				req.Credential.Response = authenticator.Get(ceremonyChallenge(req.Ceremony), other[:])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := passkeyLoginReq(t, s, "user@example.com", authenticator)
+			tt.forge(req)
+
+			if _, err := s.FinishPasskeyLogin(req); err != ErrInvalidPasskey {
+				t.Errorf("got %v, want %v", err, ErrInvalidPasskey)
+			}
+		})
+	}
+
+	// a genuine login still works afterwards
+	if _, err := s.FinishPasskeyLogin(passkeyLoginReq(t, s, "user@example.com", authenticator)); err != nil {
+		t.Errorf("login: %v", err)
+	}
+}
+
+func TestPasskeyLoginConcurrent(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	authenticator := registerPasskey(t, s, user.ID)
+
+	req := passkeyLoginReq(t, s, "user@example.com", authenticator)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.FinishPasskeyLogin(req)
+		}()
+	}
+
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			succeeded++
+		case ErrInvalidCeremony:
+		default:
+			t.Errorf("finish login: %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("%d logins succeeded with the same ceremony, want 1", succeeded)
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/password"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// PasswordPolicyError lists every rule of the password policy a new
+// password broke.
+type PasswordPolicyError struct {
+	Violations []password.Violation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the policy"
+}
+
+// checkPassword checks a new password against the policy, the error is a
+// *PasswordPolicyError if the password is rejected.
+func (s *Service) checkPassword(pass string, user password.UserInfo) error {
+	violations, err := s.Passwords.Check(pass, user)
+	if err != nil {
+		s.Logger.Error("failed to check password policy", "err", err)
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// ChangePassword sets a new password for a logged in user. Every refresh
+// token branch but the current session is revoked, so the other devices
+// have to log in with the new password.
+func (s *Service) ChangePassword(userID, sessionID uuid.UUID, req *ChangePasswordReq) error {
+	if err := validateChangePasswordReq(req); err != nil {
+		return err
+	}
+
+	if err := s.checkAccountAttempts(userID); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	// accounts created through an external provider have no password,
+	// they can set one with ForgotPassword
+	if len(user.PasswordHash) == 0 {
+		return ErrInvalidPassword
+	}
+
+	if err := s.Hasher.Compare(user.PasswordHash, []byte(req.CurrentPassword)); err != nil {
+		if err != hashing.ErrMismatchedHash {
+			s.Logger.Error("failed to verify password", "err", err)
+			return err
+		}
+
+		s.recordFailedAttempt(user, req.Client.IP)
+		s.recordAuthEvent(models.AuthEventPasswordChange, user.ID, req.Client, ErrInvalidPassword)
+		return ErrInvalidPassword
+	}
+
+	if err := s.checkPassword(req.NewPassword, password.UserInfo{
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}); err != nil {
+		return err
+	}
+
+	passHash, err := s.Hasher.Hash([]byte(req.NewPassword))
+	if err != nil {
+		s.Logger.Error("failed to hash password", "err", err)
+		return err
+	}
+
+	if err := s.Storage.User.UpdatePassword(user.ID, passHash); err != nil {
+		s.Logger.Error("failed to update password", "err", err)
+		return err
+	}
+
+	sessions, err := s.Sessions(user.ID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Storage.Token.DeleteAllByUserExceptBranch(user.ID, sessionID); err != nil {
+		s.Logger.Error("failed to revoke user tokens", "err", err)
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.Current {
+			continue
+		}
+
+		if err := s.revokeSessionAccessTokens(session.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.resetAttempts(user.ID); err != nil {
+		return err
+	}
+
+	s.recordAuthEvent(models.AuthEventPasswordChange, user.ID, req.Client, nil)
+
+	go func() {
+		if err := s.Mailer.SendPasswordChangedEmail(user.Email); err != nil {
+			s.Logger.Error("failed to send password changed email", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+func validateChangePasswordReq(req *ChangePasswordReq) error {
+	if len(req.CurrentPassword) == 0 {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
@@ -3,14 +3,15 @@ package auth
 import (
 	"time"
 
-	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
 )
 
 type RefreshReq struct {
-	Email        string `json:"email"`
-	RefreshToken string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+
+	Client ClientInfo `json:"-"`
 }
 
 type RefreshResp struct {
@@ -23,74 +24,140 @@ func (s *Service) Refresh(req *RefreshReq) (RefreshResp, error) {
 		return RefreshResp{}, err
 	}
 
-	user, err := s.Storage.User.GetByEmail(req.Email)
+	token, newRefreshToken, err := s.rotateRefreshToken(req.RefreshToken, uuid.NullUUID{}, req.Client)
+	if err != nil {
+		return RefreshResp{}, err
+	}
+
+	newAccessToken, err := s.issueAccessToken(token.UserID, token.Branch)
+	if err != nil {
+		return RefreshResp{}, err
+	}
+
+	return RefreshResp{
+		AccessToken:  newAccessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
+}
+
+// rotateRefreshToken replaces the refresh token with a new one in the same
+// branch. The token must belong to clientID, which is null for our own apps.
+// The new token and its selector string are returned.
+func (s *Service) rotateRefreshToken(refreshToken string, clientID uuid.NullUUID, client ClientInfo) (*models.Token, string, error) {
+	token, err := s.verifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if token.ClientID != clientID {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if token.ExpiresAt.Before(time.Now()) {
+		return nil, "", ErrRefreshTokenExpired
+	}
+
+	// an attempt to reuse the token (suspect token leakage).
+	// revoking all tokens in the branch
+	if token.Status != models.TokenStatusActive {
+		s.Logger.Warn("refresh token reuse detected", "user_id", token.UserID, "branch", token.Branch)
+		s.recordAuthEvent(models.AuthEventRefreshReuse, token.UserID, client, ErrRefreshTokenReuse)
+
+		if err := s.Storage.Token.DeleteAllByBranch(token.UserID, token.Branch); err != nil {
+			s.Logger.Error("failed to delete tokens", "err", err)
+		}
+
+		s.revokeSessionAccessTokens(token.Branch)
+
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	user, err := s.Storage.User.GetByID(token.UserID)
 	if err != nil {
 		if err == models.ErrUserNotFound {
-			return RefreshResp{}, ErrUserNotFound
+			return nil, "", ErrUserNotFound
 		}
 
-		s.Logger.Error("failed to get user by email", "err", err)
-		return RefreshResp{}, err
+		s.Logger.Error("failed to get user by id", "err", err)
+		return nil, "", err
+	}
+
+	if user.State == models.UserStateSuspended {
+		return nil, "", ErrUserSuspended
 	}
 
-	tokens, err := s.Storage.Token.GetAllByUserScope(user.ID, models.TokenScopeRefresh)
+	if user.State != models.UserStateActive {
+		return nil, "", ErrUserNotFound
+	}
+
+	// successful refresh
+	newSecret, newHash, err := generateSelectorSecret()
 	if err != nil {
-		s.Logger.Error("failed to get tokens for user", "err", err)
-		return RefreshResp{}, err
+		s.Logger.Error("failed to generate refresh token", "err", err)
+		return nil, "", err
 	}
 
-	for _, token := range tokens {
-		if err := bcrypt.CompareHashAndPassword(token.Hash, []byte(req.RefreshToken)); err != nil {
-			if err != bcrypt.ErrMismatchedHashAndPassword {
-				s.Logger.Error("failed to verify refrest token", "err", err)
-				return RefreshResp{}, err
-			}
-		} else {
-			if token.ExpiresAt.Before(time.Now()) {
-				return RefreshResp{}, ErrRefreshTokenExpired
-			}
-
-			// an attempt to reuse the token (suspect token leakage).
-			// revoking all tokens in the branch
-			if token.Status != models.TokenStatusActive {
-				if err := s.Storage.Token.DeleteAllByBranch(user.ID, token.Branch); err != nil {
-					s.Logger.Error("failed to delete tokens", "err", err)
-				}
-
-				return RefreshResp{}, ErrInvalidRefreshToken
-			}
-
-			// successful refresh
-			newRefreshToken := uuid.New().String()
-			if err := s.Storage.Token.InsertChild(token.ID, &models.Token{
-				UserID:    user.ID,
-				Hash:      []byte(newRefreshToken),
-				Branch:    token.Branch,
-				Status:    models.TokenStatusActive,
-				Scope:     models.TokenScopeRefresh,
-				CreatedAt: time.Now(),
-				ExpiresAt: time.Now().Add(time.Minute * 30),
-			}); err != nil {
-				s.Logger.Error("failed to insert new child token", "err", err)
-				return RefreshResp{}, err
-			}
-
-			newAccessToken, err := generateAccessToken(user.ID, s.Cfg.AccessTokenTTL, s.Cfg.JWTKey)
-			if err != nil {
-				s.Logger.Error("failed to generate access token", "err", err)
-				return RefreshResp{}, err
-			}
-
-			return RefreshResp{
-				AccessToken:  newAccessToken,
-				RefreshToken: newRefreshToken,
-			}, nil
+	newToken := &models.Token{
+		UserID:    user.ID,
+		Hash:      newHash,
+		Branch:    token.Branch,
+		Status:    models.TokenStatusActive,
+		Scope:     models.TokenScopeRefresh,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ClientID:  token.ClientID,
+		Scopes:    token.Scopes,
+		ExpiresAt: time.Now().Add(s.Cfg.RefreshTokenTTL),
+	}
+
+	if err := s.Storage.Token.InsertChild(token.ID, newToken); err != nil {
+		// the parent was rotated concurrently
+		if err == models.ErrTokenNotFound {
+			return nil, "", ErrInvalidRefreshToken
 		}
+
+		s.Logger.Error("failed to insert new child token", "err", err)
+		return nil, "", err
 	}
 
-	return RefreshResp{}, ErrInvalidRefreshToken
+	s.recordAuthEvent(models.AuthEventRefresh, user.ID, client, nil)
+
+	return newToken, formatSelectorToken(newToken.ID, newSecret), nil
+}
+
+// verifyRefreshToken finds the row selected by the token and checks the secret
+// against its hash. Expiration and status are left to the caller.
+func (s *Service) verifyRefreshToken(refreshToken string) (*models.Token, error) {
+	tokenID, secret, err := parseSelectorToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	token, err := s.Storage.Token.GetByID(tokenID)
+	if err != nil {
+		if err == models.ErrTokenNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+
+		s.Logger.Error("failed to get refresh token", "err", err)
+		return nil, err
+	}
+
+	if token.Scope != models.TokenScopeRefresh {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if !verifySelectorSecret(token.Hash, secret) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return token, nil
 }
 
 func validateRefreshReq(req *RefreshReq) error {
+	if len(req.RefreshToken) == 0 {
+		return ErrInvalidRefreshToken
+	}
+
 	return nil
 }
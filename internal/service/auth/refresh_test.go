@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+func TestRefresh(t *testing.T) {
+	s, _ := newTestService(t)
+	createUser(t, s, "user@example.com")
+
+	session := login(t, s, "user@example.com")
+
+	resp, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	claims, err := s.Authorize(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("refreshed access token: %v", err)
+	}
+
+	sessionClaims, err := s.Authorize(session.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+
+	if claims.SessionID != sessionClaims.SessionID {
+		t.Errorf("refresh moved the token to session %v, want %v", claims.SessionID, sessionClaims.SessionID)
+	}
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: resp.RefreshToken, Client: testClient}); err != nil {
+		t.Errorf("refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshReuse(t *testing.T) {
+	s, db := newTestService(t)
+	createUser(t, s, "user@example.com")
+
+	session := login(t, s, "user@example.com")
+
+	resp, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	// the rotated token is presented again, the whole branch goes
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient}); err != ErrInvalidRefreshToken {
+		t.Fatalf("reuse: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: resp.RefreshToken, Client: testClient}); err != ErrInvalidRefreshToken {
+		t.Errorf("refresh after reuse: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, err := s.Authorize(resp.AccessToken); err != ErrAccessTokenRevoked {
+		t.Errorf("access token after reuse: got %v, want %v", err, ErrAccessTokenRevoked)
+	}
+
+	reused := false
+	for _, event := range db.events {
+		reused = reused || event.Type == models.AuthEventRefreshReuse
+	}
+
+	if !reused {
+		t.Error("reuse was not recorded")
+	}
+}
+
+func TestRefreshAfterRevocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(s *Service, session LoginResp) error
+		want   error
+	}{
+		{
+			name: "session revoked",
+			revoke: func(s *Service, session LoginResp) error {
+				claims, err := s.Authorize(session.AccessToken)
+				if err != nil {
+					return err
+				}
+
+				return s.RevokeSession(claims.UserID, claims.SessionID)
+			},
+			want: ErrInvalidRefreshToken,
+		},
+		{
+			name: "tokens revoked by an admin",
+			revoke: func(s *Service, session LoginResp) error {
+				admin := createUser(t, s, "admin@example.com")
+				s.Storage.Role.Grant(admin.ID, models.RoleSuperadmin, uuid.NullUUID{})
+
+				user, _ := s.Storage.User.GetByEmail("user@example.com")
+				return s.RevokeUserTokens(admin.ID, user.ID)
+			},
+			want: ErrInvalidRefreshToken,
+		},
+		{
+			name: "password reset",
+			revoke: func(s *Service, session LoginResp) error {
+				user, _ := s.Storage.User.GetByEmail("user@example.com")
+
+				code := &models.Code{
+					UserID:    user.ID,
+					Scope:     models.CodeScopeReset,
+					ExpiresAt: time.Now().Add(time.Minute),
+				}
+
+				if err := s.Storage.Code.Insert(code); err != nil {
+					return err
+				}
+
+				return s.Storage.User.ResetPassword(user.ID, code.ID, user.PasswordHash)
+			},
+			want: ErrInvalidRefreshToken,
+		},
+		{
+			name: "user suspended",
+			revoke: func(s *Service, session LoginResp) error {
+				user, _ := s.Storage.User.GetByEmail("user@example.com")
+				return s.Storage.User.Suspend(user.ID, "spam", user.ID, nil)
+			},
+			want: ErrUserSuspended,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			createUser(t, s, "user@example.com")
+
+			session := login(t, s, "user@example.com")
+
+			if err := tt.revoke(s, session); err != nil {
+				t.Fatalf("revoke: %v", err)
+			}
+
+			if _, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient}); err != tt.want {
+				t.Errorf("refresh: got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshExpired(t *testing.T) {
+	s, db := newTestService(t)
+	createUser(t, s, "user@example.com")
+
+	session := login(t, s, "user@example.com")
+
+	db.mu.Lock()
+	for _, token := range db.tokens {
+		token.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	db.mu.Unlock()
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient}); err != ErrRefreshTokenExpired {
+		t.Errorf("refresh: got %v, want %v", err, ErrRefreshTokenExpired)
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/MartynyukAlexey/gymshark/internal/password"
 	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
 )
 
@@ -15,6 +15,8 @@ type RegisterReq struct {
 	Password  string `json:"password"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+
+	Client ClientInfo `json:"-"`
 }
 
 func (s *Service) Register(req *RegisterReq) (uuid.UUID, error) {
@@ -22,7 +24,15 @@ func (s *Service) Register(req *RegisterReq) (uuid.UUID, error) {
 		return uuid.Nil, err
 	}
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err := s.checkPassword(req.Password, password.UserInfo{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	}); err != nil {
+		return uuid.Nil, err
+	}
+
+	passHash, err := s.Hasher.Hash([]byte(req.Password))
 	if err != nil {
 		s.Logger.Error("failed to hash password", "err", err)
 		return uuid.Nil, err
@@ -35,8 +45,9 @@ func (s *Service) Register(req *RegisterReq) (uuid.UUID, error) {
 		LastName:     req.LastName,
 	}
 
-	// remove deleted accounts and accounts from unsuccessfult registrations
-	if err := s.Storage.User.DeleteByEmailIfInactive(req.Email); err != nil {
+	// remove accounts from unsuccessful registrations, deleted accounts keep
+	// the email until they are purged, so that they can still be restored
+	if err := s.Storage.User.DeleteByEmailIfPending(req.Email); err != nil {
 		s.Logger.Error("failed to delete old non-active user", "err", err)
 		return uuid.Nil, err
 	}
@@ -50,37 +61,53 @@ func (s *Service) Register(req *RegisterReq) (uuid.UUID, error) {
 		return uuid.Nil, err
 	}
 
+	if err := s.Storage.Role.Grant(m.ID, models.RoleMember, uuid.NullUUID{}); err != nil {
+		s.Logger.Error("failed to grant member role", "err", err)
+		return uuid.Nil, err
+	}
+
+	if err := s.issueConfirmationCode(m); err != nil {
+		return uuid.Nil, err
+	}
+
+	s.recordAuthEvent(models.AuthEventRegister, m.ID, req.Client, nil)
+
+	return m.ID, nil
+}
+
+// issueConfirmationCode emails the user a new code for Confirm.
+func (s *Service) issueConfirmationCode(user *models.User) error {
 	code, err := generateCode(8)
 	if err != nil {
 		s.Logger.Error("failed to generate activation code", "err", err)
-		return uuid.Nil, err
+		return err
 	}
 
-	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	codeHash, err := s.Hasher.Hash([]byte(code))
 	if err != nil {
 		s.Logger.Error("failed to hash activation code", "err", err)
-		return uuid.Nil, err
+		return err
 	}
 
 	t := &models.Code{
-		UserID:    m.ID,
+		UserID:    user.ID,
 		Hash:      codeHash,
 		Scope:     models.CodeScopeConfirm,
-		ExpiresAt: m.CreatedAt.Add(24 * time.Hour),
+		ExpiresAt: time.Now().Add(24 * time.Hour),
 	}
 
 	if err := s.Storage.Code.Insert(t); err != nil {
 		s.Logger.Error("failed to save activation code", "err", err)
-		return uuid.Nil, err
+		return err
 	}
 
 	go func() {
-		if err := s.Mailer.SendActivationEmail(m.Email, code); err != nil {
+		if err := s.Mailer.SendActivationEmail(user.Email, code); err != nil {
 			s.Logger.Error("failed to send activation email", "err", err)
 		}
 	}()
 
-	return m.ID, nil
+	return nil
 }
 
 func validateRegisterReq(req *RegisterReq) error {
@@ -89,10 +116,6 @@ func validateRegisterReq(req *RegisterReq) error {
 		return ErrInvalidEmail
 	}
 
-	if len(req.Password) < 8 {
-		return ErrInvalidPassword
-	}
-
 	if len(req.FirstName) == 0 || len(req.LastName) == 0 {
 		return ErrInvalidName
 	}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/password"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type ResetPasswordReq struct {
+	Email    string `json:"email"`
+	Code     string `json:"code"`
+	Password string `json:"password"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// ResetPassword sets a new password using a code issued by ForgotPassword.
+// The code is burned on success and every refresh token branch of the user
+// is revoked, so sessions opened with the old password stop working. The
+// three happen in one transaction.
+func (s *Service) ResetPassword(req *ResetPasswordReq) error {
+	if err := validateResetPasswordReq(req); err != nil {
+		return err
+	}
+
+	user, err := s.Storage.User.GetByEmail(req.Email)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrInvalidCode
+		}
+
+		s.Logger.Error("failed to get user by email", "err", err)
+		return err
+	}
+
+	if user.State != models.UserStateActive {
+		return ErrInvalidCode
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(user.ID, models.CodeScopeReset)
+	if err != nil {
+		s.Logger.Error("failed to get reset codes", "err", err)
+		return err
+	}
+
+	for _, code := range codes {
+		if err := s.Hasher.Compare(code.Hash, []byte(req.Code)); err != nil {
+			if err != hashing.ErrMismatchedHash {
+				s.Logger.Error("failed to verify reset code", "err", err)
+				return err
+			}
+
+			continue
+		}
+
+		// code matches
+
+		if code.ExpiresAt.Before(time.Now()) {
+			return ErrCodeExpired
+		}
+
+		if err := s.checkPassword(req.Password, password.UserInfo{
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+		}); err != nil {
+			return err
+		}
+
+		passHash, err := s.Hasher.Hash([]byte(req.Password))
+		if err != nil {
+			s.Logger.Error("failed to hash password", "err", err)
+			return err
+		}
+
+		if err := s.Storage.User.ResetPassword(user.ID, code.ID, passHash); err != nil {
+			// used by a concurrent reset, or expired in the meantime
+			if err == models.ErrCodeNotFound {
+				return ErrInvalidCode
+			}
+
+			s.Logger.Error("failed to reset password", "err", err)
+			return err
+		}
+
+		s.recordAuthEvent(models.AuthEventPasswordReset, user.ID, req.Client, nil)
+		s.recordAuthEvent(models.AuthEventLogoutAll, user.ID, req.Client, nil)
+
+		return s.revokeUserAccessTokens(user.ID)
+	}
+
+	s.recordAuthEvent(models.AuthEventPasswordReset, user.ID, req.Client, ErrInvalidCode)
+	return ErrInvalidCode
+}
+
+func validateResetPasswordReq(req *ResetPasswordReq) error {
+	_, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return ErrInvalidEmail
+	}
+
+	if len(req.Code) == 0 {
+		return ErrInvalidCode
+	}
+
+	if len(req.Password) == 0 {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+const newPassword = "a brand new passphrase"
+
+func TestResetPassword(t *testing.T) {
+	s, db := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	session := login(t, s, "user@example.com")
+
+	insertCode(t, s, user.ID, models.CodeScopeReset, "12345678")
+
+	err := s.ResetPassword(&ResetPasswordReq{
+		Email:    "user@example.com",
+		Code:     "12345678",
+		Password: newPassword,
+		Client:   testClient,
+	})
+
+	if err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if codes := resetCodes(t, s, user.ID); len(codes) != 0 {
+		t.Errorf("%d reset codes kept after the reset", len(codes))
+	}
+
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: session.RefreshToken, Client: testClient}); err == nil {
+		t.Error("a session opened with the old password survived the reset")
+	}
+
+	if _, err := s.Login(&LoginReq{Email: "user@example.com", Password: testPassword, Client: testClient}); err != ErrInvalidPassword {
+		t.Errorf("old password: got %v, want %v", err, ErrInvalidPassword)
+	}
+
+	if _, err := s.Login(&LoginReq{Email: "user@example.com", Password: newPassword, Client: testClient}); err != nil {
+		t.Errorf("new password: %v", err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	recorded := false
+	for _, event := range db.events {
+		if event.Type == models.AuthEventPasswordReset && event.Outcome == models.AuthEventSuccess {
+			recorded = true
+		}
+	}
+
+	if !recorded {
+		t.Error("the reset was not recorded")
+	}
+}
+
+func TestResetPasswordRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, s *Service, user *models.User)
+		code     string
+		password string
+		want     error
+	}{
+		{
+			name: "wrong code",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				insertCode(t, s, user.ID, models.CodeScopeReset, "12345678")
+			},
+			code: "87654321",
+			want: ErrInvalidCode,
+		},
+		{
+			name: "code of another scope",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				insertCode(t, s, user.ID, models.CodeScopeLogin, "12345678")
+			},
+			want: ErrInvalidCode,
+		},
+		{
+			name: "expired code",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				hash, err := s.Hasher.Hash([]byte("12345678"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				s.Storage.Code.Insert(&models.Code{
+					UserID:    user.ID,
+					Hash:      hash,
+					Scope:     models.CodeScopeReset,
+					ExpiresAt: time.Now().Add(-time.Minute),
+				})
+			},
+			want: ErrCodeExpired,
+		},
+		{
+			name: "suspended",
+			setup: func(t *testing.T, s *Service, user *models.User) {
+				insertCode(t, s, user.ID, models.CodeScopeReset, "12345678")
+				s.Storage.User.UpdateStatus(user.ID, models.UserStateSuspended)
+			},
+			want: ErrInvalidCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			user := createUser(t, s, "user@example.com")
+			tt.setup(t, s, user)
+
+			req := &ResetPasswordReq{
+				Email:    "user@example.com",
+				Code:     "12345678",
+				Password: newPassword,
+				Client:   testClient,
+			}
+			if tt.code != "" {
+				req.Code = tt.code
+			}
+
+			if err := s.ResetPassword(req); err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if stored, _ := s.Storage.User.GetByID(user.ID); s.Hasher.Compare(stored.PasswordHash, []byte(testPassword)) != nil {
+				t.Error("the password was changed")
+			}
+		})
+	}
+}
+
+func TestResetPasswordPolicy(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	insertCode(t, s, user.ID, models.CodeScopeReset, "12345678")
+
+	err := s.ResetPassword(&ResetPasswordReq{
+		Email:    "user@example.com",
+		Code:     "12345678",
+		Password: "short",
+		Client:   testClient,
+	})
+
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("got %v, want a policy violation", err)
+	}
+
+	// the user picks another password with the same code
+	if codes := resetCodes(t, s, user.ID); len(codes) != 1 {
+		t.Errorf("got %d reset codes, want the code kept", len(codes))
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// Access tokens are not stored, so they are revoked with a denylist that
+// Authorize consults. An entry names a single token by its jti, or a
+// session or a user, in which case every token of it issued up to the
+// revocation is denied. Suspended users get an entry of their own, so that
+// their tokens are refused with ErrUserSuspended. Entries are kept until
+// the tokens they deny have expired anyway.
+
+func revokedTokenKey(tokenID string) string {
+	return "jti:" + tokenID
+}
+
+func revokedSessionKey(sessionID uuid.UUID) string {
+	return "session:" + sessionID.String()
+}
+
+func revokedUserKey(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+func suspendedUserKey(userID uuid.UUID) string {
+	return "suspended:" + userID.String()
+}
+
+// checkAccessTokenRevoked returns ErrAccessTokenRevoked if the token, its
+// session or its user is on the denylist, ErrUserSuspended if the user was
+// suspended after the token was issued.
+func (s *Service) checkAccessTokenRevoked(tokenID string, userID uuid.NullUUID, sessionID uuid.NullUUID, issuedAt time.Time) error {
+	var keys []string
+
+	// checked first, the suspension is what the user should be told about
+	if userID.Valid {
+		keys = append(keys, suspendedUserKey(userID.UUID))
+	}
+
+	keys = append(keys, revokedTokenKey(tokenID))
+
+	if sessionID.Valid {
+		keys = append(keys, revokedSessionKey(sessionID.UUID))
+	}
+
+	if userID.Valid {
+		keys = append(keys, revokedUserKey(userID.UUID))
+	}
+
+	for _, key := range keys {
+		revoked, err := s.Storage.RevokedToken.Get(key)
+		if err != nil {
+			if err == models.ErrRevokedTokenNotFound {
+				continue
+			}
+
+			s.Logger.Error("failed to get revoked token", "err", err)
+			return err
+		}
+
+		// iat has a precision of seconds, a token issued within the second
+		// of the revocation is denied too
+		if !issuedAt.After(revoked.RevokedAt) {
+			if userID.Valid && key == suspendedUserKey(userID.UUID) {
+				return ErrUserSuspended
+			}
+
+			return ErrAccessTokenRevoked
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) revokeAccessTokens(key string, expiresAt time.Time) error {
+	err := s.Storage.RevokedToken.Upsert(&models.RevokedToken{
+		Key:       key,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	})
+
+	if err != nil {
+		s.Logger.Error("failed to revoke access tokens", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// revokeAccessToken denies a single token until it expires.
+func (s *Service) revokeAccessToken(tokenID string, expiresAt time.Time) error {
+	return s.revokeAccessTokens(revokedTokenKey(tokenID), expiresAt)
+}
+
+// revokeSessionAccessTokens denies the tokens issued so far for the refresh
+// token branch, it is called whenever the branch is deleted.
+func (s *Service) revokeSessionAccessTokens(sessionID uuid.UUID) error {
+	return s.revokeAccessTokens(revokedSessionKey(sessionID), time.Now().Add(s.Cfg.AccessTokenTTL))
+}
+
+// revokeUserAccessTokens denies every token issued so far for the user.
+func (s *Service) revokeUserAccessTokens(userID uuid.UUID) error {
+	return s.revokeAccessTokens(revokedUserKey(userID), time.Now().Add(s.Cfg.AccessTokenTTL))
+}
+
+// revokeSuspendedUserAccessTokens denies every token issued so far for the
+// user with ErrUserSuspended.
+func (s *Service) revokeSuspendedUserAccessTokens(userID uuid.UUID) error {
+	return s.revokeAccessTokens(suspendedUserKey(userID), time.Now().Add(s.Cfg.AccessTokenTTL))
+}
+
+// RevokeUserTokens revokes every session and access token of the user on
+// behalf of an administrator.
+func (s *Service) RevokeUserTokens(actorID uuid.UUID, userID uuid.UUID) error {
+	if err := s.checkUserManagement(actorID, userID); err != nil {
+		return err
+	}
+
+	if err := s.LogoutAll(userID, ClientInfo{}); err != nil {
+		return err
+	}
+
+	s.Logger.Info("user tokens revoked", "user_id", userID, "actor_id", actorID)
+	return nil
+}
+
+// PruneRevokedTokens drops the entries whose tokens have all expired.
+func (s *Service) PruneRevokedTokens() error {
+	if err := s.Storage.RevokedToken.DeleteAllExpired(); err != nil {
+		s.Logger.Error("failed to prune revoked tokens", "err", err)
+		return err
+	}
+
+	return nil
+}
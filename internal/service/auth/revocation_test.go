@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+func authorize(t *testing.T, s *Service, accessToken string) *Claims {
+	t.Helper()
+
+	claims, err := s.Authorize(accessToken)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+
+	return claims
+}
+
+func TestRevokedTokenKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  func(claims *Claims) string
+		want error
+	}{
+		{
+			name: "jti",
+			key:  func(claims *Claims) string { return revokedTokenKey(claims.TokenID) },
+			want: ErrAccessTokenRevoked,
+		},
+		{
+			name: "session",
+			key:  func(claims *Claims) string { return revokedSessionKey(claims.SessionID) },
+			want: ErrAccessTokenRevoked,
+		},
+		{
+			name: "user",
+			key:  func(claims *Claims) string { return revokedUserKey(claims.UserID) },
+			want: ErrAccessTokenRevoked,
+		},
+		{
+			name: "suspended user",
+			key:  func(claims *Claims) string { return suspendedUserKey(claims.UserID) },
+			want: ErrUserSuspended,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			createUser(t, s, "user@example.com")
+
+			accessToken := login(t, s, "user@example.com").AccessToken
+			claims := authorize(t, s, accessToken)
+
+			// an entry from before the token was issued does not deny it
+			err := s.Storage.RevokedToken.Upsert(&models.RevokedToken{
+				Key:       tt.key(claims),
+				RevokedAt: time.Now().Add(-time.Hour),
+				ExpiresAt: time.Now().Add(time.Hour),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Authorize(accessToken); err != nil {
+				t.Fatalf("revoked before the token was issued: %v", err)
+			}
+
+			if err := s.revokeAccessTokens(tt.key(claims), time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Authorize(accessToken); err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevokeSessionAccessTokens(t *testing.T) {
+	s, _ := newTestService(t)
+	createUser(t, s, "user@example.com")
+
+	revoked := login(t, s, "user@example.com").AccessToken
+	other := login(t, s, "user@example.com").AccessToken
+
+	if err := s.revokeSessionAccessTokens(authorize(t, s, revoked).SessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Authorize(revoked); err != ErrAccessTokenRevoked {
+		t.Errorf("revoked session: got %v, want %v", err, ErrAccessTokenRevoked)
+	}
+
+	if _, err := s.Authorize(other); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	createUser(t, s, "other@example.com")
+	admin := createAdmin(t, s, models.RoleSuperadmin)
+
+	accessToken := login(t, s, "user@example.com").AccessToken
+	otherToken := login(t, s, "other@example.com").AccessToken
+
+	if err := s.RevokeUserTokens(admin.ID, user.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	if _, err := s.Authorize(accessToken); err != ErrAccessTokenRevoked {
+		t.Errorf("got %v, want %v", err, ErrAccessTokenRevoked)
+	}
+
+	if _, err := s.Authorize(otherToken); err != nil {
+		t.Errorf("token of another user: %v", err)
+	}
+
+	if sessions, _ := s.Sessions(user.ID, uuid.Nil); len(sessions) != 0 {
+		t.Errorf("%d sessions kept", len(sessions))
+	}
+
+	if err := s.RevokeUserTokens(admin.ID, uuid.New()); err != ErrUserNotFound {
+		t.Errorf("unknown user: got %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestRevokeUserTokensManagement(t *testing.T) {
+	s, _ := newTestService(t)
+	target := createAdmin(t, s, models.RoleSuperadmin)
+	admin := createAdmin(t, s, models.RoleGymAdmin)
+
+	accessToken := login(t, s, target.Email).AccessToken
+
+	if err := s.RevokeUserTokens(admin.ID, target.ID); err != ErrAccessDenied {
+		t.Fatalf("got %v, want %v", err, ErrAccessDenied)
+	}
+
+	if _, err := s.Authorize(accessToken); err != nil {
+		t.Errorf("token revoked by a refused request: %v", err)
+	}
+}
+
+func TestPruneRevokedTokens(t *testing.T) {
+	s, db := newTestService(t)
+
+	entries := map[string]time.Duration{
+		"jti:expired":    -time.Minute,
+		"jti:live":       time.Minute,
+		"session:live":   time.Hour,
+		"user:expired":   -time.Hour,
+		"suspended:live": time.Minute,
+	}
+
+	for key, ttl := range entries {
+		err := s.Storage.RevokedToken.Upsert(&models.RevokedToken{
+			Key:       key,
+			RevokedAt: time.Now().Add(-2 * time.Hour),
+			ExpiresAt: time.Now().Add(ttl),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.PruneRevokedTokens(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for key, ttl := range entries {
+		if _, kept := db.revoked[key]; kept != (ttl > 0) {
+			t.Errorf("%s kept: %v, want %v", key, kept, ttl > 0)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UserRoles struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+func (s *Service) Roles() ([]Role, error) {
+	roles, err := s.Storage.Role.GetAll()
+	if err != nil {
+		s.Logger.Error("failed to get roles", "err", err)
+		return nil, err
+	}
+
+	result := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Service) UserRoles(userID uuid.UUID) (UserRoles, error) {
+	if _, err := s.Storage.User.GetByID(userID); err != nil {
+		if err == models.ErrUserNotFound {
+			return UserRoles{}, ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return UserRoles{}, err
+	}
+
+	roles, permissions, err := s.Storage.Role.GetByUser(userID)
+	if err != nil {
+		s.Logger.Error("failed to get roles of user", "err", err)
+		return UserRoles{}, err
+	}
+
+	return UserRoles{
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// GrantRole grants the role on behalf of the actor. The change reaches the
+// access tokens of the user with the next refresh.
+func (s *Service) GrantRole(actorID uuid.UUID, userID uuid.UUID, role string) error {
+	if err := s.checkRoleManagement(actorID, role); err != nil {
+		return err
+	}
+
+	if _, err := s.Storage.User.GetByID(userID); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	if err := s.Storage.Role.Grant(userID, role, uuid.NullUUID{UUID: actorID, Valid: true}); err != nil {
+		if err == models.ErrRoleNotFound {
+			return ErrRoleNotFound
+		}
+
+		s.Logger.Error("failed to grant role", "err", err)
+		return err
+	}
+
+	s.Logger.Info("role granted", "user_id", userID, "role", role, "actor_id", actorID)
+	return nil
+}
+
+func (s *Service) RevokeRole(actorID uuid.UUID, userID uuid.UUID, role string) error {
+	if err := s.checkRoleManagement(actorID, role); err != nil {
+		return err
+	}
+
+	if role == models.RoleSuperadmin {
+		count, err := s.Storage.Role.CountUsers(models.RoleSuperadmin)
+		if err != nil {
+			s.Logger.Error("failed to count superadmins", "err", err)
+			return err
+		}
+
+		if count <= 1 {
+			return ErrLastSuperadmin
+		}
+	}
+
+	if err := s.Storage.Role.Revoke(userID, role); err != nil {
+		if err == models.ErrUserRoleNotFound {
+			return ErrUserRoleNotFound
+		}
+
+		s.Logger.Error("failed to revoke role", "err", err)
+		return err
+	}
+
+	s.Logger.Info("role revoked", "user_id", userID, "role", role, "actor_id", actorID)
+	return nil
+}
+
+// checkRoleManagement prevents privilege escalation: the actor may only
+// grant and revoke roles whose permissions they hold themselves. The
+// permissions are read from the database, not from the access token.
+func (s *Service) checkRoleManagement(actorID uuid.UUID, role string) error {
+	rolePermissions, err := s.Storage.Role.GetPermissionsByRole(role)
+	if err != nil {
+		if err == models.ErrRoleNotFound {
+			return ErrRoleNotFound
+		}
+
+		s.Logger.Error("failed to get permissions of role", "err", err)
+		return err
+	}
+
+	_, actorPermissions, err := s.Storage.Role.GetByUser(actorID)
+	if err != nil {
+		s.Logger.Error("failed to get roles of user", "err", err)
+		return err
+	}
+
+	if !isSubset([]string{"roles:manage"}, actorPermissions) || !isSubset(rolePermissions, actorPermissions) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+// BootstrapSuperadmins grants the superadmin role to the users listed in
+// the config. Users that have not registered yet are skipped.
+func (s *Service) BootstrapSuperadmins() error {
+	for _, email := range s.Cfg.SuperadminEmails {
+		user, err := s.Storage.User.GetByEmail(email)
+		if err != nil {
+			if err == models.ErrUserNotFound {
+				s.Logger.Warn("superadmin is not registered", "email", email)
+				continue
+			}
+
+			s.Logger.Error("failed to get user by email", "err", err)
+			return err
+		}
+
+		if user.State != models.UserStateActive {
+			s.Logger.Warn("superadmin is not active", "email", email)
+			continue
+		}
+
+		if err := s.Storage.Role.Grant(user.ID, models.RoleSuperadmin, uuid.NullUUID{}); err != nil {
+			s.Logger.Error("failed to grant superadmin role", "err", err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,7 +2,11 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base32"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -20,13 +24,78 @@ func generateCode(bytesUsed int) (string, error) {
 	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
 }
 
-func generateAccessToken(userID uuid.UUID, ttl time.Duration, key []byte) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iss": "gymshark",
-		"sub": userID,
-		"exp": time.Now().Add(ttl).Unix(),
-		"iat": time.Now().Unix(),
+// the sid claim holds the refresh token branch (session) the access token was issued for,
+// roles and perms are the roles of the user and the permissions they grant.
+// jti identifies the token on the denylist.
+func generateAccessToken(userID uuid.UUID, sessionID uuid.UUID, roles []string, permissions []string, ttl time.Duration, keys *KeySet) (string, error) {
+	return keys.Sign(jwt.MapClaims{
+		"iss":   "gymshark",
+		"jti":   uuid.New(),
+		"sub":   userID,
+		"sid":   sessionID,
+		"roles": roles,
+		"perms": permissions,
+		"exp":   time.Now().Add(ttl).Unix(),
+		"iat":   time.Now().Unix(),
 	})
+}
+
+// access tokens of partner apps also carry the client and the granted
+// scopes. The subject is the user, or the client itself for the client
+// credentials grant. sid is set only if a refresh token was issued.
+func generateClientAccessToken(subject string, clientID uuid.UUID, sessionID uuid.NullUUID, scopes []string, ttl time.Duration, keys *KeySet) (string, error) {
+	claims := jwt.MapClaims{
+		"iss":       "gymshark",
+		"jti":       uuid.New(),
+		"sub":       subject,
+		"client_id": clientID,
+		"scope":     strings.Join(scopes, " "),
+		"exp":       time.Now().Add(ttl).Unix(),
+		"iat":       time.Now().Unix(),
+	}
+
+	if sessionID.Valid {
+		claims["sid"] = sessionID.UUID
+	}
+
+	return keys.Sign(claims)
+}
+
+// selector tokens (refresh tokens, mfa challenges, oidc states, webauthn
+// ceremonies, report and restore links) have the form <id>.<secret>: the id
+// selects a single row, the secret is verified against its hash. The secret
+// is random and long enough not to need the password hasher, sha256 keeps
+// the hot paths like refresh cheap.
+func generateSelectorSecret() (string, []byte, error) {
+	secret, err := generateCode(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+
+	return secret, hash[:], nil
+}
+
+func verifySelectorSecret(hash []byte, secret string) bool {
+	other := sha256.Sum256([]byte(secret))
+	return subtle.ConstantTimeCompare(hash, other[:]) == 1
+}
+
+func formatSelectorToken(id uuid.UUID, secret string) string {
+	return id.String() + "." + secret
+}
+
+func parseSelectorToken(token string) (uuid.UUID, string, error) {
+	idString, secret, ok := strings.Cut(token, ".")
+	if !ok || len(secret) == 0 {
+		return uuid.Nil, "", errors.New("malformed selector token")
+	}
+
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
 
-	return token.SignedString(key)
+	return id, secret, nil
 }
@@ -5,6 +5,10 @@ import (
 	"log/slog"
 
 	"github.com/MartynyukAlexey/gymshark/internal/config"
+	"github.com/MartynyukAlexey/gymshark/internal/geoip"
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/oidc"
+	"github.com/MartynyukAlexey/gymshark/internal/password"
 	"github.com/MartynyukAlexey/gymshark/internal/smtp"
 	"github.com/MartynyukAlexey/gymshark/internal/storage"
 )
@@ -14,6 +18,23 @@ type Service struct {
 	Mailer  *smtp.SMTPMailer
 	Logger  *slog.Logger
 	Cfg     *config.AuthConfig
+	Keys    *KeySet
+	Hasher  *hashing.Hasher
+
+	// rules new passwords are checked against
+	Passwords *password.Policy
+
+	// external identity providers by name
+	OIDC map[string]*oidc.Provider
+
+	// locations for new sign-in alerts, nil if there is no database
+	GeoIP *geoip.DB
+}
+
+// ClientInfo describes the device a request was made from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
 }
 
 var (
@@ -37,4 +58,82 @@ var (
 	ErrAccessTokenExpired  = errors.New("access token expired")
 	ErrRefreshTokenExpired = errors.New("refresh token expired")
 	ErrRefreshTokenReuse   = errors.New("refresh token reuse")
+	ErrAccessTokenRevoked  = errors.New("access token revoked")
+
+	// sessions
+	ErrSessionNotFound = errors.New("session not found")
+
+	// two-factor authentication
+	ErrInvalidMFAToken    = errors.New("invalid mfa token")
+	ErrMFATokenExpired    = errors.New("mfa token expired")
+	ErrTOTPAlreadyEnabled = errors.New("totp is already enabled")
+	ErrTOTPNotEnabled     = errors.New("totp is not enabled")
+	ErrTOTPNotEnrolled    = errors.New("totp enrollment was not started")
+
+	// passkeys
+	ErrInvalidCeremony   = errors.New("invalid webauthn ceremony")
+	ErrCeremonyExpired   = errors.New("webauthn ceremony expired")
+	ErrInvalidPasskey    = errors.New("invalid passkey")
+	ErrPasskeyNotFound   = errors.New("passkey not found")
+	ErrPasskeyRegistered = errors.New("passkey is already registered")
+
+	// external identity providers
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidOIDCState     = errors.New("invalid oidc state")
+	ErrOIDCStateExpired     = errors.New("oidc state expired")
+	ErrOIDCLoginFailed      = errors.New("identity provider login failed")
+	ErrOIDCEmailRequired    = errors.New("identity provider did not share an email")
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not verify the email")
+
+	// authorization server
+	ErrClientNotFound          = errors.New("oauth client not found")
+	ErrInvalidClient           = errors.New("invalid client")
+	ErrInvalidClientMetadata   = errors.New("invalid client metadata")
+	ErrInvalidRedirectURI      = errors.New("invalid redirect uri")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrInvalidGrant            = errors.New("invalid grant")
+	ErrInvalidAuthorizeRequest = errors.New("invalid authorization request")
+	ErrInvalidConsent          = errors.New("invalid consent")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant type")
+	ErrUnsupportedResponseType = errors.New("unsupported response type")
+	ErrAccessDenied            = errors.New("access denied")
+	ErrOAuthDisabled           = errors.New("authorization server requires an asymmetric jwt algorithm")
+
+	// api keys
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrInvalidAPIKeyName = errors.New("invalid api key name")
+	ErrInvalidExpiry     = errors.New("invalid expiry")
+	ErrAPIKeyExpired     = errors.New("api key expired")
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+
+	// roles
+	ErrRoleNotFound     = errors.New("role not found")
+	ErrUserRoleNotFound = errors.New("user does not have the role")
+	ErrLastSuperadmin   = errors.New("the last superadmin can not be revoked")
+
+	// email change
+	ErrEmailUnchanged      = errors.New("new email is the same as the current one")
+	ErrEmailChangeNotFound = errors.New("no pending email change")
+	ErrEmailChangeTooSoon  = errors.New("an email change was requested recently, try again later")
+	ErrInvalidCancelToken  = errors.New("invalid cancel token")
+
+	// brute-force protection
+	ErrAccountLocked   = errors.New("account is temporarily locked")
+	ErrTooManyAttempts = errors.New("too many attempts, try again later")
+
+	// audit log
+	ErrInvalidEventFilter = errors.New("invalid event filter")
+
+	// sign-in alerts
+	ErrInvalidReportToken = errors.New("invalid report token")
+
+	// account deletion
+	ErrInvalidRestoreToken = errors.New("invalid restore token")
+	ErrOwnsOAuthClients    = errors.New("delete the oauth clients of the account first")
+
+	// suspensions
+	ErrUserSuspended     = errors.New("account is suspended")
+	ErrUserNotSuspended  = errors.New("user is not suspended")
+	ErrInvalidSuspension = errors.New("invalid suspension")
+	ErrSelfSuspension    = errors.New("users can not suspend themselves")
 )
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/config"
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/password"
+	"github.com/MartynyukAlexey/gymshark/internal/smtp"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+const testPassword = "correct horse battery staple"
+
+var testClient = ClientInfo{IP: "192.0.2.1", UserAgent: "test"}
+
+// newTestService builds a service on top of the in-memory fakes. Mail goes
+// to a closed port, so emails fail and are only logged.
+func newTestService(t *testing.T) (*Service, *fakeDB) {
+	t.Helper()
+
+	cfg := &config.AuthConfig{
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+		ResetCodeTTL:    30 * time.Minute,
+		MFATokenTTL:     5 * time.Minute,
+		TOTPIssuer:      "gymshark",
+		AppURL:          "http://localhost:8080",
+
+		PasswordMinLength: 8,
+		PasswordMaxLength: 72,
+
+		PasswordHashAlgorithm: hashing.AlgorithmArgon2id,
+		Argon2Memory:          64,
+		Argon2Iterations:      1,
+		Argon2Parallelism:     1,
+
+		JWTAlgorithm: "HS256",
+		JWTKey:       []byte("test"),
+
+		WebAuthnRPID:        "localhost",
+		WebAuthnRPName:      "gymshark",
+		WebAuthnOrigins:     []string{"http://localhost:8080"},
+		WebAuthnCeremonyTTL: 5 * time.Minute,
+
+		OIDCStateTTL: 10 * time.Minute,
+
+		AttemptWindow:       15 * time.Minute,
+		MaxAccountFailures:  10,
+		MaxIPFailures:       100,
+		LockoutDuration:     15 * time.Minute,
+		FailuresBeforeDelay: 100,
+
+		SignInReportTTL:            7 * 24 * time.Hour,
+		AccountDeletionGracePeriod: 14 * 24 * time.Hour,
+	}
+
+	keys, err := NewKeySet(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasher, err := hashing.NewHasher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mailer := smtp.NewSMTPMailer(&config.MailerConfig{
+		SenderEmail: "gymshark@example.com",
+		RelayHost:   "127.0.0.1",
+		RelayPort:   1,
+	}, logger)
+
+	storage, db := newFakeStorage()
+
+	return &Service{
+		Storage:   storage,
+		Mailer:    mailer,
+		Logger:    logger,
+		Cfg:       cfg,
+		Keys:      keys,
+		Hasher:    hasher,
+		Passwords: password.NewPolicy(cfg),
+	}, db
+}
+
+// createUser inserts an active member with testPassword.
+func createUser(t *testing.T, s *Service, email string) *models.User {
+	t.Helper()
+
+	passHash, err := s.Hasher.Hash([]byte(testPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{
+		Email:        email,
+		PasswordHash: passHash,
+		State:        models.UserStateActive,
+	}
+
+	if err := s.Storage.User.Insert(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Storage.Role.Grant(user.ID, models.RoleMember, uuid.NullUUID{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// login opens a session for the user created with createUser.
+func login(t *testing.T, s *Service, email string) LoginResp {
+	t.Helper()
+
+	resp, err := s.Login(&LoginReq{
+		Email:    email,
+		Password: testPassword,
+		Client:   testClient,
+	})
+
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	return resp
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a refresh token branch started by a single login.
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	Current   bool      `json:"current"`
+	// partner app the session was authorized for
+	ClientID   *uuid.UUID `json:"client_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+}
+
+// Sessions lists the sessions of the user that can still be refreshed,
+// most recently used first. currentID marks the session of the caller.
+func (s *Service) Sessions(userID uuid.UUID, currentID uuid.UUID) ([]Session, error) {
+	sessions, err := s.Storage.Token.GetSessions(userID)
+	if err != nil {
+		s.Logger.Error("failed to get sessions for user", "err", err)
+		return nil, err
+	}
+
+	result := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		item := Session{
+			ID:         session.Branch,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.Branch == currentID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		}
+
+		if session.ClientID.Valid {
+			item.ClientID = &session.ClientID.UUID
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// RevokeSession revokes a single session of the user.
+func (s *Service) RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error {
+	sessions, err := s.Sessions(userID, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID != sessionID {
+			continue
+		}
+
+		if err := s.Storage.Token.DeleteAllByBranch(userID, sessionID); err != nil {
+			s.Logger.Error("failed to delete tokens for branch", "err", err)
+			return err
+		}
+
+		return s.revokeSessionAccessTokens(sessionID)
+	}
+
+	return ErrSessionNotFound
+}
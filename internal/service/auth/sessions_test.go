@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestSessions(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+
+	first := login(t, s, "user@example.com")
+	second := login(t, s, "user@example.com")
+
+	claims, err := s.Authorize(first.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+
+	// the refresh makes the first session the most recently used one
+	if _, err := s.Refresh(&RefreshReq{RefreshToken: first.RefreshToken, Client: testClient}); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	sessions, err := s.Sessions(user.ID, claims.SessionID)
+	if err != nil {
+		t.Fatalf("sessions: %v", err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	if sessions[0].ID != claims.SessionID || !sessions[0].Current || sessions[1].Current {
+		t.Errorf("got sessions %+v, want the current one first", sessions)
+	}
+
+	if !sessions[0].CreatedAt.Before(sessions[0].LastUsedAt) {
+		t.Errorf("created at %v is not before the refresh at %v", sessions[0].CreatedAt, sessions[0].LastUsedAt)
+	}
+
+	if err := s.Logout(&LogoutReq{RefreshToken: second.RefreshToken, Client: testClient}); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	sessions, err = s.Sessions(user.ID, claims.SessionID)
+	if err != nil {
+		t.Fatalf("sessions: %v", err)
+	}
+
+	if len(sessions) != 1 || sessions[0].ID != claims.SessionID {
+		t.Errorf("got sessions %+v after logout, want the first one only", sessions)
+	}
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type SignInAlertsReq struct {
+	Enabled bool `json:"enabled"`
+}
+
+// checkNewDevice alerts the user by email if the login comes from a user
+// agent or an ip none of their earlier logins had. It must run before the
+// login is recorded. The very first login is not reported, and the alert
+// itself is sent in the background, so it is best effort.
+func (s *Service) checkNewDevice(userID uuid.UUID, sessionID uuid.UUID, client ClientInfo) {
+	history, err := s.Storage.AuthEvent.GetDeviceHistory(userID, client.UserAgent, client.IP)
+	if err != nil {
+		s.Logger.Error("failed to get device history", "err", err)
+		return
+	}
+
+	if !history.HasLogins || (history.UserAgentSeen && history.IPSeen) {
+		return
+	}
+
+	go func() {
+		if err := s.sendNewSignInAlert(userID, sessionID, client); err != nil {
+			s.Logger.Error("failed to send new sign in alert", "err", err)
+		}
+	}()
+}
+
+func (s *Service) sendNewSignInAlert(userID uuid.UUID, sessionID uuid.UUID, client ClientInfo) error {
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.SignInAlerts {
+		return nil
+	}
+
+	reportSecret, reportHash, err := generateSelectorSecret()
+	if err != nil {
+		return err
+	}
+
+	signIn := &models.SignIn{
+		UserID:     user.ID,
+		Branch:     sessionID,
+		ReportHash: reportHash,
+		ExpiresAt:  time.Now().Add(s.Cfg.SignInReportTTL),
+	}
+
+	if err := s.Storage.SignIn.Insert(signIn); err != nil {
+		return err
+	}
+
+	reportURL := s.Cfg.AppURL + "/api/v1/sign-ins/report?" + url.Values{
+		"token": {formatSelectorToken(signIn.ID, reportSecret)},
+	}.Encode()
+
+	location := "Unknown location"
+	if loc, ok := s.GeoIP.Lookup(client.IP); ok {
+		location = loc.String()
+	}
+
+	return s.Mailer.SendNewSignInEmail(user.Email, describeDevice(client.UserAgent), location, client.IP, signIn.CreatedAt, reportURL)
+}
+
+// ReportSignIn is the "this wasn't me" link of the alert: the session the
+// login opened is revoked and a password reset code is sent to the user.
+func (s *Service) ReportSignIn(reportToken string) error {
+	signInID, secret, err := parseSelectorToken(reportToken)
+	if err != nil {
+		return ErrInvalidReportToken
+	}
+
+	signIn, err := s.Storage.SignIn.GetByID(signInID)
+	if err != nil {
+		if err == models.ErrSignInNotFound {
+			return ErrInvalidReportToken
+		}
+
+		s.Logger.Error("failed to get sign in", "err", err)
+		return err
+	}
+
+	if !verifySelectorSecret(signIn.ReportHash, secret) {
+		return ErrInvalidReportToken
+	}
+
+	if signIn.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidReportToken
+	}
+
+	// the link works once, a second click must not send another reset code
+	if err := s.Storage.SignIn.DeleteByID(signIn.ID); err != nil {
+		if err == models.ErrSignInNotFound {
+			return ErrInvalidReportToken
+		}
+
+		s.Logger.Error("failed to delete sign in", "err", err)
+		return err
+	}
+
+	if err := s.Storage.Token.DeleteAllByBranch(signIn.UserID, signIn.Branch); err != nil {
+		s.Logger.Error("failed to delete tokens for branch", "err", err)
+		return err
+	}
+
+	if err := s.revokeSessionAccessTokens(signIn.Branch); err != nil {
+		return err
+	}
+
+	s.Logger.Warn("sign in reported", "user_id", signIn.UserID, "branch", signIn.Branch)
+
+	user, err := s.Storage.User.GetByID(signIn.UserID)
+	if err != nil {
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	return s.ForgotPassword(&ForgotPasswordReq{Email: user.Email})
+}
+
+// UpdateSignInAlerts turns the new sign-in emails on or off.
+func (s *Service) UpdateSignInAlerts(userID uuid.UUID, req *SignInAlertsReq) error {
+	if err := s.Storage.User.UpdateSignInAlerts(userID, req.Enabled); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to update sign in alerts", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// PruneSignIns drops the logins whose report links have expired.
+func (s *Service) PruneSignIns() error {
+	if err := s.Storage.SignIn.DeleteAllExpired(); err != nil {
+		s.Logger.Error("failed to prune sign ins", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// describeDevice turns a user agent into something like "Firefox on
+// Windows". Order matters: Edge and Chrome also claim to be Safari.
+func describeDevice(userAgent string) string {
+	var browser string
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	var os string
+	for _, o := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "" || os != "":
+		return browser + os
+	case userAgent != "":
+		return userAgent
+	default:
+		return "Unknown device"
+	}
+}
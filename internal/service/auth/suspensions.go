@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+const maxSuspensionReasonLen = 500
+
+type SuspendUserReq struct {
+	Reason string `json:"reason"`
+	// nil suspends the user until the suspension is lifted
+	Until *time.Time `json:"until,omitempty"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// SuspendUser suspends the user on behalf of an administrator: every
+// session is revoked and the user can not log in until the suspension
+// ends or is lifted. Suspending an already suspended user replaces the
+// suspension.
+func (s *Service) SuspendUser(actorID uuid.UUID, userID uuid.UUID, req *SuspendUserReq) error {
+	if err := validateSuspendUserReq(req); err != nil {
+		return err
+	}
+
+	if actorID == userID {
+		return ErrSelfSuspension
+	}
+
+	if err := s.checkUserManagement(actorID, userID); err != nil {
+		return err
+	}
+
+	if err := s.Storage.User.Suspend(userID, strings.TrimSpace(req.Reason), actorID, req.Until); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to suspend user", "err", err)
+		return err
+	}
+
+	if err := s.Storage.Token.DeleteAllByUser(userID); err != nil {
+		s.Logger.Error("failed to delete tokens for user", "err", err)
+		return err
+	}
+
+	if err := s.revokeSuspendedUserAccessTokens(userID); err != nil {
+		return err
+	}
+
+	// logins half way through and confirmation codes would get the user
+	// back in
+	if err := s.deleteLoginCodes(userID); err != nil {
+		return err
+	}
+
+	if err := s.Storage.Code.DeleteAllByUser(userID, models.CodeScopeConfirm); err != nil {
+		s.Logger.Error("failed to delete confirmation codes", "err", err)
+		return err
+	}
+
+	s.recordAdminEvent(models.AuthEventSuspend, actorID, userID, req.Client, strings.TrimSpace(req.Reason))
+
+	s.Logger.Info("user suspended", "user_id", userID, "actor_id", actorID, "until", req.Until)
+	return nil
+}
+
+// UnsuspendUser lifts the suspension before its end date, the user has to
+// log in again.
+func (s *Service) UnsuspendUser(actorID uuid.UUID, userID uuid.UUID, client ClientInfo) error {
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	if user.State != models.UserStateSuspended {
+		return ErrUserNotSuspended
+	}
+
+	if err := s.checkUserManagement(actorID, userID); err != nil {
+		return err
+	}
+
+	if err := s.Storage.User.Unsuspend(userID); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotSuspended
+		}
+
+		s.Logger.Error("failed to unsuspend user", "err", err)
+		return err
+	}
+
+	s.recordAdminEvent(models.AuthEventUnsuspend, actorID, userID, client, "")
+
+	s.Logger.Info("user unsuspended", "user_id", userID, "actor_id", actorID)
+	return nil
+}
+
+// LiftExpiredSuspensions reactivates the users whose suspension has ended.
+func (s *Service) LiftExpiredSuspensions() error {
+	if err := s.Storage.User.UnsuspendAllExpired(); err != nil {
+		s.Logger.Error("failed to lift expired suspensions", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// checkUserManagement keeps administrators from acting on users with more
+// permissions than their own, a gym admin must not lock out a superadmin.
+func (s *Service) checkUserManagement(actorID uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.Storage.User.GetByID(userID); err != nil {
+		if err == models.ErrUserNotFound {
+			return ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return err
+	}
+
+	_, userPermissions, err := s.Storage.Role.GetByUser(userID)
+	if err != nil {
+		s.Logger.Error("failed to get roles of user", "err", err)
+		return err
+	}
+
+	_, actorPermissions, err := s.Storage.Role.GetByUser(actorID)
+	if err != nil {
+		s.Logger.Error("failed to get roles of user", "err", err)
+		return err
+	}
+
+	if !isSubset(userPermissions, actorPermissions) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+func validateSuspendUserReq(req *SuspendUserReq) error {
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) == 0 || len(reason) > maxSuspensionReasonLen {
+		return ErrInvalidSuspension
+	}
+
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return ErrInvalidSuspension
+	}
+
+	return nil
+}
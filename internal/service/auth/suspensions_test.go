@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// createAdmin creates an active user with the role.
+func createAdmin(t *testing.T, s *Service, role string) *models.User {
+	t.Helper()
+
+	admin := createUser(t, s, uuid.NewString()+"@example.com")
+	if err := s.Storage.Role.Grant(admin.ID, role, uuid.NullUUID{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return admin
+}
+
+// suspend suspends the user on behalf of a new superadmin.
+func suspend(t *testing.T, s *Service, userID uuid.UUID) {
+	t.Helper()
+
+	admin := createAdmin(t, s, models.RoleSuperadmin)
+
+	if err := s.SuspendUser(admin.ID, userID, &SuspendUserReq{Reason: "spam"}); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+}
+
+// insertCode stores a code like the ones sent by email.
+func insertCode(t *testing.T, s *Service, userID uuid.UUID, scope models.CodeScope, code string) {
+	t.Helper()
+
+	hash, err := s.Hasher.Hash([]byte(code))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Storage.Code.Insert(&models.Code{
+		UserID:    userID,
+		Hash:      hash,
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func confirmationCodes(t *testing.T, s *Service, userID uuid.UUID) int {
+	t.Helper()
+
+	codes, err := s.Storage.Code.GetAllByUser(userID, models.CodeScopeConfirm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(codes)
+}
+
+func TestConfirmSuspendedUser(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+
+	// a code left over from the registration
+	insertCode(t, s, user.ID, models.CodeScopeConfirm, "123456")
+
+	suspend(t, s, user.ID)
+
+	if n := confirmationCodes(t, s, user.ID); n != 0 {
+		t.Errorf("%d confirmation codes kept after the suspension", n)
+	}
+
+	insertCode(t, s, user.ID, models.CodeScopeConfirm, "123456")
+
+	err := s.Confirm(&ConfirmReq{Email: "user@example.com", Code: "123456", Client: testClient})
+	if err != ErrUserSuspended {
+		t.Fatalf("got %v, want %v", err, ErrUserSuspended)
+	}
+
+	if user, _ := s.Storage.User.GetByID(user.ID); user.State != models.UserStateSuspended {
+		t.Errorf("got state %s, want the suspension kept", user.State)
+	}
+}
+
+func TestConfirmDeletesCodes(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	s.Storage.User.UpdateStatus(user.ID, models.UserStatePending)
+
+	// the first code and the one sent again
+	insertCode(t, s, user.ID, models.CodeScopeConfirm, "111111")
+	insertCode(t, s, user.ID, models.CodeScopeConfirm, "222222")
+
+	if err := s.Confirm(&ConfirmReq{Email: "user@example.com", Code: "222222", Client: testClient}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	if n := confirmationCodes(t, s, user.ID); n != 0 {
+		t.Errorf("%d confirmation codes kept after the confirmation", n)
+	}
+}
+
+func TestSuspendDropsPendingLogins(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	authenticator := registerPasskey(t, s, user.ID)
+	recoveryCodes := enableTOTP(t, s, user.ID)
+
+	challenge := login(t, s, "user@example.com")
+	ceremony := passkeyLoginReq(t, s, "user@example.com", authenticator)
+
+	suspend(t, s, user.ID)
+
+	_, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: recoveryCodes[0], Client: testClient})
+	if err != ErrInvalidMFAToken {
+		t.Errorf("mfa challenge: got %v, want %v", err, ErrInvalidMFAToken)
+	}
+
+	if _, err := s.FinishPasskeyLogin(ceremony); err != ErrInvalidCeremony {
+		t.Errorf("passkey ceremony: got %v, want %v", err, ErrInvalidCeremony)
+	}
+}
+
+func TestStartSessionSuspended(t *testing.T) {
+	s, _ := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	recoveryCodes := enableTOTP(t, s, user.ID)
+
+	challenge := login(t, s, "user@example.com")
+
+	// suspended in the storage only, the challenge survives
+	if err := s.Storage.User.Suspend(user.ID, "spam", uuid.New(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.LoginMFA(&LoginMFAReq{MFAToken: challenge.MFAToken, Code: recoveryCodes[0], Client: testClient})
+	if err != ErrUserSuspended {
+		t.Errorf("got %v, want %v", err, ErrUserSuspended)
+	}
+}
+
+// checkAdminEvent checks that the action was recorded with the
+// administrator who took it.
+func checkAdminEvent(t *testing.T, db *fakeDB, eventType models.AuthEventType, actorID uuid.UUID, userID uuid.UUID) {
+	t.Helper()
+
+	event := lastEvent(t, db)
+
+	if event.Type != eventType || event.Outcome != models.AuthEventSuccess {
+		t.Errorf("got %s event with outcome %s, want a successful %s", event.Type, event.Outcome, eventType)
+	}
+
+	if event.ActorID.UUID != actorID {
+		t.Errorf("got actor %v, want %v", event.ActorID.UUID, actorID)
+	}
+
+	if event.UserID.UUID != userID {
+		t.Errorf("got user %v, want %v", event.UserID.UUID, userID)
+	}
+}
+
+func TestGymAdminSuspendsMember(t *testing.T) {
+	s, db := newTestService(t)
+	user := createUser(t, s, "user@example.com")
+	admin := createAdmin(t, s, models.RoleGymAdmin)
+
+	if err := s.SuspendUser(admin.ID, user.ID, &SuspendUserReq{Reason: "spam", Client: testClient}); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+
+	checkAdminEvent(t, db, models.AuthEventSuspend, admin.ID, user.ID)
+
+	if event := lastEvent(t, db); event.Detail != "spam" {
+		t.Errorf("got detail %q, want the reason", event.Detail)
+	}
+
+	if _, err := s.Login(&LoginReq{Email: "user@example.com", Password: testPassword, Client: testClient}); err != ErrUserSuspended {
+		t.Fatalf("login: got %v, want %v", err, ErrUserSuspended)
+	}
+
+	if err := s.UnsuspendUser(admin.ID, user.ID, testClient); err != nil {
+		t.Fatalf("unsuspend: %v", err)
+	}
+
+	checkAdminEvent(t, db, models.AuthEventUnsuspend, admin.ID, user.ID)
+
+	login(t, s, "user@example.com")
+}
+
+func TestUnsuspendUserManagement(t *testing.T) {
+	s, db := newTestService(t)
+	target := createAdmin(t, s, models.RoleSuperadmin)
+	suspend(t, s, target.ID)
+
+	admin := createAdmin(t, s, models.RoleGymAdmin)
+	recorded := len(db.events)
+
+	if err := s.UnsuspendUser(admin.ID, target.ID, testClient); err != ErrAccessDenied {
+		t.Fatalf("got %v, want %v", err, ErrAccessDenied)
+	}
+
+	if user, _ := s.Storage.User.GetByID(target.ID); user.State != models.UserStateSuspended {
+		t.Errorf("got state %s, want the suspension kept", user.State)
+	}
+
+	if len(db.events) != recorded {
+		t.Error("a refused unsuspension was recorded")
+	}
+}
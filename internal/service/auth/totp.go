@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// RFC 6238 parameters, the defaults understood by every authenticator app
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// accepted clock drift in steps on either side
+	totpSkew = 1
+
+	recoveryCodesCount = 10
+)
+
+type EnrollTOTPResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type ConfirmTOTPReq struct {
+	Code string `json:"code"`
+}
+
+type ConfirmTOTPResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableTOTPReq struct {
+	// totp or recovery code
+	Code string `json:"code"`
+}
+
+// EnrollTOTP generates a new secret for the user. Two-factor authentication
+// stays disabled until the secret is confirmed with ConfirmTOTP.
+func (s *Service) EnrollTOTP(userID uuid.UUID) (EnrollTOTPResp, error) {
+	user, err := s.Storage.User.GetByID(userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			return EnrollTOTPResp{}, ErrUserNotFound
+		}
+
+		s.Logger.Error("failed to get user by id", "err", err)
+		return EnrollTOTPResp{}, err
+	}
+
+	totp, err := s.Storage.TOTP.GetByUser(userID)
+	if err != nil && err != models.ErrTOTPNotFound {
+		s.Logger.Error("failed to get totp", "err", err)
+		return EnrollTOTPResp{}, err
+	}
+
+	if totp != nil && totp.Enabled {
+		return EnrollTOTPResp{}, ErrTOTPAlreadyEnabled
+	}
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		s.Logger.Error("failed to generate totp secret", "err", err)
+		return EnrollTOTPResp{}, err
+	}
+
+	if err := s.Storage.TOTP.Upsert(&models.TOTP{
+		UserID: userID,
+		Secret: secret,
+	}); err != nil {
+		s.Logger.Error("failed to save totp secret", "err", err)
+		return EnrollTOTPResp{}, err
+	}
+
+	encodedSecret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+
+	return EnrollTOTPResp{
+		Secret: encodedSecret,
+		URI:    totpURI(s.Cfg.TOTPIssuer, user.Email, encodedSecret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves the
+// authenticator app is set up, and issues a fresh set of recovery codes.
+func (s *Service) ConfirmTOTP(userID uuid.UUID, req *ConfirmTOTPReq) (ConfirmTOTPResp, error) {
+	totp, err := s.Storage.TOTP.GetByUser(userID)
+	if err != nil {
+		if err == models.ErrTOTPNotFound {
+			return ConfirmTOTPResp{}, ErrTOTPNotEnrolled
+		}
+
+		s.Logger.Error("failed to get totp", "err", err)
+		return ConfirmTOTPResp{}, err
+	}
+
+	if totp.Enabled {
+		return ConfirmTOTPResp{}, ErrTOTPAlreadyEnabled
+	}
+
+	if err := s.verifyTOTP(totp, req.Code); err != nil {
+		return ConfirmTOTPResp{}, err
+	}
+
+	if err := s.Storage.TOTP.Enable(userID); err != nil {
+		s.Logger.Error("failed to enable totp", "err", err)
+		return ConfirmTOTPResp{}, err
+	}
+
+	if err := s.Storage.Code.DeleteAllByUser(userID, models.CodeScopeRecovery); err != nil {
+		s.Logger.Error("failed to delete old recovery codes", "err", err)
+		return ConfirmTOTPResp{}, err
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodesCount)
+	for range recoveryCodesCount {
+		code, err := generateCode(5)
+		if err != nil {
+			s.Logger.Error("failed to generate recovery code", "err", err)
+			return ConfirmTOTPResp{}, err
+		}
+
+		codeHash, err := s.Hasher.Hash([]byte(code))
+		if err != nil {
+			s.Logger.Error("failed to hash recovery code", "err", err)
+			return ConfirmTOTPResp{}, err
+		}
+
+		// recovery codes do not expire, they are burned on use
+		if err := s.Storage.Code.Insert(&models.Code{
+			UserID:    userID,
+			Hash:      codeHash,
+			Scope:     models.CodeScopeRecovery,
+			ExpiresAt: time.Now().AddDate(100, 0, 0),
+		}); err != nil {
+			s.Logger.Error("failed to save recovery code", "err", err)
+			return ConfirmTOTPResp{}, err
+		}
+
+		recoveryCodes = append(recoveryCodes, code)
+	}
+
+	return ConfirmTOTPResp{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It requires a valid
+// totp or recovery code, so a stolen session alone can not do it.
+func (s *Service) DisableTOTP(userID uuid.UUID, req *DisableTOTPReq) error {
+	totp, err := s.Storage.TOTP.GetByUser(userID)
+	if err != nil {
+		if err == models.ErrTOTPNotFound {
+			return ErrTOTPNotEnabled
+		}
+
+		s.Logger.Error("failed to get totp", "err", err)
+		return err
+	}
+
+	if !totp.Enabled {
+		return ErrTOTPNotEnabled
+	}
+
+	if err := s.verifySecondFactor(totp, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.Storage.TOTP.Delete(userID); err != nil {
+		s.Logger.Error("failed to delete totp", "err", err)
+		return err
+	}
+
+	if err := s.Storage.Code.DeleteAllByUser(userID, models.CodeScopeRecovery); err != nil {
+		s.Logger.Error("failed to delete recovery codes", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// verifySecondFactor accepts either a totp code or a recovery code. A
+// matching recovery code is burned.
+func (s *Service) verifySecondFactor(totp *models.TOTP, code string) error {
+	if len(code) == totpDigits {
+		return s.verifyTOTP(totp, code)
+	}
+
+	codes, err := s.Storage.Code.GetAllByUser(totp.UserID, models.CodeScopeRecovery)
+	if err != nil {
+		s.Logger.Error("failed to get recovery codes", "err", err)
+		return err
+	}
+
+	for _, recoveryCode := range codes {
+		if err := s.Hasher.Compare(recoveryCode.Hash, []byte(code)); err != nil {
+			if err != hashing.ErrMismatchedHash {
+				s.Logger.Error("failed to verify recovery code", "err", err)
+				return err
+			}
+
+			continue
+		}
+
+		if err := s.Storage.Code.Consume(recoveryCode.ID); err != nil {
+			// used by a concurrent request
+			if err == models.ErrCodeNotFound {
+				return ErrInvalidCode
+			}
+
+			s.Logger.Error("failed to delete used recovery code", "err", err)
+			return err
+		}
+
+		return nil
+	}
+
+	return ErrInvalidCode
+}
+
+// verifyTOTP checks the code against the current time step and its
+// neighbours. Every step is accepted at most once.
+func (s *Service) verifyTOTP(totp *models.TOTP, code string) error {
+	current := time.Now().Unix() / totpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(totp.Secret, step)), []byte(code)) != 1 {
+			continue
+		}
+
+		if err := s.Storage.TOTP.UpdateLastUsedStep(totp.UserID, step); err != nil {
+			if err == models.ErrTOTPStepUsed {
+				return ErrInvalidCode
+			}
+
+			s.Logger.Error("failed to update totp step", "err", err)
+			return err
+		}
+
+		return nil
+	}
+
+	return ErrInvalidCode
+}
+
+// totpCode computes the HOTP value (RFC 4226) of the given time step.
+func totpCode(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func totpURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}).String()
+}
@@ -4,6 +4,10 @@ import (
 	"log/slog"
 
 	"github.com/MartynyukAlexey/gymshark/internal/config"
+	"github.com/MartynyukAlexey/gymshark/internal/geoip"
+	"github.com/MartynyukAlexey/gymshark/internal/hashing"
+	"github.com/MartynyukAlexey/gymshark/internal/oidc"
+	"github.com/MartynyukAlexey/gymshark/internal/password"
 	"github.com/MartynyukAlexey/gymshark/internal/service/auth"
 	"github.com/MartynyukAlexey/gymshark/internal/service/user"
 	"github.com/MartynyukAlexey/gymshark/internal/smtp"
@@ -20,6 +24,10 @@ type ServiceOpts struct {
 	Mailer     *smtp.SMTPMailer
 	Logger     *slog.Logger
 	AuthConfig *config.AuthConfig
+	AuthKeys   *auth.KeySet
+	Hasher     *hashing.Hasher
+	OIDCConfig *config.OIDCConfig
+	GeoIP      *geoip.DB
 }
 
 func NewService(opts *ServiceOpts) *Service {
@@ -29,6 +37,12 @@ func NewService(opts *ServiceOpts) *Service {
 			Mailer:  opts.Mailer,
 			Logger:  opts.Logger,
 			Cfg:     opts.AuthConfig,
+			Keys:    opts.AuthKeys,
+			Hasher:  opts.Hasher,
+			OIDC:    oidc.NewProviders(opts.OIDCConfig),
+			GeoIP:   opts.GeoIP,
+
+			Passwords: password.NewPolicy(opts.AuthConfig),
 		},
 
 		User: &user.Service{},
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net/smtp"
 	"path/filepath"
+	"time"
 
 	"github.com/MartynyukAlexey/gymshark/internal/config"
 )
@@ -85,3 +86,106 @@ func (m *SMTPMailer) SendPasswordResetEmail(to string, resetCode string) error {
 
 	return m.sendEmail(to, subject, body)
 }
+
+func (m *SMTPMailer) SendUnlockEmail(to string, unlockCode string) error {
+	subject := "Your Account Was Locked"
+
+	body, err := m.renderTemplate("account_unlock.html", map[string]string{
+		"UnlockCode": unlockCode,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
+
+func (m *SMTPMailer) SendLoginCodeEmail(to string, loginCode string, ttl time.Duration) error {
+	subject := "Your Sign In Code"
+
+	body, err := m.renderTemplate("login_code.html", map[string]string{
+		"LoginCode": loginCode,
+		"TTL":       ttl.String(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
+
+func (m *SMTPMailer) SendEmailChangeCodeEmail(to string, confirmationCode string) error {
+	subject := "Confirm Your New Email"
+
+	body, err := m.renderTemplate("email_change_code.html", map[string]string{
+		"ConfirmationCode": confirmationCode,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
+
+func (m *SMTPMailer) SendEmailChangeNoticeEmail(to string, newEmail string, cancelURL string) error {
+	subject := "Your Email Is Being Changed"
+
+	body, err := m.renderTemplate("email_change_notice.html", map[string]string{
+		"NewEmail":  newEmail,
+		"CancelURL": cancelURL,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
+
+func (m *SMTPMailer) SendPasswordChangedEmail(to string) error {
+	subject := "Your Password Was Changed"
+
+	body, err := m.renderTemplate("password_changed.html", map[string]string{})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
+
+func (m *SMTPMailer) SendNewSignInEmail(to string, device string, location string, ip string, at time.Time, reportURL string) error {
+	subject := "New Sign In To Your Account"
+
+	body, err := m.renderTemplate("new_sign_in.html", map[string]string{
+		"Device":    device,
+		"Location":  location,
+		"IP":        ip,
+		"Time":      at.UTC().Format("January 2, 2006 15:04 MST"),
+		"ReportURL": reportURL,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
+
+func (m *SMTPMailer) SendAccountDeletedEmail(to string, purgeAt time.Time, restoreURL string) error {
+	subject := "Your Account Was Deleted"
+
+	body, err := m.renderTemplate("account_deleted.html", map[string]string{
+		"PurgeDate":  purgeAt.UTC().Format("January 2, 2006"),
+		"RestoreURL": restoreURL,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return m.sendEmail(to, subject, body)
+}
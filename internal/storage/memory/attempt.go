@@ -0,0 +1,93 @@
+// Package memory has in-process implementations of storage interfaces, for
+// single instance deployments and local development, and caches kept in
+// front of the persistent ones.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type AttemptStorage struct {
+	mu       sync.Mutex
+	attempts map[string]*models.Attempt
+}
+
+func NewAttemptStorage() *AttemptStorage {
+	return &AttemptStorage{
+		attempts: make(map[string]*models.Attempt),
+	}
+}
+
+func (s *AttemptStorage) Get(key string) (*models.Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return &models.Attempt{Key: key}, nil
+	}
+
+	result := *attempt
+	return &result, nil
+}
+
+func (s *AttemptStorage) RecordFailure(key string, window time.Duration) (*models.Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = &models.Attempt{Key: key}
+		s.attempts[key] = attempt
+	}
+
+	// failures older than the window are forgotten
+	if attempt.LastFailedAt.Before(now.Add(-window)) {
+		attempt.Failures = 0
+	}
+
+	attempt.Failures++
+	attempt.LastFailedAt = now
+
+	result := *attempt
+	return &result, nil
+}
+
+func (s *AttemptStorage) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.LockedUntil = until
+		attempt.Failures = 0
+	}
+
+	return nil
+}
+
+func (s *AttemptStorage) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *AttemptStorage) DeleteAllStale(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, attempt := range s.attempts {
+		if attempt.LastFailedAt.Before(before) && attempt.LockedUntil.Before(now) {
+			delete(s.attempts, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type revokedTokenStorage interface {
+	Upsert(token *models.RevokedToken) error
+	GetAll() ([]*models.RevokedToken, error)
+	DeleteAllExpired() error
+}
+
+// RevokedTokenCache keeps the whole denylist in memory in front of the
+// persistent storage, so checking an access token does not hit the
+// database. Revocations are written through, the ones made by other
+// instances show up after the next Sync.
+type RevokedTokenCache struct {
+	storage revokedTokenStorage
+
+	mu     sync.RWMutex
+	tokens map[string]*models.RevokedToken
+}
+
+func NewRevokedTokenCache(storage revokedTokenStorage) *RevokedTokenCache {
+	return &RevokedTokenCache{
+		storage: storage,
+		tokens:  make(map[string]*models.RevokedToken),
+	}
+}
+
+func (c *RevokedTokenCache) Upsert(token *models.RevokedToken) error {
+	if err := c.storage.Upsert(token); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.merge(token)
+	return nil
+}
+
+func (c *RevokedTokenCache) Get(key string) (*models.RevokedToken, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	token, ok := c.tokens[key]
+	if !ok || !token.ExpiresAt.After(time.Now()) {
+		return nil, models.ErrRevokedTokenNotFound
+	}
+
+	result := *token
+	return &result, nil
+}
+
+func (c *RevokedTokenCache) GetAll() ([]*models.RevokedToken, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+
+	tokens := make([]*models.RevokedToken, 0, len(c.tokens))
+	for _, token := range c.tokens {
+		if token.ExpiresAt.After(now) {
+			result := *token
+			tokens = append(tokens, &result)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (c *RevokedTokenCache) DeleteAllExpired() error {
+	if err := c.storage.DeleteAllExpired(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune()
+	return nil
+}
+
+// Sync loads the entries added by other instances. Entries are only ever
+// added or moved forward, so the loaded ones are merged into the cache.
+func (c *RevokedTokenCache) Sync() error {
+	tokens, err := c.storage.GetAll()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, token := range tokens {
+		c.merge(token)
+	}
+
+	c.prune()
+	return nil
+}
+
+func (c *RevokedTokenCache) merge(token *models.RevokedToken) {
+	cached, ok := c.tokens[token.Key]
+	if !ok {
+		result := *token
+		c.tokens[token.Key] = &result
+		return
+	}
+
+	if token.RevokedAt.After(cached.RevokedAt) {
+		cached.RevokedAt = token.RevokedAt
+	}
+
+	if token.ExpiresAt.After(cached.ExpiresAt) {
+		cached.ExpiresAt = token.ExpiresAt
+	}
+}
+
+func (c *RevokedTokenCache) prune() {
+	now := time.Now()
+	for key, token := range c.tokens {
+		if !token.ExpiresAt.After(now) {
+			delete(c.tokens, key)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+// sharedStorage stands in for the database the instances share.
+type sharedStorage struct {
+	mu     sync.Mutex
+	tokens map[string]*models.RevokedToken
+}
+
+func newSharedStorage() *sharedStorage {
+	return &sharedStorage{tokens: make(map[string]*models.RevokedToken)}
+}
+
+func (s *sharedStorage) Upsert(token *models.RevokedToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row := *token
+	if old, ok := s.tokens[token.Key]; ok {
+		if old.RevokedAt.After(row.RevokedAt) {
+			row.RevokedAt = old.RevokedAt
+		}
+
+		if old.ExpiresAt.After(row.ExpiresAt) {
+			row.ExpiresAt = old.ExpiresAt
+		}
+	}
+
+	s.tokens[token.Key] = &row
+	return nil
+}
+
+func (s *sharedStorage) GetAll() ([]*models.RevokedToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tokens []*models.RevokedToken
+	for _, token := range s.tokens {
+		row := *token
+		tokens = append(tokens, &row)
+	}
+
+	return tokens, nil
+}
+
+func (s *sharedStorage) DeleteAllExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, token := range s.tokens {
+		if !token.ExpiresAt.After(time.Now()) {
+			delete(s.tokens, key)
+		}
+	}
+
+	return nil
+}
+
+func TestRevokedTokenCacheSync(t *testing.T) {
+	storage := newSharedStorage()
+	first := NewRevokedTokenCache(storage)
+	second := NewRevokedTokenCache(storage)
+
+	now := time.Now()
+
+	// one entry of every kind
+	keys := []string{"jti:token", "session:branch", "user:id", "suspended:id"}
+
+	for _, key := range keys {
+		err := first.Upsert(&models.RevokedToken{Key: key, RevokedAt: now, ExpiresAt: now.Add(time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := first.Get(key); err != nil {
+			t.Errorf("%s: not cached by the instance that revoked it: %v", key, err)
+		}
+
+		if _, err := second.Get(key); err != models.ErrRevokedTokenNotFound {
+			t.Errorf("%s: got %v before the sync, want %v", key, err, models.ErrRevokedTokenNotFound)
+		}
+	}
+
+	if err := second.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	for _, key := range keys {
+		if _, err := second.Get(key); err != nil {
+			t.Errorf("%s: not loaded by the sync: %v", key, err)
+		}
+	}
+}
+
+func TestRevokedTokenCacheMovesForward(t *testing.T) {
+	storage := newSharedStorage()
+	cache := NewRevokedTokenCache(storage)
+
+	now := time.Now()
+
+	if err := cache.Upsert(&models.RevokedToken{Key: "user:id", RevokedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// another instance wrote an older revocation, which must not pull the
+	// cached one back
+	storage.mu.Lock()
+	storage.tokens["user:id"] = &models.RevokedToken{Key: "user:id", RevokedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Minute)}
+	storage.mu.Unlock()
+
+	if err := cache.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	token, err := cache.Get("user:id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !token.RevokedAt.Equal(now) || !token.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("got revoked at %v until %v, want the later entry kept", token.RevokedAt, token.ExpiresAt)
+	}
+
+	// a later one moves it forward
+	later := now.Add(time.Minute)
+	if err := cache.Upsert(&models.RevokedToken{Key: "user:id", RevokedAt: later, ExpiresAt: later.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if token, _ := cache.Get("user:id"); !token.RevokedAt.Equal(later) {
+		t.Errorf("got revoked at %v, want %v", token.RevokedAt, later)
+	}
+}
+
+func TestRevokedTokenCacheExpiry(t *testing.T) {
+	storage := newSharedStorage()
+	cache := NewRevokedTokenCache(storage)
+
+	now := time.Now()
+
+	entries := map[string]time.Time{
+		"jti:expired":  now.Add(-time.Minute),
+		"jti:live":     now.Add(time.Minute),
+		"user:expired": now.Add(-time.Hour),
+	}
+
+	for key, expiresAt := range entries {
+		if err := cache.Upsert(&models.RevokedToken{Key: key, RevokedAt: now.Add(-2 * time.Hour), ExpiresAt: expiresAt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// expired entries deny nothing even before they are pruned
+	if _, err := cache.Get("jti:expired"); err != models.ErrRevokedTokenNotFound {
+		t.Errorf("expired entry: got %v, want %v", err, models.ErrRevokedTokenNotFound)
+	}
+
+	if tokens, _ := cache.GetAll(); len(tokens) != 1 || tokens[0].Key != "jti:live" {
+		t.Errorf("got %d entries, want only the live one", len(tokens))
+	}
+
+	if err := cache.DeleteAllExpired(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	cache.mu.RLock()
+	cached := len(cache.tokens)
+	cache.mu.RUnlock()
+
+	if cached != 1 {
+		t.Errorf("%d entries cached after pruning, want 1", cached)
+	}
+
+	if tokens, _ := storage.GetAll(); len(tokens) != 1 {
+		t.Errorf("%d entries stored after pruning, want 1", len(tokens))
+	}
+
+	// an expired entry synced from another instance is dropped as well
+	storage.mu.Lock()
+	storage.tokens["session:expired"] = &models.RevokedToken{Key: "session:expired", RevokedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Second)}
+	storage.mu.Unlock()
+
+	if err := cache.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	cache.mu.RLock()
+	_, kept := cache.tokens["session:expired"]
+	cache.mu.RUnlock()
+
+	if kept {
+		t.Error("sync cached an expired entry")
+	}
+}
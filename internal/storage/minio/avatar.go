@@ -0,0 +1,33 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+type AvatarStorage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewAvatarStorage(client *minio.Client, bucket string) *AvatarStorage {
+	return &AvatarStorage{
+		client: client,
+		bucket: bucket,
+	}
+}
+
+// Delete removes the avatar object, a missing object is not an error.
+func (s *AvatarStorage) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := s.client.RemoveObject(ctx, s.bucket, id, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete avatar: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a long-lived personal key for scripts and integrations.
+type APIKey struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Hash   []byte
+	Name   string
+	Scopes []string
+
+	CreatedAt time.Time
+	// nil if the key never expires
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
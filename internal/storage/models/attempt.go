@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// Attempt counts recent failed attempts for a key, such as an account or
+// an ip address.
+type Attempt struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	// zero if the key is not locked
+	LockedUntil time.Time
+}
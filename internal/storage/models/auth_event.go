@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthEventType string
+
+const (
+	AuthEventRegister       AuthEventType = "register"
+	AuthEventConfirm        AuthEventType = "confirm"
+	AuthEventLogin          AuthEventType = "login"
+	AuthEventRefresh        AuthEventType = "refresh"
+	AuthEventRefreshReuse   AuthEventType = "refresh_reuse"
+	AuthEventLogout         AuthEventType = "logout"
+	AuthEventLogoutAll      AuthEventType = "logout_all"
+	AuthEventPasswordChange AuthEventType = "password_change"
+	AuthEventPasswordReset  AuthEventType = "password_reset"
+	AuthEventSuspend        AuthEventType = "suspend"
+	AuthEventUnsuspend      AuthEventType = "unsuspend"
+)
+
+type AuthEventOutcome string
+
+const (
+	AuthEventSuccess AuthEventOutcome = "success"
+	AuthEventFailure AuthEventOutcome = "failure"
+)
+
+// AuthEvent is an entry of the security audit log.
+type AuthEvent struct {
+	ID uuid.UUID
+	// null if the attempt did not match an account
+	UserID uuid.NullUUID
+	// the administrator who acted on the account, null for the user's own
+	// actions
+	ActorID uuid.NullUUID
+	Type    AuthEventType
+	Outcome AuthEventOutcome
+	// why the attempt failed
+	Detail string
+
+	UserAgent string
+	IP        string
+
+	CreatedAt time.Time
+}
+
+// AuthEventFilter selects events, zero fields match any event. Events are
+// returned newest first, Before continues after the given event.
+type AuthEventFilter struct {
+	UserID uuid.NullUUID
+	// the administrator who acted on the account, null for the user's own
+	// actions
+	ActorID uuid.NullUUID
+	Type    AuthEventType
+	Outcome AuthEventOutcome
+	IP      string
+	Since   time.Time
+	Until   time.Time
+
+	Before uuid.NullUUID
+	Limit  int
+}
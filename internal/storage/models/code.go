@@ -10,8 +10,14 @@ import (
 type CodeScope string
 
 const (
-	CodeScopeReset   CodeScope = "reset"
-	CodeScopeConfirm CodeScope = "confirm"
+	CodeScopeReset    CodeScope = "reset"
+	CodeScopeConfirm  CodeScope = "confirm"
+	CodeScopeRecovery CodeScope = "recovery"
+	CodeScopeMFA      CodeScope = "mfa"
+	CodeScopeWebAuthn CodeScope = "webauthn"
+	CodeScopeUnlock   CodeScope = "unlock"
+	CodeScopeLogin    CodeScope = "login"
+	CodeScopeRestore  CodeScope = "restore"
 )
 
 type Code struct {
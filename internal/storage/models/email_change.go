@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailChange is a pending change of the user's email, applied once the
+// new address is confirmed with the code sent to it.
+type EmailChange struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	NewEmail string
+
+	// code sent to the new address
+	CodeHash []byte
+	// secret of the cancel link sent to the old address
+	CancelHash []byte
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+var (
+	ErrEmailChangeNotFound = errors.New("email change not found")
+)
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links an account at an external OpenID Connect provider to a user.
+type Identity struct {
+	Provider string
+	Subject  string
+	UserID   uuid.UUID
+	Email    string
+
+	CreatedAt time.Time
+}
+
+// OIDCState is a login started at an external provider and not finished yet.
+type OIDCState struct {
+	ID       uuid.UUID
+	Provider string
+	Hash     []byte
+
+	Nonce    string
+	Verifier string
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+var (
+	ErrIdentityNotFound  = errors.New("identity not found")
+	ErrOIDCStateNotFound = errors.New("oidc state not found")
+)
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient is a partner app that acts on behalf of users.
+type OAuthClient struct {
+	ID      uuid.UUID
+	OwnerID uuid.UUID
+	// nil for public clients, which can not keep a secret
+	SecretHash []byte
+	Name       string
+
+	RedirectURIs []string
+	Scopes       []string
+	GrantTypes   []string
+
+	CreatedAt time.Time
+}
+
+// OAuthCode is an authorization code issued to a client.
+type OAuthCode struct {
+	ID       uuid.UUID
+	ClientID uuid.UUID
+	UserID   uuid.UUID
+	Hash     []byte
+
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Nonce         string
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// OAuthConsent holds the scopes a user has granted to a client.
+type OAuthConsent struct {
+	UserID   uuid.UUID
+	ClientID uuid.UUID
+	Scopes   []string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var (
+	ErrOAuthClientNotFound  = errors.New("oauth client not found")
+	ErrOAuthCodeNotFound    = errors.New("oauth code not found")
+	ErrOAuthConsentNotFound = errors.New("oauth consent not found")
+)
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey is a WebAuthn credential registered by a user.
+type Passkey struct {
+	// credential id chosen by the authenticator
+	ID     []byte
+	UserID uuid.UUID
+	Name   string
+
+	// COSE_Key
+	PublicKey []byte
+	SignCount uint32
+
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+var (
+	ErrPasskeyNotFound  = errors.New("passkey not found")
+	ErrDuplicatePasskey = errors.New("passkey is already registered")
+)
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// RevokedToken denies access tokens before they expire. The key names a
+// single token by its jti, or a session or a user, whose tokens issued up
+// to RevokedAt are all denied.
+type RevokedToken struct {
+	Key       string
+	RevokedAt time.Time
+	// every token the entry denies has expired by then
+	ExpiresAt time.Time
+}
+
+var (
+	ErrRevokedTokenNotFound = errors.New("revoked token not found")
+)
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"errors"
+)
+
+const (
+	RoleMember     = "member"
+	RoleTrainer    = "trainer"
+	RoleGymAdmin   = "gym_admin"
+	RoleSuperadmin = "superadmin"
+)
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+var (
+	ErrRoleNotFound     = errors.New("role not found")
+	ErrUserRoleNotFound = errors.New("user does not have the role")
+)
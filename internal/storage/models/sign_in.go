@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignIn is a login from a new device the user was alerted about, kept
+// for the "this wasn't me" link of the alert.
+type SignIn struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// the session opened by the login
+	Branch     uuid.UUID
+	ReportHash []byte
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// DeviceHistory tells whether the user has logged in before, and whether
+// from the same user agent and ip.
+type DeviceHistory struct {
+	HasLogins     bool
+	UserAgentSeen bool
+	IPSeen        bool
+}
+
+var (
+	ErrSignInNotFound = errors.New("sign in not found")
+)
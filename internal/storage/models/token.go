@@ -31,10 +31,31 @@ type Token struct {
 	Status TokenStatus
 	Scope  TokenScope
 
+	// device the token was issued to
+	UserAgent string
+	IP        string
+
+	// set for tokens issued to partner apps
+	ClientID uuid.NullUUID
+	Scopes   []string
+
 	CreatedAt time.Time
 	ExpiresAt time.Time
 }
 
+// Session is a refresh token branch that can still be refreshed.
+type Session struct {
+	Branch uuid.UUID
+	// device of the latest refresh
+	UserAgent string
+	IP        string
+	ClientID  uuid.NullUUID
+
+	// login time and time of the latest refresh
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
 var (
 	ErrTokenNotFound = errors.New("token not found")
 )
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TOTP struct {
+	UserID uuid.UUID
+	Secret []byte
+
+	// the secret is unconfirmed until the user enters the first valid code
+	Enabled bool
+	// time step of the last accepted code, codes can not be replayed
+	LastUsedStep int64
+
+	CreatedAt time.Time
+}
+
+var (
+	ErrTOTPNotFound = errors.New("totp not found")
+	ErrTOTPStepUsed = errors.New("totp step already used")
+)
@@ -10,9 +10,10 @@ import (
 type UserState string
 
 const (
-	UserStatePending UserState = "pending"
-	UserStateActive  UserState = "active"
-	UserStateDeleted UserState = "deleted"
+	UserStatePending   UserState = "pending"
+	UserStateActive    UserState = "active"
+	UserStateDeleted   UserState = "deleted"
+	UserStateSuspended UserState = "suspended"
 )
 
 type User struct {
@@ -25,8 +26,19 @@ type User struct {
 	FirstName string
 	LastName  string
 
+	// whether logins from new devices are reported by email
+	SignInAlerts bool
+
+	// set while the user is suspended, SuspendedUntil is nil if the
+	// suspension has no end date
+	SuspensionReason string
+	SuspendedBy      uuid.NullUUID
+	SuspendedUntil   *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// when the account was deleted, nil unless it waits for the purge
+	DeletedAt *time.Time
 }
 
 var (
@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type APIKeyStorage struct {
+	db *sql.DB
+}
+
+func NewAPIKeyStorage(db *sql.DB) *APIKeyStorage {
+	return &APIKeyStorage{
+		db: db,
+	}
+}
+
+func (s *APIKeyStorage) Insert(key *models.APIKey) error {
+	stmt := `
+		INSERT INTO api_keys (
+			user_id, hash, name, scopes, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		key.UserID,
+		key.Hash,
+		key.Name,
+		pq.Array(key.Scopes),
+		key.ExpiresAt,
+	).Scan(
+		&key.ID,
+		&key.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *APIKeyStorage) GetByID(id uuid.UUID) (*models.APIKey, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			hash,
+			name,
+			scopes,
+			created_at,
+			expires_at,
+			last_used_at
+		FROM api_keys
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var key models.APIKey
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Hash,
+		&key.Name,
+		pq.Array(&key.Scopes),
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrAPIKeyNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get api key by id: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (s *APIKeyStorage) GetAllByUser(userID uuid.UUID) ([]*models.APIKey, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			hash,
+			name,
+			scopes,
+			created_at,
+			expires_at,
+			last_used_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys for user: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Hash,
+			&key.Name,
+			pq.Array(&key.Scopes),
+			&key.CreatedAt,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+
+		keys = append(keys, &key)
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyStorage) UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error {
+	stmt := `
+		UPDATE api_keys
+		SET last_used_at = $1 WHERE id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, lastUsedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update api key last use: %w", err)
+	}
+
+	return nil
+}
+
+func (s *APIKeyStorage) Delete(userID uuid.UUID, id uuid.UUID) error {
+	stmt := `
+		DELETE FROM api_keys
+		WHERE user_id = $1 AND id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return models.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
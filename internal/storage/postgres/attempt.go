@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type AttemptStorage struct {
+	db *sql.DB
+}
+
+func NewAttemptStorage(db *sql.DB) *AttemptStorage {
+	return &AttemptStorage{
+		db: db,
+	}
+}
+
+func (s *AttemptStorage) Get(key string) (*models.Attempt, error) {
+	stmt := `
+		SELECT
+			key,
+			failures,
+			last_failed_at,
+			locked_until
+		FROM login_attempts
+		WHERE key = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var attempt models.Attempt
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, stmt, key).Scan(
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LastFailedAt,
+		&lockedUntil,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.Attempt{Key: key}, nil
+		}
+
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	attempt.LockedUntil = lockedUntil.Time
+	return &attempt, nil
+}
+
+func (s *AttemptStorage) RecordFailure(key string, window time.Duration) (*models.Attempt, error) {
+	// failures older than the window are forgotten
+	stmt := `
+		INSERT INTO login_attempts (
+			key, failures, last_failed_at
+		) VALUES (
+			$1, 1, NOW()
+		) ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_attempts.last_failed_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failed_at = NOW()
+		RETURNING key, failures, last_failed_at, locked_until
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var attempt models.Attempt
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, stmt, key, window.Seconds()).Scan(
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LastFailedAt,
+		&lockedUntil,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to record failed attempt: %w", err)
+	}
+
+	attempt.LockedUntil = lockedUntil.Time
+	return &attempt, nil
+}
+
+func (s *AttemptStorage) Lock(key string, until time.Time) error {
+	stmt := `
+		UPDATE login_attempts
+		SET locked_until = $1, failures = 0 WHERE key = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, until, key)
+	if err != nil {
+		return fmt.Errorf("failed to lock key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AttemptStorage) Reset(key string) error {
+	stmt := `
+		DELETE FROM login_attempts
+		WHERE key = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, key)
+	if err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AttemptStorage) DeleteAllStale(before time.Time) error {
+	stmt := `
+		DELETE FROM login_attempts
+		WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < NOW())
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, before)
+	if err != nil {
+		return fmt.Errorf("failed to delete stale login attempts: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type AuthEventStorage struct {
+	db *sql.DB
+}
+
+func NewAuthEventStorage(db *sql.DB) *AuthEventStorage {
+	return &AuthEventStorage{
+		db: db,
+	}
+}
+
+func (s *AuthEventStorage) Insert(event *models.AuthEvent) error {
+	stmt := `
+		INSERT INTO auth_events (
+			user_id, actor_id, type, outcome, detail, user_agent, ip
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		event.UserID,
+		event.ActorID,
+		event.Type,
+		event.Outcome,
+		event.Detail,
+		event.UserAgent,
+		event.IP,
+	).Scan(
+		&event.ID,
+		&event.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert auth event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuthEventStorage) GetAll(filter *models.AuthEventFilter) ([]*models.AuthEvent, error) {
+	var conditions []string
+	var args []any
+
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID.Valid {
+		where("user_id = $%d", filter.UserID.UUID)
+	}
+
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+
+	if filter.Outcome != "" {
+		where("outcome = $%d", filter.Outcome)
+	}
+
+	if filter.IP != "" {
+		where("ip = $%d", filter.IP)
+	}
+
+	if !filter.Since.IsZero() {
+		where("created_at >= $%d", filter.Since)
+	}
+
+	if !filter.Until.IsZero() {
+		where("created_at < $%d", filter.Until)
+	}
+
+	// events of the same instant are ordered by id
+	if filter.Before.Valid {
+		where("(created_at, id) < (SELECT created_at, id FROM auth_events WHERE id = $%d)", filter.Before.UUID)
+	}
+
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			actor_id,
+			type,
+			outcome,
+			detail,
+			COALESCE(user_agent, ''),
+			COALESCE(ip, ''),
+			created_at
+		FROM auth_events
+	`
+
+	if len(conditions) > 0 {
+		stmt += "WHERE " + strings.Join(conditions, " AND ") + "\n"
+	}
+
+	args = append(args, filter.Limit)
+	stmt += fmt.Sprintf("ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.AuthEvent
+	for rows.Next() {
+		var event models.AuthEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.UserID,
+			&event.ActorID,
+			&event.Type,
+			&event.Outcome,
+			&event.Detail,
+			&event.UserAgent,
+			&event.IP,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to get auth events: %w", err)
+		}
+
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+func (s *AuthEventStorage) GetDeviceHistory(userID uuid.UUID, userAgent string, ip string) (*models.DeviceHistory, error) {
+	stmt := `
+		WITH logins AS (
+			SELECT user_agent, ip
+			FROM auth_events
+			WHERE user_id = $1 AND type = 'login' AND outcome = 'success'
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM logins),
+			EXISTS (SELECT 1 FROM logins WHERE user_agent = $2),
+			EXISTS (SELECT 1 FROM logins WHERE ip = $3)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var history models.DeviceHistory
+	err := s.db.QueryRowContext(ctx, stmt, userID, userAgent, ip).Scan(
+		&history.HasLogins,
+		&history.UserAgentSeen,
+		&history.IPSeen,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device history: %w", err)
+	}
+
+	return &history, nil
+}
@@ -52,7 +52,40 @@ func (s *CodeStorage) Insert(code *models.Code) error {
 }
 
 func (s *CodeStorage) GetByID(id uuid.UUID) (*models.Code, error) {
-	return nil, nil
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			hash,
+			scope,
+			expires_at,
+			created_at
+		FROM codes
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var code models.Code
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&code.ID,
+		&code.UserID,
+		&code.Hash,
+		&code.Scope,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrCodeNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get code by id: %w", err)
+	}
+
+	return &code, nil
 }
 
 func (s *CodeStorage) GetAllByUser(userID uuid.UUID, scope models.CodeScope) ([]*models.Code, error) {
@@ -97,16 +130,59 @@ func (s *CodeStorage) GetAllByUser(userID uuid.UUID, scope models.CodeScope) ([]
 	return codes, nil
 }
 
-func (s *CodeStorage) DeleteAllByUser(userID uuid.UUID) error {
+func (s *CodeStorage) DeleteByID(id uuid.UUID) error {
 	stmt := `
 		DELETE FROM codes
-		WHERE user_id = $1
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete code by id: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CodeStorage) Consume(id uuid.UUID) error {
+	stmt := `
+		DELETE FROM codes
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to consume code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to consume code: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrCodeNotFound
+	}
+
+	return nil
+}
+
+func (s *CodeStorage) DeleteAllByUser(userID uuid.UUID, scope models.CodeScope) error {
+	stmt := `
+		DELETE FROM codes
+		WHERE user_id = $1 AND scope = $2
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, stmt, userID)
+	_, err := s.db.ExecContext(ctx, stmt, userID, scope)
 	if err != nil {
 		return fmt.Errorf("failed to delete codes for user: %w", err)
 	}
@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type EmailChangeStorage struct {
+	db *sql.DB
+}
+
+func NewEmailChangeStorage(db *sql.DB) *EmailChangeStorage {
+	return &EmailChangeStorage{
+		db: db,
+	}
+}
+
+func (s *EmailChangeStorage) Upsert(change *models.EmailChange) error {
+	stmt := `
+		INSERT INTO email_changes (
+			user_id, new_email, code_hash, cancel_hash, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) ON CONFLICT (user_id) DO UPDATE
+		SET id = gen_random_uuid(),
+			new_email = EXCLUDED.new_email,
+			code_hash = EXCLUDED.code_hash,
+			cancel_hash = EXCLUDED.cancel_hash,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		change.UserID,
+		change.NewEmail,
+		change.CodeHash,
+		change.CancelHash,
+		change.ExpiresAt,
+	).Scan(
+		&change.ID,
+		&change.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert email change: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EmailChangeStorage) GetByID(id uuid.UUID) (*models.EmailChange, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			new_email,
+			code_hash,
+			cancel_hash,
+			created_at,
+			expires_at
+		FROM email_changes
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var change models.EmailChange
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&change.ID,
+		&change.UserID,
+		&change.NewEmail,
+		&change.CodeHash,
+		&change.CancelHash,
+		&change.CreatedAt,
+		&change.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrEmailChangeNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get email change by id: %w", err)
+	}
+
+	return &change, nil
+}
+
+func (s *EmailChangeStorage) GetByUser(userID uuid.UUID) (*models.EmailChange, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			new_email,
+			code_hash,
+			cancel_hash,
+			created_at,
+			expires_at
+		FROM email_changes
+		WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var change models.EmailChange
+	err := s.db.QueryRowContext(ctx, stmt, userID).Scan(
+		&change.ID,
+		&change.UserID,
+		&change.NewEmail,
+		&change.CodeHash,
+		&change.CancelHash,
+		&change.CreatedAt,
+		&change.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrEmailChangeNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get email change by user: %w", err)
+	}
+
+	return &change, nil
+}
+
+// Apply swaps the user's email for the new one and drops the change in one
+// statement, so a change is applied at most once. The new email may have
+// been taken since the change was requested (ErrDuplicateEmail).
+func (s *EmailChangeStorage) Apply(id uuid.UUID) error {
+	stmt := `
+		WITH change AS (
+			DELETE FROM email_changes
+			WHERE id = $1
+			RETURNING user_id, new_email
+		)
+		UPDATE users
+		SET email = change.new_email, updated_at = NOW()
+		FROM change
+		WHERE users.id = change.user_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		if err, ok := err.(*pq.Error); ok {
+			if err.Code.Name() == "unique_violation" && err.Constraint == "users_email_key" {
+				return models.ErrDuplicateEmail
+			}
+		}
+
+		return fmt.Errorf("failed to apply email change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to apply email change: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrEmailChangeNotFound
+	}
+
+	return nil
+}
+
+func (s *EmailChangeStorage) DeleteByID(id uuid.UUID) error {
+	stmt := `
+		DELETE FROM email_changes
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete email change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete email change: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrEmailChangeNotFound
+	}
+
+	return nil
+}
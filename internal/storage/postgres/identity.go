@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type IdentityStorage struct {
+	db *sql.DB
+}
+
+func NewIdentityStorage(db *sql.DB) *IdentityStorage {
+	return &IdentityStorage{
+		db: db,
+	}
+}
+
+func (s *IdentityStorage) Insert(identity *models.Identity) error {
+	stmt := `
+		INSERT INTO identities (
+			provider, subject, user_id, email
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		identity.Provider,
+		identity.Subject,
+		identity.UserID,
+		identity.Email,
+	).Scan(
+		&identity.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert identity: %w", err)
+	}
+
+	return nil
+}
+
+func (s *IdentityStorage) Get(provider string, subject string) (*models.Identity, error) {
+	stmt := `
+		SELECT
+			provider,
+			subject,
+			user_id,
+			email,
+			created_at
+		FROM identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var identity models.Identity
+	err := s.db.QueryRowContext(ctx, stmt, provider, subject).Scan(
+		&identity.Provider,
+		&identity.Subject,
+		&identity.UserID,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrIdentityNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+type OIDCStateStorage struct {
+	db *sql.DB
+}
+
+func NewOIDCStateStorage(db *sql.DB) *OIDCStateStorage {
+	return &OIDCStateStorage{
+		db: db,
+	}
+}
+
+func (s *OIDCStateStorage) Insert(state *models.OIDCState) error {
+	stmt := `
+		INSERT INTO oidc_states (
+			provider, hash, nonce, verifier, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		state.Provider,
+		state.Hash,
+		state.Nonce,
+		state.Verifier,
+		state.ExpiresAt,
+	).Scan(
+		&state.ID,
+		&state.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert oidc state: %w", err)
+	}
+
+	return nil
+}
+
+func (s *OIDCStateStorage) GetByID(id uuid.UUID) (*models.OIDCState, error) {
+	stmt := `
+		SELECT
+			id,
+			provider,
+			hash,
+			nonce,
+			verifier,
+			created_at,
+			expires_at
+		FROM oidc_states
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var state models.OIDCState
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&state.ID,
+		&state.Provider,
+		&state.Hash,
+		&state.Nonce,
+		&state.Verifier,
+		&state.CreatedAt,
+		&state.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOIDCStateNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get oidc state by id: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (s *OIDCStateStorage) DeleteByID(id uuid.UUID) error {
+	stmt := `
+		DELETE FROM oidc_states
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete oidc state: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	// the state was used concurrently
+	if affected == 0 {
+		return models.ErrOIDCStateNotFound
+	}
+
+	return nil
+}
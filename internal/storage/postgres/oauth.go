@@ -0,0 +1,358 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type OAuthClientStorage struct {
+	db *sql.DB
+}
+
+func NewOAuthClientStorage(db *sql.DB) *OAuthClientStorage {
+	return &OAuthClientStorage{
+		db: db,
+	}
+}
+
+func (s *OAuthClientStorage) Insert(client *models.OAuthClient) error {
+	stmt := `
+		INSERT INTO oauth_clients (
+			owner_id, secret_hash, name, redirect_uris, scopes, grant_types
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		client.OwnerID,
+		client.SecretHash,
+		client.Name,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.Scopes),
+		pq.Array(client.GrantTypes),
+	).Scan(
+		&client.ID,
+		&client.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert oauth client: %w", err)
+	}
+
+	return nil
+}
+
+func (s *OAuthClientStorage) GetByID(id uuid.UUID) (*models.OAuthClient, error) {
+	stmt := `
+		SELECT
+			id,
+			owner_id,
+			secret_hash,
+			name,
+			redirect_uris,
+			scopes,
+			grant_types,
+			created_at
+		FROM oauth_clients
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var client models.OAuthClient
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&client.ID,
+		&client.OwnerID,
+		&client.SecretHash,
+		&client.Name,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes),
+		pq.Array(&client.GrantTypes),
+		&client.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOAuthClientNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get oauth client by id: %w", err)
+	}
+
+	return &client, nil
+}
+
+func (s *OAuthClientStorage) GetAllByOwner(ownerID uuid.UUID) ([]*models.OAuthClient, error) {
+	stmt := `
+		SELECT
+			id,
+			owner_id,
+			secret_hash,
+			name,
+			redirect_uris,
+			scopes,
+			grant_types,
+			created_at
+		FROM oauth_clients
+		WHERE owner_id = $1
+		ORDER BY created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth clients for owner: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []*models.OAuthClient
+	for rows.Next() {
+		var client models.OAuthClient
+		if err := rows.Scan(
+			&client.ID,
+			&client.OwnerID,
+			&client.SecretHash,
+			&client.Name,
+			pq.Array(&client.RedirectURIs),
+			pq.Array(&client.Scopes),
+			pq.Array(&client.GrantTypes),
+			&client.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan oauth client: %w", err)
+		}
+
+		clients = append(clients, &client)
+	}
+
+	return clients, nil
+}
+
+func (s *OAuthClientStorage) Delete(ownerID uuid.UUID, id uuid.UUID) error {
+	stmt := `
+		DELETE FROM oauth_clients
+		WHERE owner_id = $1 AND id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, ownerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth client: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return models.ErrOAuthClientNotFound
+	}
+
+	return nil
+}
+
+type OAuthCodeStorage struct {
+	db *sql.DB
+}
+
+func NewOAuthCodeStorage(db *sql.DB) *OAuthCodeStorage {
+	return &OAuthCodeStorage{
+		db: db,
+	}
+}
+
+func (s *OAuthCodeStorage) Insert(code *models.OAuthCode) error {
+	stmt := `
+		INSERT INTO oauth_codes (
+			client_id, user_id, hash, redirect_uri, scopes, code_challenge, nonce, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		code.ClientID,
+		code.UserID,
+		code.Hash,
+		code.RedirectURI,
+		pq.Array(code.Scopes),
+		code.CodeChallenge,
+		code.Nonce,
+		code.ExpiresAt,
+	).Scan(
+		&code.ID,
+		&code.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert oauth code: %w", err)
+	}
+
+	return nil
+}
+
+func (s *OAuthCodeStorage) GetByID(id uuid.UUID) (*models.OAuthCode, error) {
+	stmt := `
+		SELECT
+			id,
+			client_id,
+			user_id,
+			hash,
+			redirect_uri,
+			scopes,
+			code_challenge,
+			nonce,
+			created_at,
+			expires_at
+		FROM oauth_codes
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var code models.OAuthCode
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&code.ID,
+		&code.ClientID,
+		&code.UserID,
+		&code.Hash,
+		&code.RedirectURI,
+		pq.Array(&code.Scopes),
+		&code.CodeChallenge,
+		&code.Nonce,
+		&code.CreatedAt,
+		&code.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOAuthCodeNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get oauth code by id: %w", err)
+	}
+
+	return &code, nil
+}
+
+func (s *OAuthCodeStorage) DeleteByID(id uuid.UUID) error {
+	stmt := `
+		DELETE FROM oauth_codes
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	// the code was redeemed concurrently
+	if affected == 0 {
+		return models.ErrOAuthCodeNotFound
+	}
+
+	return nil
+}
+
+type OAuthConsentStorage struct {
+	db *sql.DB
+}
+
+func NewOAuthConsentStorage(db *sql.DB) *OAuthConsentStorage {
+	return &OAuthConsentStorage{
+		db: db,
+	}
+}
+
+func (s *OAuthConsentStorage) Upsert(consent *models.OAuthConsent) error {
+	stmt := `
+		INSERT INTO oauth_consents (
+			user_id, client_id, scopes
+		) VALUES (
+			$1, $2, $3
+		) ON CONFLICT (user_id, client_id) DO UPDATE
+		SET scopes = EXCLUDED.scopes, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		consent.UserID,
+		consent.ClientID,
+		pq.Array(consent.Scopes),
+	).Scan(
+		&consent.CreatedAt,
+		&consent.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert oauth consent: %w", err)
+	}
+
+	return nil
+}
+
+func (s *OAuthConsentStorage) Get(userID uuid.UUID, clientID uuid.UUID) (*models.OAuthConsent, error) {
+	stmt := `
+		SELECT
+			user_id,
+			client_id,
+			scopes,
+			created_at,
+			updated_at
+		FROM oauth_consents
+		WHERE user_id = $1 AND client_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var consent models.OAuthConsent
+	err := s.db.QueryRowContext(ctx, stmt, userID, clientID).Scan(
+		&consent.UserID,
+		&consent.ClientID,
+		pq.Array(&consent.Scopes),
+		&consent.CreatedAt,
+		&consent.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOAuthConsentNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get oauth consent: %w", err)
+	}
+
+	return &consent, nil
+}
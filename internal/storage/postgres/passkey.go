@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type PasskeyStorage struct {
+	db *sql.DB
+}
+
+func NewPasskeyStorage(db *sql.DB) *PasskeyStorage {
+	return &PasskeyStorage{
+		db: db,
+	}
+}
+
+func (s *PasskeyStorage) Insert(passkey *models.Passkey) error {
+	stmt := `
+		INSERT INTO passkeys (
+			id, user_id, name, public_key, sign_count
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING created_at, last_used_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		passkey.ID,
+		passkey.UserID,
+		passkey.Name,
+		passkey.PublicKey,
+		int64(passkey.SignCount),
+	).Scan(
+		&passkey.CreatedAt,
+		&passkey.LastUsedAt,
+	)
+
+	if err != nil {
+		if err, ok := err.(*pq.Error); ok {
+			if err.Code.Name() == "unique_violation" && err.Constraint == "passkeys_pkey" {
+				return models.ErrDuplicatePasskey
+			}
+		}
+
+		return fmt.Errorf("failed to insert passkey: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PasskeyStorage) GetByID(id []byte) (*models.Passkey, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			name,
+			public_key,
+			sign_count,
+			created_at,
+			last_used_at
+		FROM passkeys
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var passkey models.Passkey
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&passkey.ID,
+		&passkey.UserID,
+		&passkey.Name,
+		&passkey.PublicKey,
+		&passkey.SignCount,
+		&passkey.CreatedAt,
+		&passkey.LastUsedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrPasskeyNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get passkey by id: %w", err)
+	}
+
+	return &passkey, nil
+}
+
+func (s *PasskeyStorage) GetAllByUser(userID uuid.UUID) ([]*models.Passkey, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			name,
+			public_key,
+			sign_count,
+			created_at,
+			last_used_at
+		FROM passkeys
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys for user: %w", err)
+	}
+	defer rows.Close()
+
+	var passkeys []*models.Passkey
+	for rows.Next() {
+		var passkey models.Passkey
+		if err := rows.Scan(
+			&passkey.ID,
+			&passkey.UserID,
+			&passkey.Name,
+			&passkey.PublicKey,
+			&passkey.SignCount,
+			&passkey.CreatedAt,
+			&passkey.LastUsedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan passkey: %w", err)
+		}
+
+		passkeys = append(passkeys, &passkey)
+	}
+
+	return passkeys, nil
+}
+
+func (s *PasskeyStorage) UpdateSignCount(id []byte, signCount uint32) error {
+	stmt := `
+		UPDATE passkeys
+		SET sign_count = $2, last_used_at = NOW()
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, int64(signCount))
+	if err != nil {
+		return fmt.Errorf("failed to update passkey sign count: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update passkey sign count: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+func (s *PasskeyStorage) Delete(userID uuid.UUID, id []byte) error {
+	stmt := `
+		DELETE FROM passkeys
+		WHERE user_id = $1 AND id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrPasskeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type RevokedTokenStorage struct {
+	db *sql.DB
+}
+
+func NewRevokedTokenStorage(db *sql.DB) *RevokedTokenStorage {
+	return &RevokedTokenStorage{
+		db: db,
+	}
+}
+
+func (s *RevokedTokenStorage) Upsert(token *models.RevokedToken) error {
+	// a repeated revocation only moves the entry forward
+	stmt := `
+		INSERT INTO revoked_tokens (
+			key, revoked_at, expires_at
+		) VALUES (
+			$1, $2, $3
+		) ON CONFLICT (key) DO UPDATE
+		SET revoked_at = GREATEST(revoked_tokens.revoked_at, EXCLUDED.revoked_at),
+			expires_at = GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, token.Key, token.RevokedAt, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert revoked token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RevokedTokenStorage) Get(key string) (*models.RevokedToken, error) {
+	stmt := `
+		SELECT
+			key,
+			revoked_at,
+			expires_at
+		FROM revoked_tokens
+		WHERE key = $1 AND expires_at > NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var token models.RevokedToken
+	err := s.db.QueryRowContext(ctx, stmt, key).Scan(
+		&token.Key,
+		&token.RevokedAt,
+		&token.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrRevokedTokenNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get revoked token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (s *RevokedTokenStorage) GetAll() ([]*models.RevokedToken, error) {
+	stmt := `
+		SELECT
+			key,
+			revoked_at,
+			expires_at
+		FROM revoked_tokens
+		WHERE expires_at > NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revoked tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.RevokedToken
+	for rows.Next() {
+		var token models.RevokedToken
+		if err := rows.Scan(
+			&token.Key,
+			&token.RevokedAt,
+			&token.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to get revoked tokens: %w", err)
+		}
+
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, nil
+}
+
+func (s *RevokedTokenStorage) DeleteAllExpired() error {
+	stmt := `
+		DELETE FROM revoked_tokens
+		WHERE expires_at <= NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type RoleStorage struct {
+	db *sql.DB
+}
+
+func NewRoleStorage(db *sql.DB) *RoleStorage {
+	return &RoleStorage{
+		db: db,
+	}
+}
+
+func (s *RoleStorage) GetAll() ([]*models.Role, error) {
+	stmt := `
+		SELECT
+			r.name,
+			r.description,
+			COALESCE(ARRAY_AGG(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name, r.description
+		ORDER BY r.name
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(
+			&role.Name,
+			&role.Description,
+			pq.Array(&role.Permissions),
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+
+		roles = append(roles, &role)
+	}
+
+	return roles, nil
+}
+
+func (s *RoleStorage) GetPermissionsByRole(role string) ([]string, error) {
+	stmt := `
+		SELECT
+			COALESCE(ARRAY_AGG(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		WHERE r.name = $1
+		GROUP BY r.name
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var permissions []string
+	err := s.db.QueryRowContext(ctx, stmt, role).Scan(pq.Array(&permissions))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrRoleNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get permissions of role: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// GetByUser returns the roles of the user and the permissions they grant.
+func (s *RoleStorage) GetByUser(userID uuid.UUID) ([]string, []string, error) {
+	stmt := `
+		SELECT
+			COALESCE(ARRAY_AGG(DISTINCT ur.role), '{}'),
+			COALESCE(ARRAY_AGG(DISTINCT rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM user_roles ur
+		LEFT JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var roles, permissions []string
+	err := s.db.QueryRowContext(ctx, stmt, userID).Scan(
+		pq.Array(&roles),
+		pq.Array(&permissions),
+	)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get roles of user: %w", err)
+	}
+
+	return roles, permissions, nil
+}
+
+func (s *RoleStorage) CountUsers(role string) (int, error) {
+	stmt := `
+		SELECT COUNT(*)
+		FROM user_roles
+		WHERE role = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, stmt, role).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
+	}
+
+	return count, nil
+}
+
+// Grant is a no-op if the user already has the role.
+func (s *RoleStorage) Grant(userID uuid.UUID, role string, grantedBy uuid.NullUUID) error {
+	stmt := `
+		INSERT INTO user_roles (
+			user_id, role, granted_by
+		) VALUES (
+			$1, $2, $3
+		) ON CONFLICT (user_id, role) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, userID, role, grantedBy)
+	if err != nil {
+		if err, ok := err.(*pq.Error); ok {
+			if err.Code.Name() == "foreign_key_violation" && err.Constraint == "user_roles_role_fkey" {
+				return models.ErrRoleNotFound
+			}
+		}
+
+		return fmt.Errorf("failed to grant role: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RoleStorage) Revoke(userID uuid.UUID, role string) error {
+	stmt := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return models.ErrUserRoleNotFound
+	}
+
+	return nil
+}
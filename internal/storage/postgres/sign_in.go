@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type SignInStorage struct {
+	db *sql.DB
+}
+
+func NewSignInStorage(db *sql.DB) *SignInStorage {
+	return &SignInStorage{
+		db: db,
+	}
+}
+
+func (s *SignInStorage) Insert(signIn *models.SignIn) error {
+	stmt := `
+		INSERT INTO sign_ins (
+			user_id, branch, report_hash, expires_at
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		signIn.UserID,
+		signIn.Branch,
+		signIn.ReportHash,
+		signIn.ExpiresAt,
+	).Scan(
+		&signIn.ID,
+		&signIn.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert sign in: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SignInStorage) GetByID(id uuid.UUID) (*models.SignIn, error) {
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			branch,
+			report_hash,
+			created_at,
+			expires_at
+		FROM sign_ins
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var signIn models.SignIn
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&signIn.ID,
+		&signIn.UserID,
+		&signIn.Branch,
+		&signIn.ReportHash,
+		&signIn.CreatedAt,
+		&signIn.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrSignInNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get sign in by id: %w", err)
+	}
+
+	return &signIn, nil
+}
+
+func (s *SignInStorage) DeleteByID(id uuid.UUID) error {
+	stmt := `
+		DELETE FROM sign_ins
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sign in: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete sign in: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrSignInNotFound
+	}
+
+	return nil
+}
+
+func (s *SignInStorage) DeleteAllExpired() error {
+	stmt := `
+		DELETE FROM sign_ins
+		WHERE expires_at <= NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired sign ins: %w", err)
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type TokenStorage struct {
@@ -23,9 +24,9 @@ func NewTokenStorage(db *sql.DB) *TokenStorage {
 func (s *TokenStorage) Insert(token *models.Token) error {
 	stmt := `
 		INSERT INTO tokens (
-			user_id, hash, branch, status, scope, created_at, expires_at
+			user_id, hash, branch, status, scope, user_agent, ip, client_id, scopes, expires_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
+			$1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9::TEXT[], '{}'), $10
 		) RETURNING id, created_at
 	`
 
@@ -38,7 +39,10 @@ func (s *TokenStorage) Insert(token *models.Token) error {
 		token.Branch,
 		token.Status,
 		token.Scope,
-		token.CreatedAt,
+		token.UserAgent,
+		token.IP,
+		token.ClientID,
+		pq.Array(token.Scopes),
 		token.ExpiresAt,
 	).Scan(
 		&token.ID,
@@ -61,34 +65,47 @@ func (s *TokenStorage) InsertChild(parentID uuid.UUID, token *models.Token) erro
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 
+	// the parent is only rotated while it is still active, so two concurrent
+	// refreshes with the same token can not both succeed
 	stmt := `
-		UPDATE tokens 
-		SET status = $1 WHERE id = $2 
+		UPDATE tokens
+		SET status = $1 WHERE id = $2 AND status = $3
 		RETURNING branch
 	`
 
-	err = tx.QueryRowContext(ctx, stmt, models.TokenStatusUsed, parentID).Scan(&token.Branch)
+	err = tx.QueryRowContext(ctx, stmt, models.TokenStatusUsed, parentID, models.TokenStatusActive).Scan(&token.Branch)
 	if err != nil {
 		_ = tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return models.ErrTokenNotFound
+		}
+
 		return fmt.Errorf("failed to update parent token in transaction: %w", err)
 	}
 
 	stmt = `
 		INSERT INTO tokens (
-			user_id, hash, branch, status, scope, created_at, expires_at
+			user_id, hash, branch, status, scope, user_agent, ip, client_id, scopes, expires_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
-		)
+			$1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9::TEXT[], '{}'), $10
+		) RETURNING id, created_at
 	`
 
-	_, err = tx.ExecContext(ctx, stmt,
+	err = tx.QueryRowContext(ctx, stmt,
 		token.UserID,
 		token.Hash,
 		token.Branch,
 		token.Status,
 		token.Scope,
-		token.CreatedAt,
+		token.UserAgent,
+		token.IP,
+		token.ClientID,
+		pq.Array(token.Scopes),
 		token.ExpiresAt,
+	).Scan(
+		&token.ID,
+		&token.CreatedAt,
 	)
 
 	if err != nil {
@@ -105,11 +122,97 @@ func (s *TokenStorage) InsertChild(parentID uuid.UUID, token *models.Token) erro
 }
 
 func (s *TokenStorage) GetByID(id uuid.UUID) (*models.Token, error) {
-	return nil, nil
+	stmt := `
+		SELECT
+			id,
+			user_id,
+			hash,
+			branch,
+			status,
+			scope,
+			user_agent,
+			ip,
+			client_id,
+			scopes,
+			created_at,
+			expires_at
+		FROM tokens
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var token models.Token
+	err := s.db.QueryRowContext(ctx, stmt, id).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Hash,
+		&token.Branch,
+		&token.Status,
+		&token.Scope,
+		&token.UserAgent,
+		&token.IP,
+		&token.ClientID,
+		pq.Array(&token.Scopes),
+		&token.CreatedAt,
+		&token.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrTokenNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get token by id: %w", err)
+	}
+
+	return &token, nil
 }
 
-func (s *TokenStorage) GetAllByUser(userID uuid.UUID) ([]*models.Token, error) {
-	return nil, nil
+func (s *TokenStorage) GetSessions(userID uuid.UUID) ([]*models.Session, error) {
+	// only the latest token of a branch is active, the login time comes from
+	// the first token of the branch
+	stmt := `
+		SELECT
+			t.branch,
+			t.user_agent,
+			t.ip,
+			t.client_id,
+			(SELECT MIN(f.created_at) FROM tokens f WHERE f.branch = t.branch),
+			t.created_at
+		FROM tokens t
+		WHERE t.user_id = $1 AND t.scope = $2 AND t.status = $3 AND t.expires_at > NOW()
+		ORDER BY t.created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt, userID, models.TokenScopeRefresh, models.TokenStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions for user: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(
+			&session.Branch,
+			&session.UserAgent,
+			&session.IP,
+			&session.ClientID,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, nil
 }
 
 func (s *TokenStorage) GetAllByUserScope(userID uuid.UUID, scope models.TokenScope) ([]*models.Token, error) {
@@ -121,6 +224,10 @@ func (s *TokenStorage) GetAllByUserScope(userID uuid.UUID, scope models.TokenSco
 			branch,
 			status,
 			scope,
+			user_agent,
+			ip,
+			client_id,
+			scopes,
 			created_at,
 			expires_at
 		FROM tokens
@@ -146,6 +253,10 @@ func (s *TokenStorage) GetAllByUserScope(userID uuid.UUID, scope models.TokenSco
 			&token.Branch,
 			&token.Status,
 			&token.Scope,
+			&token.UserAgent,
+			&token.IP,
+			&token.ClientID,
+			pq.Array(&token.Scopes),
 			&token.CreatedAt,
 			&token.ExpiresAt,
 		); err != nil {
@@ -159,9 +270,52 @@ func (s *TokenStorage) GetAllByUserScope(userID uuid.UUID, scope models.TokenSco
 }
 
 func (s *TokenStorage) DeleteAllByUser(userID uuid.UUID) error {
+	stmt := `
+		DELETE FROM tokens
+		WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens for user: %w", err)
+	}
+
 	return nil
 }
 
 func (s *TokenStorage) DeleteAllByBranch(userID uuid.UUID, branch uuid.UUID) error {
+	stmt := `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND branch = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, userID, branch)
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens for branch: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TokenStorage) DeleteAllByUserExceptBranch(userID uuid.UUID, branch uuid.UUID) error {
+	stmt := `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND branch != $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, userID, branch)
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens except branch: %w", err)
+	}
+
 	return nil
 }
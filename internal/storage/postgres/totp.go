@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
+)
+
+type TOTPStorage struct {
+	db *sql.DB
+}
+
+func NewTOTPStorage(db *sql.DB) *TOTPStorage {
+	return &TOTPStorage{
+		db: db,
+	}
+}
+
+func (s *TOTPStorage) Upsert(totp *models.TOTP) error {
+	stmt := `
+		INSERT INTO totp (
+			user_id, secret
+		) VALUES (
+			$1, $2
+		) ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, enabled = FALSE, last_used_step = 0, created_at = NOW()
+		RETURNING enabled, last_used_step, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, stmt,
+		totp.UserID,
+		totp.Secret,
+	).Scan(
+		&totp.Enabled,
+		&totp.LastUsedStep,
+		&totp.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert totp: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TOTPStorage) GetByUser(userID uuid.UUID) (*models.TOTP, error) {
+	stmt := `
+		SELECT
+			user_id,
+			secret,
+			enabled,
+			last_used_step,
+			created_at
+		FROM totp
+		WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var totp models.TOTP
+	err := s.db.QueryRowContext(ctx, stmt, userID).Scan(
+		&totp.UserID,
+		&totp.Secret,
+		&totp.Enabled,
+		&totp.LastUsedStep,
+		&totp.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrTOTPNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get totp by user: %w", err)
+	}
+
+	return &totp, nil
+}
+
+func (s *TOTPStorage) Enable(userID uuid.UUID) error {
+	stmt := `
+		UPDATE totp
+		SET enabled = TRUE
+		WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTOTPNotFound
+	}
+
+	return nil
+}
+
+func (s *TOTPStorage) UpdateLastUsedStep(userID uuid.UUID, step int64) error {
+	stmt := `
+		UPDATE totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to update totp step: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update totp step: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTOTPStepUsed
+	}
+
+	return nil
+}
+
+func (s *TOTPStorage) Delete(userID uuid.UUID) error {
+	stmt := `
+		DELETE FROM totp
+		WHERE user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete totp: %w", err)
+	}
+
+	return nil
+}
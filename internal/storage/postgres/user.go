@@ -28,7 +28,7 @@ func (s *UserStorage) Insert(user *models.User) error {
             email, password, avatar_id, first_name, last_name
         ) VALUES (
             $1, $2, $3, $4, $5
-        ) RETURNING id, created_at, updated_at
+        ) RETURNING id, sign_in_alerts, created_at, updated_at
     `
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -42,6 +42,7 @@ func (s *UserStorage) Insert(user *models.User) error {
 		user.LastName,
 	).Scan(
 		&user.ID,
+		&user.SignInAlerts,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -65,11 +66,17 @@ func (s *UserStorage) GetByID(id uuid.UUID) (*models.User, error) {
 			id,
 			email,
 			password,
+			state,
 			avatar_id,
 			first_name,
 			last_name,
+			sign_in_alerts,
+			suspension_reason,
+			suspended_by,
+			suspended_until,
 			created_at,
-			updated_at
+			updated_at,
+			deleted_at
 		FROM users
 		WHERE id = $1
 	`
@@ -82,11 +89,17 @@ func (s *UserStorage) GetByID(id uuid.UUID) (*models.User, error) {
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
+		&user.State,
 		&user.AvatarID,
 		&user.FirstName,
 		&user.LastName,
+		&user.SignInAlerts,
+		&user.SuspensionReason,
+		&user.SuspendedBy,
+		&user.SuspendedUntil,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.DeletedAt,
 	)
 
 	if err != nil {
@@ -106,11 +119,17 @@ func (s *UserStorage) GetByEmail(email string) (*models.User, error) {
 			id,
 			email,
 			password,
+			state,
 			avatar_id,
 			first_name,
 			last_name,
+			sign_in_alerts,
+			suspension_reason,
+			suspended_by,
+			suspended_until,
 			created_at,
-			updated_at
+			updated_at,
+			deleted_at
 		FROM users
 		WHERE email = $1
 	`
@@ -123,11 +142,17 @@ func (s *UserStorage) GetByEmail(email string) (*models.User, error) {
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
+		&user.State,
 		&user.AvatarID,
 		&user.FirstName,
 		&user.LastName,
+		&user.SignInAlerts,
+		&user.SuspensionReason,
+		&user.SuspendedBy,
+		&user.SuspendedUntil,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.DeletedAt,
 	)
 
 	if err != nil {
@@ -168,6 +193,377 @@ func (s *UserStorage) UpdateStatus(id uuid.UUID, state models.UserState) error {
 	return nil
 }
 
+func (s *UserStorage) UpdatePassword(id uuid.UUID, passwordHash []byte) error {
+	stmt := `
+		UPDATE users
+		SET password = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to execute update user password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to execute update user password: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) ResetPassword(id uuid.UUID, codeID uuid.UUID, passwordHash []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	// the code is burned first, so two concurrent resets with the same
+	// code can not both succeed
+	stmt := `
+		DELETE FROM codes
+		WHERE id = $1 AND user_id = $2 AND scope = $3 AND expires_at > NOW()
+		RETURNING id
+	`
+
+	err = tx.QueryRowContext(ctx, stmt, codeID, id, models.CodeScopeReset).Scan(&codeID)
+	if err != nil {
+		_ = tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return models.ErrCodeNotFound
+		}
+
+		return fmt.Errorf("failed to consume reset code in transaction: %w", err)
+	}
+
+	stmt = `
+		UPDATE users
+		SET password = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, stmt, id, passwordHash); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to update user password in transaction: %w", err)
+	}
+
+	stmt = `
+		DELETE FROM tokens
+		WHERE user_id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete tokens for user in transaction: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *UserStorage) UpdateSignInAlerts(id uuid.UUID, enabled bool) error {
+	stmt := `
+		UPDATE users
+		SET sign_in_alerts = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to execute update user sign in alerts: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to execute update user sign in alerts: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) Suspend(id uuid.UUID, reason string, suspendedBy uuid.UUID, until *time.Time) error {
+	stmt := `
+		UPDATE users
+		SET
+			state = $2,
+			suspension_reason = $3,
+			suspended_by = $4,
+			suspended_until = $5,
+			updated_at = NOW()
+		WHERE id = $1 AND state IN ($6, $2)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, models.UserStateSuspended, reason, suspendedBy, until, models.UserStateActive)
+	if err != nil {
+		return fmt.Errorf("failed to execute suspend user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to execute suspend user: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) Unsuspend(id uuid.UUID) error {
+	stmt := `
+		UPDATE users
+		SET
+			state = $2,
+			suspension_reason = '',
+			suspended_by = NULL,
+			suspended_until = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND state = $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, models.UserStateActive, models.UserStateSuspended)
+	if err != nil {
+		return fmt.Errorf("failed to execute unsuspend user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to execute unsuspend user: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) UnsuspendAllExpired() error {
+	stmt := `
+		UPDATE users
+		SET
+			state = $1,
+			suspension_reason = '',
+			suspended_by = NULL,
+			suspended_until = NULL,
+			updated_at = NOW()
+		WHERE state = $2 AND suspended_until <= NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, stmt, models.UserStateActive, models.UserStateSuspended)
+	if err != nil {
+		return fmt.Errorf("failed to lift expired suspensions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *UserStorage) MarkDeleted(id uuid.UUID) error {
+	stmt := `
+		UPDATE users
+		SET state = $2, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND state = $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, models.UserStateDeleted, models.UserStateActive)
+	if err != nil {
+		return fmt.Errorf("failed to execute mark user deleted: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to execute mark user deleted: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) Restore(id uuid.UUID) error {
+	stmt := `
+		UPDATE users
+		SET state = $2, deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND state = $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id, models.UserStateActive, models.UserStateDeleted)
+	if err != nil {
+		return fmt.Errorf("failed to execute restore user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to execute restore user: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) GetAllDeletedBefore(before time.Time) ([]*models.User, error) {
+	stmt := `
+		SELECT
+			id,
+			email,
+			state,
+			avatar_id,
+			deleted_at
+		FROM users
+		WHERE state = $1 AND deleted_at <= $2
+		ORDER BY deleted_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, stmt, models.UserStateDeleted, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.State,
+			&user.AvatarID,
+			&user.DeletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
+func (s *UserStorage) DeleteByID(id uuid.UUID) error {
+	stmt := `
+		DELETE FROM users
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user by id: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete user by id: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStorage) Purge(id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	// only a user still deleted is purged, a concurrent restore wins
+	stmt := `
+		DELETE FROM users
+		WHERE id = $1 AND state = $2
+	`
+
+	result, err := tx.ExecContext(ctx, stmt, id, models.UserStateDeleted)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete user in transaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete user in transaction: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		_ = tx.Rollback()
+		return models.ErrUserNotFound
+	}
+
+	// the audit log keeps the events but forgets where they came from
+	stmt = `
+		UPDATE auth_events
+		SET user_agent = NULL, ip = NULL
+		WHERE user_id = $1 AND (user_agent IS NOT NULL OR ip IS NOT NULL)
+	`
+
+	if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to anonymize auth events in transaction: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (s *UserStorage) DeleteByEmail(email string) error {
 	stmt := `
 		DELETE FROM users
@@ -185,16 +581,16 @@ func (s *UserStorage) DeleteByEmail(email string) error {
 	return nil
 }
 
-func (s *UserStorage) DeleteByEmailIfInactive(email string) error {
+func (s *UserStorage) DeleteByEmailIfPending(email string) error {
 	stmt := `
 		DELETE FROM users
-		WHERE email = $1 AND state != $2
+		WHERE email = $1 AND state = $2
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, stmt, email, models.UserStateActive)
+	_, err := s.db.ExecContext(ctx, stmt, email, models.UserStatePending)
 	if err != nil {
 		return fmt.Errorf("failed to delete user by email: %w", err)
 	}
@@ -2,25 +2,79 @@ package storage
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 
+	objects "github.com/MartynyukAlexey/gymshark/internal/storage/minio"
 	"github.com/MartynyukAlexey/gymshark/internal/storage/models"
 	"github.com/MartynyukAlexey/gymshark/internal/storage/postgres"
 )
 
 type Storage struct {
-	User  UserStorage
-	Code  CodeStorage
-	Token TokenStorage
+	User    UserStorage
+	Code    CodeStorage
+	Token   TokenStorage
+	TOTP    TOTPStorage
+	Passkey PasskeyStorage
+
+	Identity  IdentityStorage
+	OIDCState OIDCStateStorage
+
+	OAuthClient  OAuthClientStorage
+	OAuthCode    OAuthCodeStorage
+	OAuthConsent OAuthConsentStorage
+
+	APIKey APIKeyStorage
+
+	Role RoleStorage
+
+	// postgres by default, see memory.NewAttemptStorage for single instance setups
+	Attempt AttemptStorage
+
+	EmailChange EmailChangeStorage
+
+	// wrapped into memory.NewRevokedTokenCache on startup
+	RevokedToken RevokedTokenStorage
+
+	AuthEvent AuthEventStorage
+
+	SignIn SignInStorage
+
+	Avatar AvatarStorage
 }
 
-func NewStorage(db *sql.DB, _ *minio.Client) *Storage {
+func NewStorage(db *sql.DB, minioClient *minio.Client, avatarBucket string) *Storage {
 	return &Storage{
-		User:  postgres.NewUserStorage(db),
-		Code:  postgres.NewCodeStorage(db),
-		Token: postgres.NewTokenStorage(db),
+		User:    postgres.NewUserStorage(db),
+		Code:    postgres.NewCodeStorage(db),
+		Token:   postgres.NewTokenStorage(db),
+		TOTP:    postgres.NewTOTPStorage(db),
+		Passkey: postgres.NewPasskeyStorage(db),
+
+		Identity:  postgres.NewIdentityStorage(db),
+		OIDCState: postgres.NewOIDCStateStorage(db),
+
+		OAuthClient:  postgres.NewOAuthClientStorage(db),
+		OAuthCode:    postgres.NewOAuthCodeStorage(db),
+		OAuthConsent: postgres.NewOAuthConsentStorage(db),
+
+		APIKey: postgres.NewAPIKeyStorage(db),
+
+		Role: postgres.NewRoleStorage(db),
+
+		Attempt: postgres.NewAttemptStorage(db),
+
+		EmailChange: postgres.NewEmailChangeStorage(db),
+
+		RevokedToken: postgres.NewRevokedTokenStorage(db),
+
+		AuthEvent: postgres.NewAuthEventStorage(db),
+
+		SignIn: postgres.NewSignInStorage(db),
+
+		Avatar: objects.NewAvatarStorage(minioClient, avatarBucket),
 	}
 }
 
@@ -31,9 +85,31 @@ type UserStorage interface {
 	GetByEmail(email string) (*models.User, error)
 
 	UpdateStatus(id uuid.UUID, state models.UserState) error
+	UpdatePassword(id uuid.UUID, passwordHash []byte) error
+	// burn the reset code, set the password and delete every refresh token
+	// at once (ErrCodeNotFound if the code was used or has expired)
+	ResetPassword(id uuid.UUID, codeID uuid.UUID, passwordHash []byte) error
+	UpdateSignInAlerts(id uuid.UUID, enabled bool) error
+
+	// suspend an active user or replace the suspension (ErrUserNotFound otherwise)
+	Suspend(id uuid.UUID, reason string, suspendedBy uuid.UUID, until *time.Time) error
+	// ErrUserNotFound if the user is not suspended
+	Unsuspend(id uuid.UUID) error
+	UnsuspendAllExpired() error
 
+	// move an active user to the deleted state (ErrUserNotFound otherwise)
+	MarkDeleted(id uuid.UUID) error
+	// bring a deleted user back (ErrUserNotFound if it is not deleted)
+	Restore(id uuid.UUID) error
+	// deleted users waiting for the purge since before the given time
+	GetAllDeletedBefore(before time.Time) ([]*models.User, error)
+	// delete a deleted user with everything that references it and
+	// anonymize its audit log at once (ErrUserNotFound if it is not deleted)
+	Purge(id uuid.UUID) error
+
+	DeleteByID(id uuid.UUID) error
 	DeleteByEmail(email string) error
-	DeleteByEmailIfInactive(email string) error
+	DeleteByEmailIfPending(email string) error
 }
 
 type CodeStorage interface {
@@ -42,19 +118,175 @@ type CodeStorage interface {
 	GetByID(id uuid.UUID) (*models.Code, error)
 	GetAllByUser(userID uuid.UUID, scope models.CodeScope) ([]*models.Code, error)
 
-	DeleteAllByUser(userID uuid.UUID) error
+	DeleteByID(id uuid.UUID) error
+	// delete a single-use code, ErrCodeNotFound if it was already used
+	Consume(id uuid.UUID) error
+	DeleteAllByUser(userID uuid.UUID, scope models.CodeScope) error
 	DeleteAllExpired() error
 }
 
 type TokenStorage interface {
 	Insert(token *models.Token) error
-	// insert new token and mark parent as used
+	// insert new token and mark parent as used (ErrTokenNotFound if parent is not active)
 	InsertChild(parentID uuid.UUID, token *models.Token) error
 
 	GetByID(id uuid.UUID) (*models.Token, error)
-	GetAllByUser(userID uuid.UUID) ([]*models.Token, error)
+	// active, unexpired refresh token branches of the user, most recently used first
+	GetSessions(userID uuid.UUID) ([]*models.Session, error)
 	GetAllByUserScope(userID uuid.UUID, scope models.TokenScope) ([]*models.Token, error)
 
 	DeleteAllByUser(userID uuid.UUID) error
 	DeleteAllByBranch(userID uuid.UUID, branch uuid.UUID) error
+	// delete every branch of the user but the given one
+	DeleteAllByUserExceptBranch(userID uuid.UUID, branch uuid.UUID) error
+}
+
+type TOTPStorage interface {
+	// insert a new unconfirmed secret or replace the existing one
+	Upsert(totp *models.TOTP) error
+
+	GetByUser(userID uuid.UUID) (*models.TOTP, error)
+
+	Enable(userID uuid.UUID) error
+	// set the last used step if it is newer than the stored one (ErrTOTPStepUsed otherwise)
+	UpdateLastUsedStep(userID uuid.UUID, step int64) error
+
+	Delete(userID uuid.UUID) error
+}
+
+type PasskeyStorage interface {
+	Insert(passkey *models.Passkey) error
+
+	GetByID(id []byte) (*models.Passkey, error)
+	GetAllByUser(userID uuid.UUID) ([]*models.Passkey, error)
+
+	// store the new signature counter and mark the passkey as used
+	UpdateSignCount(id []byte, signCount uint32) error
+
+	Delete(userID uuid.UUID, id []byte) error
+}
+
+type IdentityStorage interface {
+	Insert(identity *models.Identity) error
+
+	Get(provider string, subject string) (*models.Identity, error)
+}
+
+type OIDCStateStorage interface {
+	Insert(state *models.OIDCState) error
+
+	GetByID(id uuid.UUID) (*models.OIDCState, error)
+
+	// ErrOIDCStateNotFound if the state is already deleted
+	DeleteByID(id uuid.UUID) error
+}
+
+type OAuthClientStorage interface {
+	Insert(client *models.OAuthClient) error
+
+	GetByID(id uuid.UUID) (*models.OAuthClient, error)
+	GetAllByOwner(ownerID uuid.UUID) ([]*models.OAuthClient, error)
+
+	Delete(ownerID uuid.UUID, id uuid.UUID) error
+}
+
+type OAuthCodeStorage interface {
+	Insert(code *models.OAuthCode) error
+
+	GetByID(id uuid.UUID) (*models.OAuthCode, error)
+
+	// ErrOAuthCodeNotFound if the code is already deleted
+	DeleteByID(id uuid.UUID) error
+}
+
+type OAuthConsentStorage interface {
+	Upsert(consent *models.OAuthConsent) error
+
+	Get(userID uuid.UUID, clientID uuid.UUID) (*models.OAuthConsent, error)
+}
+
+type APIKeyStorage interface {
+	Insert(key *models.APIKey) error
+
+	GetByID(id uuid.UUID) (*models.APIKey, error)
+	GetAllByUser(userID uuid.UUID) ([]*models.APIKey, error)
+
+	UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error
+
+	Delete(userID uuid.UUID, id uuid.UUID) error
+}
+
+type RoleStorage interface {
+	GetAll() ([]*models.Role, error)
+	GetPermissionsByRole(role string) ([]string, error)
+	// roles of the user and the permissions they grant
+	GetByUser(userID uuid.UUID) ([]string, []string, error)
+	CountUsers(role string) (int, error)
+
+	Grant(userID uuid.UUID, role string, grantedBy uuid.NullUUID) error
+	Revoke(userID uuid.UUID, role string) error
+}
+
+// AttemptStorage counts failed login attempts.
+type AttemptStorage interface {
+	// an empty attempt if there were no failures
+	Get(key string) (*models.Attempt, error)
+
+	// count a failure, failures older than window are forgotten
+	RecordFailure(key string, window time.Duration) (*models.Attempt, error)
+	// lock the key and start counting from zero
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+
+	DeleteAllStale(before time.Time) error
+}
+
+type EmailChangeStorage interface {
+	// insert a pending change or replace the one the user already has
+	Upsert(change *models.EmailChange) error
+
+	GetByID(id uuid.UUID) (*models.EmailChange, error)
+	GetByUser(userID uuid.UUID) (*models.EmailChange, error)
+
+	// set the new email and drop the change (ErrDuplicateEmail if it is taken)
+	Apply(id uuid.UUID) error
+	DeleteByID(id uuid.UUID) error
+}
+
+// RevokedTokenStorage is the denylist of access tokens revoked before
+// they expire.
+type RevokedTokenStorage interface {
+	// insert the entry or move the existing one forward
+	Upsert(token *models.RevokedToken) error
+
+	// ErrRevokedTokenNotFound if there is no entry or it has expired
+	Get(key string) (*models.RevokedToken, error)
+	// every entry that has not expired
+	GetAll() ([]*models.RevokedToken, error)
+
+	DeleteAllExpired() error
+}
+
+// AuthEventStorage is the append-only security audit log.
+type AuthEventStorage interface {
+	Insert(event *models.AuthEvent) error
+
+	GetAll(filter *models.AuthEventFilter) ([]*models.AuthEvent, error)
+	// what is known about earlier successful logins of the user
+	GetDeviceHistory(userID uuid.UUID, userAgent string, ip string) (*models.DeviceHistory, error)
+}
+
+// SignInStorage keeps the logins users were alerted about.
+type SignInStorage interface {
+	Insert(signIn *models.SignIn) error
+
+	GetByID(id uuid.UUID) (*models.SignIn, error)
+
+	DeleteByID(id uuid.UUID) error
+	DeleteAllExpired() error
+}
+
+// AvatarStorage keeps the profile pictures, AvatarID of a user is the key.
+type AvatarStorage interface {
+	Delete(id string) error
 }
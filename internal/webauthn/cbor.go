@@ -0,0 +1,129 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// a minimal CBOR (RFC 8949) decoder, enough for attestation objects and
+// COSE keys: integers, byte and text strings, arrays, maps and simple
+// values of definite length. Integers decode to int64, maps to map[any]any.
+
+var errInvalidCBOR = errors.New("invalid cbor")
+
+const maxCBORDepth = 16
+
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if len(data) == 0 || depth > maxCBORDepth {
+		return nil, nil, errInvalidCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	// simple values and floats carry no length argument
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		default:
+			return nil, nil, errInvalidCBOR
+		}
+	}
+
+	arg, data, err := decodeCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, errInvalidCBOR
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, errInvalidCBOR
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return append([]byte(nil), value...), data[arg:], nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+
+		items := make([]any, 0, arg)
+		for range arg {
+			var item any
+			if item, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+
+		items := make(map[any]any, arg)
+		for range arg {
+			var key, value any
+			if key, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errInvalidCBOR
+			}
+
+			if value, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, data, nil
+	default:
+		// tags are not used by webauthn
+		return nil, nil, errInvalidCBOR
+	}
+}
+
+func decodeCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	default:
+		// indefinite lengths and reserved values
+		return 0, nil, errInvalidCBOR
+	}
+}
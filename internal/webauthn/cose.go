@@ -0,0 +1,126 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// COSE algorithm identifiers (RFC 9053) offered to authenticators
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key parameters
+const (
+	coseKeyType = 1
+	coseAlg     = 3
+
+	coseCurve = -1 // EC2, OKP
+	coseX     = -2 // EC2, OKP
+	coseY     = -3 // EC2
+	coseN     = -1 // RSA
+	coseE     = -2 // RSA
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+var ErrUnsupportedKey = errors.New("unsupported public key")
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key as stored with the credential.
+func parsePublicKey(data []byte) (*publicKey, error) {
+	value, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		return nil, errInvalidCBOR
+	}
+
+	params, ok := value.(map[any]any)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	alg, _ := params[int64(coseAlg)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && alg == AlgES256:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrUnsupportedKey
+		}
+
+		return &publicKey{alg: alg, key: key}, nil
+	case keyType == coseKeyTypeOKP && alg == AlgEdDSA:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+	case keyType == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := params[int64(coseN)].([]byte)
+		e, _ := params[int64(coseE)].([]byte)
+
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+
+		return &publicKey{alg: alg, key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func (k *publicKey) verify(message, signature []byte) bool {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,323 @@
+// Package webauthn implements the relying party side of the WebAuthn
+// registration and authentication ceremonies.
+//
+// Attestation is not requested ("none" conveyance), so attestation
+// statements are not verified: a credential is trusted because it was
+// registered from an authenticated session, not because of its vendor.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidClientData = errors.New("invalid client data")
+	ErrInvalidAuthData   = errors.New("invalid authenticator data")
+	ErrChallengeMismatch = errors.New("challenge mismatch")
+	ErrOriginMismatch    = errors.New("origin not allowed")
+	ErrRPIDMismatch      = errors.New("relying party id mismatch")
+	ErrUserNotPresent    = errors.New("user presence flag not set")
+	ErrInvalidSignature  = errors.New("invalid signature")
+)
+
+// authenticator data flags
+const (
+	flagUserPresent   = 0x01
+	flagAttestedCreds = 0x40
+)
+
+type Config struct {
+	RPID    string
+	RPName  string
+	Origins []string
+	// how long the client may wait for the authenticator
+	Timeout time.Duration
+}
+
+// Base64URL is binary data encoded as unpadded base64url in JSON, the
+// encoding used by the WebAuthn JSON serialization.
+type Base64URL []byte
+
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type User struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string    `json:"type"`
+	ID   Base64URL `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are passed to navigator.credentials.create.
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   User                   `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+	Timeout                int64                  `json:"timeout"`
+}
+
+// RequestOptions are passed to navigator.credentials.get.
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+	Timeout          int64                  `json:"timeout"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AttestationObject Base64URL `json:"attestationObject"`
+}
+
+type AssertionResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AuthenticatorData Base64URL `json:"authenticatorData"`
+	Signature         Base64URL `json:"signature"`
+	UserHandle        Base64URL `json:"userHandle"`
+}
+
+// Registration is a verified new credential.
+type Registration struct {
+	CredentialID []byte
+	// COSE_Key, passed back to VerifyAssertion
+	PublicKey []byte
+	SignCount uint32
+}
+
+func (c *Config) NewCreationOptions(challenge []byte, user User, exclude [][]byte) CreationOptions {
+	options := CreationOptions{
+		Challenge: challenge,
+		RP: RelyingParty{
+			ID:   c.RPID,
+			Name: c.RPName,
+		},
+		User: user,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		ExcludeCredentials: make([]CredentialDescriptor, 0, len(exclude)),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+		Timeout:     c.Timeout.Milliseconds(),
+	}
+
+	for _, id := range exclude {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, CredentialDescriptor{
+			Type: "public-key",
+			ID:   id,
+		})
+	}
+
+	return options
+}
+
+func (c *Config) NewRequestOptions(challenge []byte, allow [][]byte) RequestOptions {
+	options := RequestOptions{
+		Challenge:        challenge,
+		RPID:             c.RPID,
+		AllowCredentials: make([]CredentialDescriptor, 0, len(allow)),
+		UserVerification: "preferred",
+		Timeout:          c.Timeout.Milliseconds(),
+	}
+
+	for _, id := range allow {
+		options.AllowCredentials = append(options.AllowCredentials, CredentialDescriptor{
+			Type: "public-key",
+			ID:   id,
+		})
+	}
+
+	return options
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create
+// against the challenge issued for the ceremony.
+func (c *Config) VerifyRegistration(resp *AttestationResponse, challenge []byte) (*Registration, error) {
+	if err := c.verifyClientData(resp.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	value, _, err := decodeCBOR(resp.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	attestation, ok := value.(map[any]any)
+	if !ok {
+		return nil, errInvalidCBOR
+	}
+
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidAuthData
+	}
+
+	signCount, flags, err := c.parseAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags&flagAttestedCreds == 0 {
+		return nil, ErrInvalidAuthData
+	}
+
+	// attested credential data: aaguid (16), id length (2), id, COSE_Key
+	data := authData[37:]
+	if len(data) < 18 {
+		return nil, ErrInvalidAuthData
+	}
+
+	idLength := int(binary.BigEndian.Uint16(data[16:18]))
+	data = data[18:]
+	if idLength == 0 || len(data) < idLength {
+		return nil, ErrInvalidAuthData
+	}
+
+	credentialID := data[:idLength]
+	data = data[idLength:]
+
+	// the key is followed by extensions if the ED flag is set
+	_, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	publicKeyData := data[:len(data)-len(rest)]
+
+	if _, err := parsePublicKey(publicKeyData); err != nil {
+		return nil, err
+	}
+
+	return &Registration{
+		CredentialID: bytes.Clone(credentialID),
+		PublicKey:    bytes.Clone(publicKeyData),
+		SignCount:    signCount,
+	}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get with the
+// stored public key and returns the new signature counter.
+func (c *Config) VerifyAssertion(resp *AssertionResponse, challenge []byte, publicKeyData []byte) (uint32, error) {
+	if err := c.verifyClientData(resp.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	signCount, _, err := c.parseAuthData(resp.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(publicKeyData)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	message := append(bytes.Clone(resp.AuthenticatorData), clientDataHash[:]...)
+
+	if !key.verify(message, resp.Signature) {
+		return 0, ErrInvalidSignature
+	}
+
+	return signCount, nil
+}
+
+func (c *Config) verifyClientData(data []byte, ceremony string, challenge []byte) error {
+	var clientData struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}
+
+	if err := json.Unmarshal(data, &clientData); err != nil {
+		return ErrInvalidClientData
+	}
+
+	if clientData.Type != ceremony {
+		return ErrInvalidClientData
+	}
+
+	received, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(clientData.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return ErrChallengeMismatch
+	}
+
+	if !slices.Contains(c.Origins, clientData.Origin) {
+		return ErrOriginMismatch
+	}
+
+	return nil
+}
+
+// parseAuthData checks the fixed part of the authenticator data:
+// rp id hash (32), flags (1) and signature counter (4).
+func (c *Config) parseAuthData(authData []byte) (uint32, byte, error) {
+	if len(authData) < 37 {
+		return 0, 0, ErrInvalidAuthData
+	}
+
+	rpIDHash := sha256.Sum256([]byte(c.RPID))
+	if subtle.ConstantTimeCompare(authData[:32], rpIDHash[:]) != 1 {
+		return 0, 0, ErrRPIDMismatch
+	}
+
+	flags := authData[32]
+	if flags&flagUserPresent == 0 {
+		return 0, 0, ErrUserNotPresent
+	}
+
+	return binary.BigEndian.Uint32(authData[33:37]), flags, nil
+}
@@ -0,0 +1,153 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/MartynyukAlexey/gymshark/internal/webauthn"
+	"github.com/MartynyukAlexey/gymshark/internal/webauthn/webauthntest"
+)
+
+const origin = "https://gymshark.example"
+
+var config = &webauthn.Config{
+	RPID:    "gymshark.example",
+	RPName:  "gymshark",
+	Origins: []string{origin},
+}
+
+func TestRegistration(t *testing.T) {
+	challenge := []byte("registration challenge")
+
+	tests := []struct {
+		name      string
+		forge     func(a *webauthntest.Authenticator)
+		challenge []byte
+		want      error
+	}{
+		{
+			name:      "valid",
+			forge:     func(a *webauthntest.Authenticator) {},
+			challenge: challenge,
+		},
+		{
+			name:      "wrong origin",
+			forge:     func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" },
+			challenge: challenge,
+			want:      webauthn.ErrOriginMismatch,
+		},
+		{
+			name:      "wrong rp id",
+			forge:     func(a *webauthntest.Authenticator) { a.RPID = "evil.example" },
+			challenge: challenge,
+			want:      webauthn.ErrRPIDMismatch,
+		},
+		{
+			name:      "wrong challenge",
+			forge:     func(a *webauthntest.Authenticator) {},
+			challenge: []byte("another challenge"),
+			want:      webauthn.ErrChallengeMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(config.RPID, origin)
+			tt.forge(authenticator)
+
+			resp := authenticator.Create(tt.challenge)
+
+			registration, err := config.VerifyRegistration(&resp, challenge)
+			if err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !bytes.Equal(registration.CredentialID, authenticator.CredentialID) {
+				t.Errorf("got credential id %x, want %x", registration.CredentialID, authenticator.CredentialID)
+			}
+
+			if !bytes.Equal(registration.PublicKey, authenticator.PublicKey()) {
+				t.Errorf("got public key %x, want %x", registration.PublicKey, authenticator.PublicKey())
+			}
+		})
+	}
+}
+
+func TestAssertion(t *testing.T) {
+	challenge := []byte("login challenge")
+
+	tests := []struct {
+		name      string
+		forge     func(a *webauthntest.Authenticator)
+		tamper    func(resp *webauthn.AssertionResponse)
+		challenge []byte
+		want      error
+	}{
+		{
+			name:      "valid",
+			challenge: challenge,
+		},
+		{
+			name:      "wrong origin",
+			forge:     func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" },
+			challenge: challenge,
+			want:      webauthn.ErrOriginMismatch,
+		},
+		{
+			name:      "wrong challenge",
+			challenge: []byte("another challenge"),
+			want:      webauthn.ErrChallengeMismatch,
+		},
+		{
+			name:      "raised sign count",
+			tamper:    func(resp *webauthn.AssertionResponse) { resp.AuthenticatorData[36]++ },
+			challenge: challenge,
+			want:      webauthn.ErrInvalidSignature,
+		},
+		{
+			name:      "user not present",
+			tamper:    func(resp *webauthn.AssertionResponse) { resp.AuthenticatorData[32] = 0 },
+			challenge: challenge,
+			want:      webauthn.ErrUserNotPresent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(config.RPID, origin)
+			if tt.forge != nil {
+				tt.forge(authenticator)
+			}
+
+			resp := authenticator.Get(tt.challenge, nil)
+			if tt.tamper != nil {
+				tt.tamper(&resp)
+			}
+
+			signCount, err := config.VerifyAssertion(&resp, challenge, authenticator.PublicKey())
+			if err != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if err == nil && signCount != authenticator.SignCount {
+				t.Errorf("got sign count %d, want %d", signCount, authenticator.SignCount)
+			}
+		})
+	}
+}
+
+func TestAssertionWrongKey(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(config.RPID, origin)
+	other := webauthntest.NewAuthenticator(config.RPID, origin)
+
+	challenge := []byte("login challenge")
+	resp := authenticator.Get(challenge, nil)
+
+	if _, err := config.VerifyAssertion(&resp, challenge, other.PublicKey()); err != webauthn.ErrInvalidSignature {
+		t.Errorf("got %v, want %v", err, webauthn.ErrInvalidSignature)
+	}
+}
@@ -0,0 +1,168 @@
+// Package webauthntest provides a software authenticator for testing the
+// relying party side of the WebAuthn ceremonies.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/MartynyukAlexey/gymshark/internal/webauthn"
+)
+
+// Authenticator holds a single ES256 credential. The fields the client
+// reports can be changed between ceremonies to forge bad responses.
+type Authenticator struct {
+	CredentialID []byte
+	// rp id the authenticator data is made for
+	RPID string
+	// origin the client data reports
+	Origin string
+	// counter of the last assertion, incremented before every signature
+	SignCount uint32
+
+	key *ecdsa.PrivateKey
+}
+
+func NewAuthenticator(rpID string, origin string) *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		panic(err)
+	}
+
+	return &Authenticator{
+		CredentialID: credentialID,
+		RPID:         rpID,
+		Origin:       origin,
+		key:          key,
+	}
+}
+
+// Create answers navigator.credentials.create with a "none" attestation.
+func (a *Authenticator) Create(challenge []byte) webauthn.AttestationResponse {
+	authData := a.authData(0x41)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, a.PublicKey()...)
+
+	var attestation []byte
+	attestation = appendHead(attestation, 5, 3)
+	attestation = appendText(attestation, "fmt")
+	attestation = appendText(attestation, "none")
+	attestation = appendText(attestation, "attStmt")
+	attestation = appendHead(attestation, 5, 0)
+	attestation = appendText(attestation, "authData")
+	attestation = appendBytes(attestation, authData)
+
+	return webauthn.AttestationResponse{
+		ClientDataJSON:    a.clientData("webauthn.create", challenge),
+		AttestationObject: attestation,
+	}
+}
+
+// Get answers navigator.credentials.get, userHandle may be nil.
+func (a *Authenticator) Get(challenge []byte, userHandle []byte) webauthn.AssertionResponse {
+	a.SignCount++
+
+	authData := a.authData(0x01)
+	clientData := a.clientData("webauthn.get", challenge)
+
+	return webauthn.AssertionResponse{
+		ClientDataJSON:    clientData,
+		AuthenticatorData: authData,
+		Signature:         a.sign(authData, clientData),
+		UserHandle:        userHandle,
+	}
+}
+
+// PublicKey is the COSE_Key of the credential.
+func (a *Authenticator) PublicKey() []byte {
+	var key []byte
+	key = appendHead(key, 5, 5)
+	key = appendInt(key, 1)
+	key = appendInt(key, 2) // EC2
+	key = appendInt(key, 3)
+	key = appendInt(key, webauthn.AlgES256)
+	key = appendInt(key, -1)
+	key = appendInt(key, 1) // P-256
+	key = appendInt(key, -2)
+	key = appendBytes(key, a.key.X.FillBytes(make([]byte, 32)))
+	key = appendInt(key, -3)
+	key = appendBytes(key, a.key.Y.FillBytes(make([]byte, 32)))
+
+	return key
+}
+
+func (a *Authenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+
+	authData := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, a.SignCount)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.Origin,
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func (a *Authenticator) sign(authData []byte, clientData []byte) []byte {
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return signature
+}
+
+// CBOR encoding of the few items authenticators produce
+
+func appendHead(data []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(data, major<<5|byte(n))
+	case n <= 0xff:
+		return append(data, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(data, major<<5|25), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(data, major<<5|26), uint32(n))
+	}
+}
+
+func appendInt(data []byte, n int64) []byte {
+	if n < 0 {
+		return appendHead(data, 1, uint64(-1-n))
+	}
+
+	return appendHead(data, 0, uint64(n))
+}
+
+func appendBytes(data []byte, b []byte) []byte {
+	return append(appendHead(data, 2, uint64(len(b))), b...)
+}
+
+func appendText(data []byte, s string) []byte {
+	return append(appendHead(data, 3, uint64(len(s))), s...)
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_tokens_branch";
+
+ALTER TABLE "tokens"
+    DROP COLUMN IF EXISTS "user_agent",
+    DROP COLUMN IF EXISTS "ip";
\ No newline at end of file
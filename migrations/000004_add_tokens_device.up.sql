@@ -0,0 +1,5 @@
+ALTER TABLE "tokens"
+    ADD COLUMN "user_agent"    TEXT                            NOT NULL DEFAULT '',
+    ADD COLUMN "ip"            TEXT                            NOT NULL DEFAULT '';
+
+CREATE INDEX "idx_tokens_branch" ON tokens("branch");
\ No newline at end of file
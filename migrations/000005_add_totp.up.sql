@@ -0,0 +1,12 @@
+ALTER TYPE "code_scope" ADD VALUE IF NOT EXISTS 'recovery';
+ALTER TYPE "code_scope" ADD VALUE IF NOT EXISTS 'mfa';
+
+CREATE TABLE IF NOT EXISTS "totp" (
+    "user_id"           UUID                            PRIMARY KEY,
+    "secret"            BYTEA                           NOT NULL,
+    "enabled"           BOOLEAN                         NOT NULL DEFAULT FALSE,
+    "last_used_step"    BIGINT                          NOT NULL DEFAULT 0,
+    "created_at"        TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
\ No newline at end of file
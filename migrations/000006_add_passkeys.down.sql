@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "passkeys";
+
+DELETE FROM "codes" WHERE "scope" = 'webauthn';
+
+ALTER TYPE "code_scope" RENAME TO "code_scope_old";
+CREATE TYPE "code_scope" AS ENUM ('reset', 'confirm', 'recovery', 'mfa');
+ALTER TABLE "codes" ALTER COLUMN "scope" TYPE "code_scope" USING "scope"::TEXT::"code_scope";
+DROP TYPE "code_scope_old";
\ No newline at end of file
@@ -0,0 +1,15 @@
+ALTER TYPE "code_scope" ADD VALUE IF NOT EXISTS 'webauthn';
+
+CREATE TABLE IF NOT EXISTS "passkeys" (
+    "id"            BYTEA                           PRIMARY KEY,
+    "user_id"       UUID                            NOT NULL,
+    "name"          TEXT                            NOT NULL,
+    "public_key"    BYTEA                           NOT NULL,
+    "sign_count"    BIGINT                          NOT NULL DEFAULT 0,
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "last_used_at"  TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_passkeys_user_id" ON passkeys("user_id");
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "oidc_states" (
+    "id"            UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "provider"      TEXT                            NOT NULL,
+    "hash"          BYTEA                           NOT NULL,
+    "nonce"         TEXT                            NOT NULL,
+    "verifier"      TEXT                            NOT NULL,
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "expires_at"    TIMESTAMP WITH TIME ZONE        NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "identities" (
+    "provider"      TEXT                            NOT NULL,
+    "subject"       TEXT                            NOT NULL,
+    "user_id"       UUID                            NOT NULL,
+    "email"         CITEXT                          NOT NULL,
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY ("provider", "subject"),
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_identities_user_id" ON identities("user_id");
\ No newline at end of file
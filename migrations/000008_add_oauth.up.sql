@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS "oauth_clients" (
+    "id"              UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "owner_id"        UUID                            NOT NULL,
+    "secret_hash"     BYTEA,
+    "name"            TEXT                            NOT NULL,
+    "redirect_uris"   TEXT[]                          NOT NULL DEFAULT '{}',
+    "scopes"          TEXT[]                          NOT NULL DEFAULT '{}',
+    "grant_types"     TEXT[]                          NOT NULL DEFAULT '{}',
+    "created_at"      TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_oauth_clients_owner_id" ON oauth_clients("owner_id");
+
+CREATE TABLE IF NOT EXISTS "oauth_codes" (
+    "id"              UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "client_id"       UUID                            NOT NULL,
+    "user_id"         UUID                            NOT NULL,
+    "hash"            BYTEA                           NOT NULL,
+    "redirect_uri"    TEXT                            NOT NULL,
+    "scopes"          TEXT[]                          NOT NULL DEFAULT '{}',
+    "code_challenge"  TEXT                            NOT NULL,
+    "nonce"           TEXT                            NOT NULL DEFAULT '',
+    "created_at"      TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "expires_at"      TIMESTAMP WITH TIME ZONE        NOT NULL,
+
+    FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "oauth_consents" (
+    "user_id"         UUID                            NOT NULL,
+    "client_id"       UUID                            NOT NULL,
+    "scopes"          TEXT[]                          NOT NULL DEFAULT '{}',
+    "created_at"      TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "updated_at"      TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY ("user_id", "client_id"),
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id") ON DELETE CASCADE
+);
+
+-- refresh token branches of partner apps
+ALTER TABLE "tokens"
+    ADD COLUMN "client_id"     UUID                            REFERENCES "oauth_clients" ("id") ON DELETE CASCADE,
+    ADD COLUMN "scopes"        TEXT[]                          NOT NULL DEFAULT '{}';
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "id"            UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "user_id"       UUID                            NOT NULL,
+    "hash"          BYTEA                           NOT NULL,
+    "name"          TEXT                            NOT NULL,
+    "scopes"        TEXT[]                          NOT NULL DEFAULT '{}',
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "expires_at"    TIMESTAMP WITH TIME ZONE,
+    "last_used_at"  TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_api_keys_user_id" ON api_keys("user_id");
\ No newline at end of file
@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS "roles" (
+    "name"          TEXT                            PRIMARY KEY,
+    "description"   TEXT                            NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "name"          TEXT                            PRIMARY KEY,
+    "description"   TEXT                            NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+    "role"          TEXT                            NOT NULL REFERENCES "roles" ("name") ON DELETE CASCADE,
+    "permission"    TEXT                            NOT NULL REFERENCES "permissions" ("name") ON DELETE CASCADE,
+
+    PRIMARY KEY ("role", "permission")
+);
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+    "user_id"       UUID                            NOT NULL,
+    "role"          TEXT                            NOT NULL,
+    "granted_by"    UUID,
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY ("user_id", "role"),
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("role") REFERENCES "roles" ("name") ON DELETE CASCADE,
+    FOREIGN KEY ("granted_by") REFERENCES "users" ("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_user_roles_role" ON user_roles("role");
+
+INSERT INTO "roles" ("name", "description") VALUES
+    ('member',      'Gym member'),
+    ('trainer',     'Trainer working with members'),
+    ('gym_admin',   'Administrator of a gym'),
+    ('superadmin',  'Administrator of the whole service');
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('members:read',            'See profiles of members'),
+    ('roles:read',              'See roles of users'),
+    ('roles:manage',            'Grant and revoke roles'),
+    ('oauth_clients:manage',    'Register partner apps'),
+    ('users:manage',            'Manage accounts of users');
+
+INSERT INTO "role_permissions" ("role", "permission") VALUES
+    ('trainer',     'members:read'),
+    ('gym_admin',   'members:read'),
+    ('gym_admin',   'roles:read'),
+    ('gym_admin',   'roles:manage'),
+    ('gym_admin',   'oauth_clients:manage'),
+    ('superadmin',  'members:read'),
+    ('superadmin',  'roles:read'),
+    ('superadmin',  'roles:manage'),
+    ('superadmin',  'oauth_clients:manage'),
+    ('superadmin',  'users:manage');
+
+-- every existing user is a member
+INSERT INTO "user_roles" ("user_id", "role")
+    SELECT "id", 'member' FROM "users";
\ No newline at end of file
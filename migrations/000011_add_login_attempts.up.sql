@@ -0,0 +1,10 @@
+ALTER TYPE "code_scope" ADD VALUE IF NOT EXISTS 'unlock';
+
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+    "key"               TEXT                            PRIMARY KEY,
+    "failures"          INTEGER                         NOT NULL DEFAULT 0,
+    "last_failed_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "locked_until"      TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX "idx_login_attempts_last_failed_at" ON login_attempts("last_failed_at");
\ No newline at end of file
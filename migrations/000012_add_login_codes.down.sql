@@ -0,0 +1,6 @@
+DELETE FROM "codes" WHERE "scope" = 'login';
+
+ALTER TYPE "code_scope" RENAME TO "code_scope_old";
+CREATE TYPE "code_scope" AS ENUM ('reset', 'confirm', 'recovery', 'mfa', 'webauthn', 'unlock');
+ALTER TABLE "codes" ALTER COLUMN "scope" TYPE "code_scope" USING "scope"::TEXT::"code_scope";
+DROP TYPE "code_scope_old";
\ No newline at end of file
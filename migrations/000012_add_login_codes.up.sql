@@ -0,0 +1 @@
+ALTER TYPE "code_scope" ADD VALUE IF NOT EXISTS 'login';
\ No newline at end of file
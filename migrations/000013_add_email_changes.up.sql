@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "email_changes" (
+    "id"            UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "user_id"       UUID                            NOT NULL UNIQUE,
+    "new_email"     CITEXT                          NOT NULL,
+    "code_hash"     BYTEA                           NOT NULL,
+    "cancel_hash"   BYTEA                           NOT NULL,
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "expires_at"    TIMESTAMP WITH TIME ZONE        NOT NULL,
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+    "key"           TEXT                            PRIMARY KEY,
+    "revoked_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "expires_at"    TIMESTAMP WITH TIME ZONE        NOT NULL
+);
+
+CREATE INDEX "idx_revoked_tokens_expires_at" ON revoked_tokens("expires_at");
\ No newline at end of file
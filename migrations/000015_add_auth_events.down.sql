@@ -0,0 +1,7 @@
+DELETE FROM "permissions" WHERE "name" = 'audit:read';
+
+DROP TABLE IF EXISTS "auth_events";
+DROP FUNCTION IF EXISTS "auth_events_append_only";
+
+DROP TYPE IF EXISTS "auth_event_outcome";
+DROP TYPE IF EXISTS "auth_event_type";
\ No newline at end of file
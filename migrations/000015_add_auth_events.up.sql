@@ -0,0 +1,45 @@
+CREATE TYPE "auth_event_type" AS ENUM (
+    'register',
+    'confirm',
+    'login',
+    'refresh',
+    'refresh_reuse',
+    'logout',
+    'logout_all',
+    'password_change',
+    'password_reset'
+);
+
+CREATE TYPE "auth_event_outcome" AS ENUM ('success', 'failure');
+
+-- no foreign key: the history outlives the account
+CREATE TABLE IF NOT EXISTS "auth_events" (
+    "id"            UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "user_id"       UUID,
+    "type"          "auth_event_type"               NOT NULL,
+    "outcome"       "auth_event_outcome"            NOT NULL,
+    "detail"        TEXT                            NOT NULL DEFAULT '',
+    "user_agent"    TEXT                            NOT NULL DEFAULT '',
+    "ip"            TEXT                            NOT NULL DEFAULT '',
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "idx_auth_events_user_id_created_at" ON auth_events("user_id", "created_at");
+CREATE INDEX "idx_auth_events_created_at" ON auth_events("created_at");
+
+-- the log is append-only
+CREATE FUNCTION "auth_events_append_only"() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_auth_events_append_only"
+    BEFORE UPDATE OR DELETE ON "auth_events"
+    FOR EACH ROW EXECUTE FUNCTION "auth_events_append_only"();
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('audit:read',              'See the security audit log');
+
+INSERT INTO "role_permissions" ("role", "permission") VALUES
+    ('superadmin',  'audit:read');
\ No newline at end of file
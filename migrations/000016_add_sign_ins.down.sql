@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "sign_ins";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "sign_in_alerts";
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE "users" ADD COLUMN "sign_in_alerts" BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS "sign_ins" (
+    "id"            UUID                            PRIMARY KEY DEFAULT gen_random_uuid(),
+    "user_id"       UUID                            NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "branch"        UUID                            NOT NULL,
+    "report_hash"   BYTEA                           NOT NULL,
+    "created_at"    TIMESTAMP WITH TIME ZONE        NOT NULL DEFAULT NOW(),
+    "expires_at"    TIMESTAMP WITH TIME ZONE        NOT NULL
+);
+
+CREATE INDEX "idx_sign_ins_expires_at" ON sign_ins("expires_at");
\ No newline at end of file
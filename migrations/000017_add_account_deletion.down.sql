@@ -0,0 +1,8 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
+
+DELETE FROM "codes" WHERE "scope" = 'restore';
+
+ALTER TYPE "code_scope" RENAME TO "code_scope_old";
+CREATE TYPE "code_scope" AS ENUM ('reset', 'confirm', 'recovery', 'mfa', 'webauthn', 'unlock', 'login');
+ALTER TABLE "codes" ALTER COLUMN "scope" TYPE "code_scope" USING "scope"::TEXT::"code_scope";
+DROP TYPE "code_scope_old";
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TYPE "code_scope" ADD VALUE IF NOT EXISTS 'restore';
+
+-- set while a deleted account waits for the purge
+ALTER TABLE "users" ADD COLUMN "deleted_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX "idx_users_deleted_at" ON users("deleted_at");
\ No newline at end of file
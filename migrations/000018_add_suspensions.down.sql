@@ -0,0 +1,13 @@
+UPDATE "users" SET "state" = 'active' WHERE "state" = 'suspended';
+
+ALTER TABLE "users"
+    DROP COLUMN IF EXISTS "suspension_reason",
+    DROP COLUMN IF EXISTS "suspended_by",
+    DROP COLUMN IF EXISTS "suspended_until";
+
+ALTER TABLE "users" ALTER COLUMN "state" DROP DEFAULT;
+ALTER TYPE "user_state" RENAME TO "user_state_old";
+CREATE TYPE "user_state" AS ENUM ('pending', 'active', 'deleted');
+ALTER TABLE "users" ALTER COLUMN "state" TYPE "user_state" USING "state"::TEXT::"user_state";
+ALTER TABLE "users" ALTER COLUMN "state" SET DEFAULT 'pending';
+DROP TYPE "user_state_old";
\ No newline at end of file
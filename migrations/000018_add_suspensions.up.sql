@@ -0,0 +1,9 @@
+ALTER TYPE "user_state" ADD VALUE IF NOT EXISTS 'suspended';
+
+-- set while the user is suspended, no end date means until lifted
+ALTER TABLE "users"
+    ADD COLUMN "suspension_reason"  TEXT                        NOT NULL DEFAULT '',
+    ADD COLUMN "suspended_by"       UUID                        REFERENCES "users" ("id") ON DELETE SET NULL,
+    ADD COLUMN "suspended_until"    TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX "idx_users_suspended_until" ON users("suspended_until");
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE OR REPLACE FUNCTION "auth_events_append_only"() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "auth_events" DISABLE TRIGGER "trg_auth_events_append_only";
+
+UPDATE "auth_events"
+SET "user_agent" = COALESCE("user_agent", ''), "ip" = COALESCE("ip", '')
+WHERE "user_agent" IS NULL OR "ip" IS NULL;
+
+ALTER TABLE "auth_events" ENABLE TRIGGER "trg_auth_events_append_only";
+
+ALTER TABLE "auth_events"
+    ALTER COLUMN "user_agent" SET NOT NULL,
+    ALTER COLUMN "ip" SET NOT NULL;
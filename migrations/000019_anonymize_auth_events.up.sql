@@ -0,0 +1,20 @@
+-- the client of events of purged accounts is erased, the rest is kept
+ALTER TABLE "auth_events"
+    ALTER COLUMN "user_agent" DROP NOT NULL,
+    ALTER COLUMN "ip" DROP NOT NULL;
+
+-- the log stays append-only, the only update allowed clears the client
+CREATE OR REPLACE FUNCTION "auth_events_append_only"() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW."user_agent" IS NULL
+        AND NEW."ip" IS NULL
+        AND (NEW."id", NEW."user_id", NEW."type", NEW."outcome", NEW."detail", NEW."created_at")
+            IS NOT DISTINCT FROM (OLD."id", OLD."user_id", OLD."type", OLD."outcome", OLD."detail", OLD."created_at")
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,39 @@
+CREATE OR REPLACE FUNCTION "auth_events_append_only"() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW."user_agent" IS NULL
+        AND NEW."ip" IS NULL
+        AND (NEW."id", NEW."user_id", NEW."type", NEW."outcome", NEW."detail", NEW."created_at")
+            IS NOT DISTINCT FROM (OLD."id", OLD."user_id", OLD."type", OLD."outcome", OLD."detail", OLD."created_at")
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "auth_events" DISABLE TRIGGER "trg_auth_events_append_only";
+
+DELETE FROM "auth_events" WHERE "type" IN ('suspend', 'unsuspend');
+
+ALTER TABLE "auth_events" ENABLE TRIGGER "trg_auth_events_append_only";
+
+ALTER TABLE "auth_events" DROP COLUMN IF EXISTS "actor_id";
+
+ALTER TYPE "auth_event_type" RENAME TO "auth_event_type_old";
+CREATE TYPE "auth_event_type" AS ENUM (
+    'register',
+    'confirm',
+    'login',
+    'refresh',
+    'refresh_reuse',
+    'logout',
+    'logout_all',
+    'password_change',
+    'password_reset'
+);
+ALTER TABLE "auth_events" ALTER COLUMN "type" TYPE "auth_event_type" USING "type"::TEXT::"auth_event_type";
+DROP TYPE "auth_event_type_old";
+
+DELETE FROM "permissions" WHERE "name" = 'users:suspend';
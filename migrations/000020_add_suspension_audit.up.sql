@@ -0,0 +1,27 @@
+ALTER TYPE "auth_event_type" ADD VALUE IF NOT EXISTS 'suspend';
+ALTER TYPE "auth_event_type" ADD VALUE IF NOT EXISTS 'unsuspend';
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('users:suspend',           'Suspend accounts and lift suspensions');
+
+INSERT INTO "role_permissions" ("role", "permission") VALUES
+    ('gym_admin',   'users:suspend'),
+    ('superadmin',  'users:suspend');
+
+-- the administrator who acted on the account, null for the user's own actions
+ALTER TABLE "auth_events" ADD COLUMN "actor_id" UUID;
+
+CREATE OR REPLACE FUNCTION "auth_events_append_only"() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW."user_agent" IS NULL
+        AND NEW."ip" IS NULL
+        AND (NEW."id", NEW."user_id", NEW."actor_id", NEW."type", NEW."outcome", NEW."detail", NEW."created_at")
+            IS NOT DISTINCT FROM (OLD."id", OLD."user_id", OLD."actor_id", OLD."type", OLD."outcome", OLD."detail", OLD."created_at")
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;